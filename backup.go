@@ -0,0 +1,181 @@
+package genji
+
+import (
+	"errors"
+	"io"
+
+	"github.com/asdine/genji/engine"
+	"github.com/asdine/genji/internal/archive"
+)
+
+// A backup archive starts with a header, followed by a list of entries,
+// each one starting with its kind:
+//
+//	store: the name of a store, the following pairs belong to that store
+//	pair: a key and its value
+//	end: the CRC-32 checksum of all the preceding bytes
+//
+// Names, keys and values are prefixed by their length.
+const (
+	backupMagic   = "GENJIBAK"
+	backupVersion = 1
+
+	backupStore byte = 's'
+	backupPair  byte = 'p'
+	backupEnd   byte = 'e'
+)
+
+// number of pairs written by each transaction during a restore,
+// engines like Badger limit the size of transactions.
+const restoreBatchSize = 10000
+
+var errCorruptedBackup = errors.New("backup corrupted")
+
+// Backup writes the content of every store of the database to w, including the system tables and the indexes.
+// The backup is written within a single read transaction, it is therefore consistent even if the database
+// is modified at the same time. The archive doesn't depend on the engine and can be restored to any
+// other engine using Restore.
+func (db DB) Backup(w io.Writer) error {
+	tx, err := db.ng.Begin(false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	bw := archive.NewWriter(w)
+	bw.Write([]byte(backupMagic))
+	bw.Write([]byte{backupVersion})
+
+	names, err := tx.ListStores("")
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		st, err := tx.Store(name)
+		if err != nil {
+			return err
+		}
+
+		bw.Write([]byte{backupStore})
+		bw.WriteBytes([]byte(name))
+
+		err = st.AscendGreaterOrEqual(nil, func(k, v []byte) error {
+			bw.Write([]byte{backupPair})
+			bw.WriteBytes(k)
+			bw.WriteBytes(v)
+			return bw.Err()
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	bw.Write([]byte{backupEnd})
+	return bw.Close()
+}
+
+// Restore loads a backup created by DB.Backup into ng, which must be empty.
+// The restored database can then be opened by passing ng to New.
+// The archive is written using multiple transactions and its checksum can only be verified
+// once it has been read entirely: if an error is returned, ng may contain part of the backup
+// and must be discarded.
+func Restore(r io.Reader, ng engine.Engine) error {
+	br := archive.NewReader(r, errCorruptedBackup)
+
+	header := br.Read(len(backupMagic) + 1)
+	if br.Err() != nil || string(header[:len(backupMagic)]) != backupMagic {
+		return errors.New("not a backup")
+	}
+	if header[len(backupMagic)] != backupVersion {
+		return errors.New("unsupported backup version")
+	}
+
+	tx, err := ng.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		tx.Rollback()
+	}()
+
+	names, err := tx.ListStores("")
+	if err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		return errors.New("backups can only be restored to an empty engine")
+	}
+
+	var name string
+	var st engine.Store
+	var count int
+
+	for {
+		kind := br.Read(1)
+		if br.Err() != nil {
+			return br.Err()
+		}
+
+		switch kind[0] {
+		case backupStore:
+			name = string(br.ReadBytes())
+			if br.Err() != nil {
+				return br.Err()
+			}
+
+			err = tx.CreateStore(name)
+			if err != nil {
+				return err
+			}
+
+			st, err = tx.Store(name)
+			if err != nil {
+				return err
+			}
+		case backupPair:
+			k := br.ReadBytes()
+			v := br.ReadBytes()
+			if br.Err() != nil {
+				return br.Err()
+			}
+			if st == nil {
+				return errCorruptedBackup
+			}
+
+			err = st.Put(k, v)
+			if err != nil {
+				return err
+			}
+
+			count++
+			if count%restoreBatchSize != 0 {
+				continue
+			}
+
+			err = tx.Commit()
+			if err != nil {
+				return err
+			}
+
+			tx, err = ng.Begin(true)
+			if err != nil {
+				return err
+			}
+
+			st, err = tx.Store(name)
+			if err != nil {
+				return err
+			}
+		case backupEnd:
+			err = br.Close()
+			if err != nil {
+				return err
+			}
+
+			return tx.Commit()
+		default:
+			return errCorruptedBackup
+		}
+	}
+}
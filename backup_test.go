@@ -0,0 +1,104 @@
+package genji_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/asdine/genji"
+	"github.com/asdine/genji/engine"
+	"github.com/asdine/genji/engine/bolt"
+	"github.com/asdine/genji/engine/memory"
+	"github.com/asdine/genji/record/recordutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupRestore(t *testing.T) {
+	db, err := genji.New(memory.NewEngine())
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE users;
+		CREATE UNIQUE INDEX idx_users_name ON users (name);
+		CREATE SEQUENCE seq;
+		INSERT INTO users (id, name) VALUES (NEXTVAL('seq'), 'seq1'), (NEXTVAL('seq'), 'seq2');
+	`)
+	require.NoError(t, err)
+
+	for i := 3; i <= 20; i++ {
+		err = db.Exec("INSERT INTO users (id, name) VALUES (?, ?)", i, fmt.Sprintf("user%02d", i))
+		require.NoError(t, err)
+	}
+
+	var buf bytes.Buffer
+	err = db.Backup(&buf)
+	require.NoError(t, err)
+	backup := buf.Bytes()
+
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bng, err := bolt.NewEngine(path.Join(dir, "test.db"), 0600, nil)
+	require.NoError(t, err)
+
+	for name, ng := range map[string]engine.Engine{"memory": memory.NewEngine(), "bolt": bng} {
+		t.Run(name, func(t *testing.T) {
+			err := genji.Restore(bytes.NewReader(backup), ng)
+			require.NoError(t, err)
+
+			db, err := genji.New(ng)
+			require.NoError(t, err)
+			defer db.Close()
+
+			res, err := db.Query("SELECT id FROM users WHERE name >= 'user18'")
+			require.NoError(t, err)
+			var buf bytes.Buffer
+			err = recordutil.IteratorToCSV(&buf, res)
+			require.NoError(t, err)
+			require.NoError(t, res.Close())
+			require.Equal(t, "18\n19\n20\n", buf.String())
+
+			// the unique index is restored
+			err = db.Exec("INSERT INTO users (id, name) VALUES (21, 'user03')")
+			require.Error(t, err)
+
+			// and so are the sequences
+			err = db.Update(func(tx *genji.Tx) error {
+				n, err := tx.NextValue("seq")
+				require.Equal(t, int64(3), n)
+				return err
+			})
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("Not empty", func(t *testing.T) {
+		ng := memory.NewEngine()
+		_, err := genji.New(ng)
+		require.NoError(t, err)
+
+		err = genji.Restore(bytes.NewReader(backup), ng)
+		require.Error(t, err)
+	})
+
+	t.Run("Corrupted", func(t *testing.T) {
+		corrupted := append([]byte{}, backup...)
+		corrupted[len(corrupted)/2]++
+
+		for _, data := range [][]byte{
+			nil,
+			[]byte("not a backup"),
+			backup[:len(backup)-1],
+			backup[:len(backup)/2],
+			corrupted,
+		} {
+			err := genji.Restore(bytes.NewReader(data), memory.NewEngine())
+			require.Error(t, err)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/asdine/genji"
+	"github.com/pkg/errors"
+)
+
+// backup writes a backup of the database located at the path given in args
+// to the standard output.
+//
+//	genji backup [-e bolt|badger|logfile] path > backup
+func backup(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	engineName := fs.String("e", "bolt", "engine used to store the database, bolt, badger or logfile")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s backup [-e bolt|badger|logfile] path > backup\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	path := fs.Arg(0)
+
+	// opening a path that doesn't exist would create an empty database
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	db, err := openDB(*engineName, path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	w := bufio.NewWriter(os.Stdout)
+	err = db.Backup(w)
+	if err != nil {
+		return errors.Wrap(err, "failed to backup database")
+	}
+
+	return w.Flush()
+}
+
+// restore creates the database located at the path given in args
+// from a backup read from the standard input.
+// Combined with backup, it can be used to migrate a database to another engine.
+//
+//	genji restore [-e bolt|badger|logfile] path < backup
+func restore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	engineName := fs.String("e", "bolt", "engine used to store the database, bolt, badger or logfile")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s restore [-e bolt|badger|logfile] path < backup\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	path := fs.Arg(0)
+
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+
+	ng, err := openEngine(*engineName, path)
+	if err != nil {
+		return err
+	}
+
+	err = genji.Restore(bufio.NewReader(os.Stdin), ng)
+	if err != nil {
+		ng.Close()
+		os.RemoveAll(path)
+		return errors.Wrap(err, "failed to restore database")
+	}
+
+	return ng.Close()
+}
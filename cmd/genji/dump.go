@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// dump writes the SQL statements reproducing the database located at the path given in args
+// to the standard output.
+//
+//	genji dump [-e bolt|badger|logfile] path > dump.sql
+func dump(args []string) error {
+	fs := flag.NewFlagSet("dump", flag.ExitOnError)
+	engineName := fs.String("e", "bolt", "engine used to store the database, bolt, badger or logfile")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s dump [-e bolt|badger|logfile] path > dump.sql\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	path := fs.Arg(0)
+
+	// opening a path that doesn't exist would create an empty database
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	db, err := openDB(*engineName, path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	w := bufio.NewWriter(os.Stdout)
+	err = db.Dump(w)
+	if err != nil {
+		return errors.Wrap(err, "failed to dump database")
+	}
+
+	return w.Flush()
+}
+
+// load executes the SQL statements read from the standard input against the database
+// located at the path given in args, which is created if it doesn't exist.
+//
+//	genji load [-e bolt|badger|logfile] path < dump.sql
+func load(args []string) error {
+	fs := flag.NewFlagSet("load", flag.ExitOnError)
+	engineName := fs.String("e", "bolt", "engine used to store the database, bolt, badger or logfile")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s load [-e bolt|badger|logfile] path < dump.sql\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	db, err := openDB(*engineName, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	err = db.Load(bufio.NewReader(os.Stdin))
+	if err != nil {
+		return errors.Wrap(err, "failed to load statements")
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/asdine/genji"
+	"github.com/asdine/genji/engine"
+	"github.com/asdine/genji/engine/badger"
+	"github.com/asdine/genji/engine/bolt"
+	"github.com/asdine/genji/engine/logfile"
+	bdg "github.com/dgraph-io/badger"
+	"github.com/pkg/errors"
+)
+
+// openEngine opens the database located at path using the engine called name.
+func openEngine(name, path string) (engine.Engine, error) {
+	var ng engine.Engine
+	var err error
+
+	switch name {
+	case "bolt":
+		ng, err = bolt.NewEngine(path, 0600, nil)
+	case "badger":
+		ng, err = badger.NewEngine(bdg.DefaultOptions(path))
+	case "logfile":
+		ng, err = logfile.NewEngine(path, nil)
+	default:
+		return nil, fmt.Errorf("unknown engine %q", name)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open database at location %s", path)
+	}
+
+	return ng, nil
+}
+
+// openDB opens the database located at path using the engine called name.
+func openDB(engineName, path string) (*genji.DB, error) {
+	ng, err := openEngine(engineName, path)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := genji.New(ng)
+	if err != nil {
+		ng.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
@@ -12,6 +12,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/asdine/genji"
 	"github.com/asdine/genji/cmd/genji/generator/testdata"
@@ -28,6 +29,7 @@ func TestGenerate(t *testing.T) {
 			{"Basic"},
 			{"basic"},
 			{"Pk"},
+			{"Times"},
 		}
 
 		f, err := os.Open("testdata/structs.go")
@@ -263,4 +265,27 @@ func TestGeneratedRecords(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, value.EncodeInt64(10), pk)
 	})
+
+	t.Run("Times", func(t *testing.T) {
+		r := testdata.Times{
+			A: time.Date(2019, 11, 3, 10, 0, 0, 0, time.UTC), B: time.Hour,
+		}
+
+		f, err := r.GetField("A")
+		require.NoError(t, err)
+		require.Equal(t, value.Timestamp, f.Type)
+
+		f, err = r.GetField("B")
+		require.NoError(t, err)
+		require.Equal(t, value.Duration, f.Type)
+
+		pk, err := r.PrimaryKey()
+		require.NoError(t, err)
+		require.Equal(t, value.EncodeTimestamp(r.A), pk)
+
+		var r2 testdata.Times
+		err = r2.Scan(&r)
+		require.NoError(t, err)
+		require.Equal(t, r, r2)
+	})
 }
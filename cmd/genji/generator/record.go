@@ -161,23 +161,9 @@ func (rctx *recordContext) lookupRecord(f *ast.File, target string) (bool, error
 		rctx.Name = target
 
 		for _, fd := range s.Fields.List {
-			var typeName string
-
-			typ, ok := fd.Type.(*ast.Ident)
-			if !ok {
-				atyp, ok := fd.Type.(*ast.ArrayType)
-				if !ok {
-					return false, errors.New("struct must only contain supported fields")
-				}
-
-				typ, ok = atyp.Elt.(*ast.Ident)
-				if !ok || typ.Name != "byte" {
-					return false, errors.New("struct must only contain supported fields")
-				}
-
-				typeName = "[]byte"
-			} else {
-				typeName = typ.Name
+			typeName, err := fieldTypeName(fd.Type)
+			if err != nil {
+				return false, err
 			}
 
 			if len(fd.Names) == 0 {
@@ -210,6 +196,27 @@ func (rctx *recordContext) lookupRecord(f *ast.File, target string) (bool, error
 	return false, nil
 }
 
+// fieldTypeName returns the name of the type of a struct field, as it would be written in Go,
+// e.g. []byte or time.Time.
+func fieldTypeName(e ast.Expr) (string, error) {
+	switch t := e.(type) {
+	case *ast.Ident:
+		return t.Name, nil
+	case *ast.ArrayType:
+		typ, ok := t.Elt.(*ast.Ident)
+		if ok && t.Len == nil && typ.Name == "byte" {
+			return "[]byte", nil
+		}
+	case *ast.SelectorExpr:
+		pkg, ok := t.X.(*ast.Ident)
+		if ok && pkg.Name == "time" {
+			return "time." + t.Sel.Name, nil
+		}
+	}
+
+	return "", errors.New("struct must only contain supported fields")
+}
+
 func (rctx *recordContext) IsExported() bool {
 	return unicode.IsUpper(rune(rctx.Name[0]))
 }
@@ -275,9 +282,14 @@ func handleGenjiTag(ctx *recordContext, fd *ast.Field) error {
 				return errors.New("only one pk field is allowed")
 			}
 
+			typeName, err := fieldTypeName(fd.Type)
+			if err != nil {
+				return err
+			}
+
 			ctx.Pk.Name = fd.Names[0].Name
-			ctx.Pk.Type = value.TypeFromGoType(fd.Type.(*ast.Ident).Name).String()
-			ctx.Pk.GoType = fd.Type.(*ast.Ident).Name
+			ctx.Pk.Type = value.TypeFromGoType(typeName).String()
+			ctx.Pk.GoType = typeName
 		default:
 			return fmt.Errorf("unsupported genji tag '%s'", gtag)
 		}
@@ -221,3 +221,65 @@ func (p *Pk) Scan(src interface{}) error {
 func (p *Pk) PrimaryKey() ([]byte, error) {
 	return value.EncodeInt64(p.B), nil
 }
+
+// GetField implements the field method of the record.Record interface.
+func (t *Times) GetField(name string) (record.Field, error) {
+	switch name {
+	case "A":
+		return record.NewTimestampField("A", t.A), nil
+	case "B":
+		return record.NewDurationField("B", t.B), nil
+	}
+
+	return record.Field{}, errors.New("unknown field")
+}
+
+// Iterate through all the fields one by one and pass each of them to the given function.
+// It the given function returns an error, the iteration is interrupted.
+func (t *Times) Iterate(fn func(record.Field) error) error {
+	var err error
+
+	err = fn(record.NewTimestampField("A", t.A))
+	if err != nil {
+		return err
+	}
+
+	err = fn(record.NewDurationField("B", t.B))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ScanRecord extracts fields from record and assigns them to the struct fields.
+// It implements the record.Scanner interface.
+func (t *Times) ScanRecord(rec record.Record) error {
+	return rec.Iterate(func(f record.Field) error {
+		var err error
+
+		switch f.Name {
+		case "A":
+			t.A, err = f.DecodeToTimestamp()
+		case "B":
+			t.B, err = f.DecodeToDuration()
+		}
+		return err
+	})
+}
+
+// Scan extracts fields from src and assigns them to the struct fields.
+// It implements the driver.Scanner interface.
+func (t *Times) Scan(src interface{}) error {
+	r, ok := src.(record.Record)
+	if !ok {
+		return errors.New("unable to scan record from src")
+	}
+
+	return t.ScanRecord(r)
+}
+
+// PrimaryKey returns the primary key. It implements the table.PrimaryKeyer interface.
+func (t *Times) PrimaryKey() ([]byte, error) {
+	return value.EncodeTimestamp(t.A), nil
+}
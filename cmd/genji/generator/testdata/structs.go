@@ -1,5 +1,7 @@
 package testdata
 
+import "time"
+
 // Basic is the simplest struct that can be used with Genji: No tags, no methods, no comments.
 // This must not be generated by the generator.
 type Basic struct {
@@ -21,3 +23,9 @@ type Pk struct {
 	A string
 	B int64 `genji:"pk"`
 }
+
+// Times contains fields of type time.Time and time.Duration.
+type Times struct {
+	A time.Time `genji:"pk"`
+	B time.Duration
+}
@@ -26,6 +26,24 @@ func (i *stringFlags) Set(value string) error {
 }
 
 func main() {
+	commands := map[string]func([]string) error{
+		"upgrade": upgrade,
+		"backup":  backup,
+		"restore": restore,
+		"dump":    dump,
+		"load":    load,
+	}
+
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			err := cmd(os.Args[2:])
+			if err != nil {
+				fail("%v\n", err)
+			}
+			return
+		}
+	}
+
 	var files, structs stringFlags
 	var output string
 
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/asdine/genji"
+	"github.com/asdine/genji/record"
+	"github.com/pkg/errors"
+)
+
+// upgrade rewrites the database located at the path given in args
+// using the latest version of the record format and rebuilds its indexes.
+//
+//	genji upgrade [-e bolt|badger|logfile] path
+func upgrade(args []string) error {
+	fs := flag.NewFlagSet("upgrade", flag.ExitOnError)
+	engineName := fs.String("e", "bolt", "engine used to store the database, bolt, badger or logfile")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s upgrade [-e bolt|badger|logfile] path\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	path := fs.Arg(0)
+
+	// opening a path that doesn't exist would create an empty database
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	ng, err := openEngine(*engineName, path)
+	if err != nil {
+		return err
+	}
+
+	db, err := genji.New(ng)
+	if err != nil {
+		ng.Close()
+		return err
+	}
+	defer db.Close()
+
+	from, err := db.FormatVersion()
+	if err != nil {
+		return err
+	}
+
+	if from == record.FormatVersion {
+		fmt.Printf("database already uses the latest format version %d\n", from)
+		return nil
+	}
+
+	err = db.Upgrade()
+	if err != nil {
+		return errors.Wrap(err, "failed to upgrade database")
+	}
+
+	fmt.Printf("database format upgraded from version %d to version %d\n", from, record.FormatVersion)
+	return nil
+}
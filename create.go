@@ -3,9 +3,13 @@ package genji
 import (
 	"database/sql/driver"
 	"errors"
+	"math"
+	"strconv"
+	"strings"
 
 	"github.com/asdine/genji/index"
 	"github.com/asdine/genji/internal/scanner"
+	"github.com/asdine/genji/value"
 )
 
 // parseCreateStatement parses a create string and returns a Statement AST object.
@@ -23,9 +27,11 @@ func (p *parser) parseCreateStatement() (statement, error) {
 		return p.parseCreateIndexStatement(true)
 	case scanner.INDEX:
 		return p.parseCreateIndexStatement(false)
+	case scanner.SEQUENCE:
+		return p.parseCreateSequenceStatement()
 	}
 
-	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX"}, pos)
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX", "SEQUENCE"}, pos)
 }
 
 // parseCreateTableStatement parses a create table string and returns a Statement AST object.
@@ -41,24 +47,285 @@ func (p *parser) parseCreateTableStatement() (createTableStmt, error) {
 	}
 
 	// Parse "IF"
-	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.IF {
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.IF {
+		// Parse "NOT"
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.NOT {
+			return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"NOT", "EXISTS"}, pos)
+		}
+
+		// Parse "EXISTS"
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.EXISTS {
+			return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"EXISTS"}, pos)
+		}
+
+		stmt.ifNotExists = true
+	} else {
 		p.Unscan()
-		return stmt, nil
 	}
 
-	// Parse "NOT"
-	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.NOT {
-		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"NOT", "EXISTS"}, pos)
+	// Parse field constraints: (fieldName TYPE CONSTRAINTS, ...)
+	err = p.parseFieldConstraints(&stmt.config)
+	if err != nil {
+		return stmt, err
+	}
+
+	// Parse table options, in any order
+	for {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		switch tok {
+		case scanner.IDENT:
+			// COMPRESSED isn't a keyword so that it can still be used as a field name
+			if strings.EqualFold(lit, "compressed") {
+				if stmt.config.Compressed {
+					return stmt, &ParseError{Message: "duplicate COMPRESSED option", Pos: pos}
+				}
+				stmt.config.Compressed = true
+				continue
+			}
+
+			// UUID isn't a keyword since it is also a type name
+			if !strings.EqualFold(lit, "uuid") {
+				p.Unscan()
+				return stmt, nil
+			}
+			if stmt.config.KeyGenerator != ULIDKeyGenerator {
+				return stmt, &ParseError{Message: "duplicate key generator option", Pos: pos}
+			}
+			stmt.config.KeyGenerator = UUIDKeyGenerator
+
+			// Parse optional ORDERED
+			if tok, _, lit := p.ScanIgnoreWhitespace(); tok == scanner.IDENT && strings.EqualFold(lit, "ordered") {
+				stmt.config.KeyGenerator = OrderedUUIDKeyGenerator
+			} else {
+				p.Unscan()
+			}
+		case scanner.STRICT:
+			if stmt.config.Strict {
+				return stmt, &ParseError{Message: "duplicate STRICT option", Pos: pos}
+			}
+			stmt.config.Strict = true
+		case scanner.AUTOINCREMENT:
+			if stmt.config.KeyGenerator != ULIDKeyGenerator {
+				return stmt, &ParseError{Message: "duplicate AUTOINCREMENT option", Pos: pos}
+			}
+			stmt.config.KeyGenerator = SequenceKeyGenerator
+		default:
+			p.Unscan()
+			return stmt, nil
+		}
+	}
+}
+
+// parseFieldConstraints parses a list of field constraints and table constraints
+// in the form: (fieldName TYPE CONSTRAINTS, ..., CHECK (expr), ...), if exists.
+func (p *parser) parseFieldConstraints(cfg *TableConfig) error {
+	// Parse ( token.
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+		p.Unscan()
+		return nil
 	}
 
-	// Parse "EXISTS"
-	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.EXISTS {
-		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"EXISTS"}, pos)
+	for {
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.CHECK {
+			check, err := p.parseCheckConstraint()
+			if err != nil {
+				return err
+			}
+			cfg.Checks = append(cfg.Checks, check)
+		} else {
+			p.Unscan()
+			fc, err := p.parseFieldConstraint(cfg)
+			if err != nil {
+				return err
+			}
+			cfg.FieldConstraints = append(cfg.FieldConstraints, fc)
+		}
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
 	}
 
-	stmt.ifNotExists = true
+	// Parse required ) token.
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		return newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	}
 
-	return stmt, nil
+	return nil
+}
+
+// parseFieldConstraint parses a field name followed by an optional type and a list of constraints.
+// CHECK constraints are added to the checks of cfg.
+func (p *parser) parseFieldConstraint(cfg *TableConfig) (FieldConstraint, error) {
+	var fc FieldConstraint
+	var err error
+
+	// Parse field name
+	fc.Name, err = p.ParseIdent()
+	if err != nil {
+		return fc, err
+	}
+
+	// Parse optional type
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.IDENT || tok == scanner.DURATION {
+		p.Unscan()
+		fc.Type, err = p.parseType()
+		if err != nil {
+			return fc, err
+		}
+	} else {
+		p.Unscan()
+	}
+
+	// Parse constraints
+	for {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		switch tok {
+		case scanner.NOT:
+			// Parse "NULL"
+			if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.NULL {
+				return fc, newParseError(scanner.Tokstr(tok, lit), []string{"NULL"}, pos)
+			}
+			if fc.NotNull {
+				return fc, &ParseError{Message: "duplicate NOT NULL constraint", Pos: pos}
+			}
+			fc.NotNull = true
+		case scanner.UNIQUE:
+			if fc.Unique {
+				return fc, &ParseError{Message: "duplicate UNIQUE constraint", Pos: pos}
+			}
+			fc.Unique = true
+		case scanner.DEFAULT:
+			if fc.DefaultValue != "" {
+				return fc, &ParseError{Message: "duplicate DEFAULT constraint", Pos: pos}
+			}
+			e, err := p.parseConstraintExpr()
+			if err != nil {
+				return fc, err
+			}
+			fc.DefaultValue = e.String()
+		case scanner.CHECK:
+			check, err := p.parseCheckConstraint()
+			if err != nil {
+				return fc, err
+			}
+			cfg.Checks = append(cfg.Checks, check)
+		case scanner.REFERENCES:
+			if fc.ForeignKey != nil {
+				return fc, &ParseError{Message: "duplicate REFERENCES constraint", Pos: pos}
+			}
+			fc.ForeignKey, err = p.parseForeignKey()
+			if err != nil {
+				return fc, err
+			}
+		case scanner.COMMA, scanner.RPAREN:
+			p.Unscan()
+			return fc, nil
+		default:
+			return fc, newParseError(scanner.Tokstr(tok, lit), []string{"NOT NULL", "UNIQUE", "DEFAULT", "CHECK", "REFERENCES", ",", ")"}, pos)
+		}
+	}
+}
+
+// parseCheckConstraint parses a check constraint in the form: CHECK (expr) and returns
+// the expression as a string.
+// This function assumes the CHECK token has already been consumed.
+func (p *parser) parseCheckConstraint() (string, error) {
+	// Parse ( token.
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+		return "", newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+	}
+
+	e, err := p.parseConstraintExpr()
+	if err != nil {
+		return "", err
+	}
+
+	// Parse required ) token.
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		return "", newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	return e.String(), nil
+}
+
+// parseForeignKey parses a foreign key in the form: REFERENCES tableName [(fieldName)] [ON DELETE action].
+// This function assumes the REFERENCES token has already been consumed.
+func (p *parser) parseForeignKey() (*ForeignKey, error) {
+	var fk ForeignKey
+	var err error
+
+	// Parse table name
+	fk.Table, err = p.ParseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse optional field name
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.LPAREN {
+		fk.Field, err = p.ParseIdent()
+		if err != nil {
+			return nil, err
+		}
+
+		// Parse required ) token.
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+		}
+	} else {
+		p.Unscan()
+	}
+
+	// Parse "ON"
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.ON {
+		p.Unscan()
+		return &fk, nil
+	}
+
+	// Parse "DELETE"
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.DELETE {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"DELETE"}, pos)
+	}
+
+	// Parse action
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch tok {
+	case scanner.RESTRICT:
+		fk.OnDelete = ForeignKeyRestrict
+	case scanner.CASCADE:
+		fk.OnDelete = ForeignKeyCascade
+	case scanner.SET:
+		// Parse "NULL"
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.NULL {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"NULL"}, pos)
+		}
+		fk.OnDelete = ForeignKeySetNull
+	default:
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"RESTRICT", "CASCADE", "SET NULL"}, pos)
+	}
+
+	return &fk, nil
+}
+
+// parseType parses a type name, e.g. INT8 or STRING, and returns the corresponding value type.
+// Type names are case insensitive.
+func (p *parser) parseType() (value.Type, error) {
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	// DURATION is a keyword, it can't be read as an identifier
+	if tok == scanner.DURATION {
+		return value.Duration, nil
+	}
+
+	if tok == scanner.IDENT {
+		for t := value.Type(1); t.String() != ""; t++ {
+			if strings.EqualFold(t.String(), lit) {
+				return t, nil
+			}
+		}
+	}
+
+	return 0, newParseError(scanner.Tokstr(tok, lit), []string{"type"}, pos)
 }
 
 // parseCreateIndexStatement parses a create index string and returns a Statement AST object.
@@ -103,13 +370,18 @@ func (p *parser) parseCreateIndexStatement(unique bool) (createIndexStmt, error)
 		return stmt, err
 	}
 
-	fields, ok, err := p.parseFieldList()
+	// Parse the list of indexed fields, which can be paths to nested fields.
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+	}
+
+	fields, err := p.parseFieldPathList()
 	if err != nil {
 		return stmt, err
 	}
-	if !ok {
-		tok, pos, lit := p.ScanIgnoreWhitespace()
-		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
 	}
 
 	if len(fields) != 1 {
@@ -125,6 +397,7 @@ func (p *parser) parseCreateIndexStatement(unique bool) (createIndexStmt, error)
 type createTableStmt struct {
 	tableName   string
 	ifNotExists bool
+	config      TableConfig
 }
 
 // IsReadOnly always returns false. It implements the Statement interface.
@@ -141,7 +414,12 @@ func (stmt createTableStmt) Run(tx *Tx, args []driver.NamedValue) (Result, error
 		return res, errors.New("missing table name")
 	}
 
-	_, err := tx.CreateTable(stmt.tableName)
+	var cfg *TableConfig
+	if !stmt.config.isZero() {
+		cfg = &stmt.config
+	}
+
+	_, err := tx.CreateTableWithConfig(stmt.tableName, cfg)
 	if stmt.ifNotExists && err == ErrTableAlreadyExists {
 		err = nil
 	}
@@ -188,3 +466,91 @@ func (stmt createIndexStmt) Run(tx *Tx, args []driver.NamedValue) (Result, error
 
 	return res, err
 }
+
+// parseCreateSequenceStatement parses a create sequence string and returns a Statement AST object.
+// This function assumes the CREATE SEQUENCE tokens have already been consumed.
+func (p *parser) parseCreateSequenceStatement() (createSequenceStmt, error) {
+	var stmt createSequenceStmt
+	var err error
+
+	// Parse "IF"
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.IF {
+		// Parse "NOT"
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.NOT {
+			return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"NOT", "EXISTS"}, pos)
+		}
+
+		// Parse "EXISTS"
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.EXISTS {
+			return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"EXISTS"}, pos)
+		}
+
+		stmt.ifNotExists = true
+	} else {
+		p.Unscan()
+	}
+
+	// Parse sequence name
+	stmt.sequenceName, err = p.ParseIdent()
+	if err != nil {
+		return stmt, err
+	}
+
+	// Parse optional START [WITH] n.
+	// START and WITH aren't keywords so that they can still be used as field names
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "start") {
+		p.Unscan()
+		return stmt, nil
+	}
+
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "with") {
+		p.Unscan()
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok == scanner.SUB {
+		tok, pos, lit = p.Scan()
+		lit = "-" + lit
+	}
+	if tok != scanner.INTEGER {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"integer"}, pos)
+	}
+
+	start, err := strconv.ParseInt(lit, 10, 64)
+	if err != nil || start == math.MinInt64 {
+		return stmt, &ParseError{Message: "invalid start value", Pos: pos}
+	}
+	stmt.value = start - 1
+
+	return stmt, nil
+}
+
+// createSequenceStmt is a DSL that allows creating a full CREATE SEQUENCE statement.
+type createSequenceStmt struct {
+	sequenceName string
+	ifNotExists  bool
+	// value of the sequence before the first call to NEXTVAL.
+	value int64
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt createSequenceStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the Create sequence statement in the given transaction.
+// It implements the Statement interface.
+func (stmt createSequenceStmt) Run(tx *Tx, args []driver.NamedValue) (Result, error) {
+	var res Result
+
+	if stmt.sequenceName == "" {
+		return res, errors.New("missing sequence name")
+	}
+
+	err := tx.createSequence(stmt.sequenceName, stmt.value)
+	if stmt.ifNotExists && err == ErrSequenceAlreadyExists {
+		err = nil
+	}
+
+	return res, err
+}
@@ -1,9 +1,14 @@
 package genji
 
 import (
+	"bytes"
+	"errors"
 	"testing"
 
 	"github.com/asdine/genji/engine/memory"
+	"github.com/asdine/genji/record"
+	"github.com/asdine/genji/record/recordutil"
+	"github.com/asdine/genji/value"
 	"github.com/stretchr/testify/require"
 )
 
@@ -16,6 +21,62 @@ func TestParserCreateTable(t *testing.T) {
 	}{
 		{"Basic", "CREATE TABLE test", createTableStmt{tableName: "test"}, false},
 		{"If not exists", "CREATE TABLE test IF NOT EXISTS", createTableStmt{tableName: "test", ifNotExists: true}, false},
+		{"With field constraints", "CREATE TABLE test (Name STRING NOT NULL, Age int8, Email STRING UNIQUE, Any)",
+			createTableStmt{tableName: "test", config: TableConfig{FieldConstraints: []FieldConstraint{
+				{Name: "Name", Type: value.String, NotNull: true},
+				{Name: "Age", Type: value.Int8},
+				{Name: "Email", Type: value.String, Unique: true},
+				{Name: "Any"},
+			}}}, false},
+		{"Time types", "CREATE TABLE test (CreatedAt TIMESTAMP DEFAULT NOW(), Timeout DURATION DEFAULT 10s)",
+			createTableStmt{tableName: "test", config: TableConfig{FieldConstraints: []FieldConstraint{
+				{Name: "CreatedAt", Type: value.Timestamp, DefaultValue: "NOW()"},
+				{Name: "Timeout", Type: value.Duration, DefaultValue: "10s"},
+			}}}, false},
+		{"Strict", "CREATE TABLE test IF NOT EXISTS (Name STRING) STRICT",
+			createTableStmt{tableName: "test", ifNotExists: true, config: TableConfig{Strict: true, FieldConstraints: []FieldConstraint{
+				{Name: "Name", Type: value.String},
+			}}}, false},
+		{"Default and check", "CREATE TABLE test (Name STRING DEFAULT 'foo', Age INT8 CHECK (Age >= 0) DEFAULT -1, CHECK (Age < 100 OR Name = 'bar'))",
+			createTableStmt{tableName: "test", config: TableConfig{
+				FieldConstraints: []FieldConstraint{
+					{Name: "Name", Type: value.String, DefaultValue: "'foo'"},
+					{Name: "Age", Type: value.Int8, DefaultValue: "-1"},
+				},
+				Checks: []string{"Age >= 0", "Age < 100 OR Name = 'bar'"},
+			}}, false},
+		{"Autoincrement", "CREATE TABLE test (Name STRING) AUTOINCREMENT STRICT",
+			createTableStmt{tableName: "test", config: TableConfig{Strict: true, KeyGenerator: SequenceKeyGenerator, FieldConstraints: []FieldConstraint{
+				{Name: "Name", Type: value.String},
+			}}}, false},
+		{"UUID keys", "CREATE TABLE test (id UUID) UUID",
+			createTableStmt{tableName: "test", config: TableConfig{KeyGenerator: UUIDKeyGenerator, FieldConstraints: []FieldConstraint{
+				{Name: "id", Type: value.UUID},
+			}}}, false},
+		{"Ordered UUID keys", "CREATE TABLE test STRICT UUID ORDERED",
+			createTableStmt{tableName: "test", config: TableConfig{Strict: true, KeyGenerator: OrderedUUIDKeyGenerator}}, false},
+		{"Duplicate key generator", "CREATE TABLE test AUTOINCREMENT UUID", nil, true},
+		{"Duplicate option", "CREATE TABLE test STRICT STRICT", nil, true},
+		{"Compressed", "CREATE TABLE test (compressed STRING) COMPRESSED AUTOINCREMENT",
+			createTableStmt{tableName: "test", config: TableConfig{Compressed: true, KeyGenerator: SequenceKeyGenerator, FieldConstraints: []FieldConstraint{
+				{Name: "compressed", Type: value.String},
+			}}}, false},
+		{"Duplicate compressed", "CREATE TABLE test COMPRESSED COMPRESSED", nil, true},
+		{"References", "CREATE TABLE test (a REFERENCES foo, b INT64 REFERENCES bar(id) ON DELETE CASCADE, c REFERENCES baz ON DELETE SET NULL)",
+			createTableStmt{tableName: "test", config: TableConfig{FieldConstraints: []FieldConstraint{
+				{Name: "a", ForeignKey: &ForeignKey{Table: "foo"}},
+				{Name: "b", Type: value.Int64, ForeignKey: &ForeignKey{Table: "bar", Field: "id", OnDelete: ForeignKeyCascade}},
+				{Name: "c", ForeignKey: &ForeignKey{Table: "baz", OnDelete: ForeignKeySetNull}},
+			}}}, false},
+		{"References / Unknown action", "CREATE TABLE test (a REFERENCES foo ON DELETE NOTHING)", nil, true},
+		{"Unknown type", "CREATE TABLE test (Name FOO)", nil, true},
+		{"Duplicate default", "CREATE TABLE test (Name DEFAULT 'a' DEFAULT 'b')", nil, true},
+		{"Param in default", "CREATE TABLE test (Name DEFAULT ?)", nil, true},
+		{"Param in check", "CREATE TABLE test (Name, CHECK (Name = $name))", nil, true},
+		{"Check without parentheses", "CREATE TABLE test (Name CHECK Name = 'a')", nil, true},
+		{"Duplicate constraint", "CREATE TABLE test (Name STRING UNIQUE UNIQUE)", nil, true},
+		{"Missing NULL", "CREATE TABLE test (Name STRING NOT)", nil, true},
+		{"Empty list", "CREATE TABLE test ()", nil, true},
 	}
 
 	for _, test := range tests {
@@ -42,6 +103,8 @@ func TestCreateTableStmt(t *testing.T) {
 		{"Exists", "CREATE TABLE test;CREATE TABLE test", true},
 		{"If not exists", "CREATE TABLE test IF NOT EXISTS", false},
 		{"If not exists, twice", "CREATE TABLE test IF NOT EXISTS;CREATE TABLE test IF NOT EXISTS", false},
+		{"With field constraints", "CREATE TABLE test (Name STRING NOT NULL, Age INT8 UNIQUE) STRICT", false},
+		{"Duplicate field", "CREATE TABLE test (Name STRING, Name INT8)", true},
 	}
 
 	for _, test := range tests {
@@ -65,6 +128,139 @@ func TestCreateTableStmt(t *testing.T) {
 	}
 }
 
+func TestCreateTableStmtConstraints(t *testing.T) {
+	tests := []struct {
+		name     string
+		create   string
+		query    string
+		fails    bool
+		expected string
+	}{
+		{"Conversion", "CREATE TABLE test (a INT8, b STRING)", "INSERT INTO test (a, b, c) VALUES (10, 'foo', 1.5)", false, "a(Int8): 10\nb(String): \"foo\"\nc(Float64): 1.5\n"},
+		{"Overflow", "CREATE TABLE test (a INT8)", "INSERT INTO test (a) VALUES (1000)", true, ""},
+		{"Lossy conversion", "CREATE TABLE test (a INT64)", "INSERT INTO test (a) VALUES (1.5)", true, ""},
+		{"Invalid type", "CREATE TABLE test (a BOOL)", "INSERT INTO test (a) VALUES ('foo')", true, ""},
+		{"Not null", "CREATE TABLE test (a INT8 NOT NULL)", "INSERT INTO test (b) VALUES (1)", true, ""},
+		{"Not null / Null value", "CREATE TABLE test (a NOT NULL)", "INSERT INTO test (a) VALUES (NULL)", true, ""},
+		{"Not null / Ok", "CREATE TABLE test (a NOT NULL)", "INSERT INTO test (a) VALUES ('foo')", false, "a(String): \"foo\"\n"},
+		{"Strict", "CREATE TABLE test (a INT8) STRICT", "INSERT INTO test (a, b) VALUES (1, 2)", true, ""},
+		{"Strict / Ok", "CREATE TABLE test (a INT8) STRICT", "INSERT INTO test (a) VALUES (1)", false, "a(Int8): 1\n"},
+		{"Unique", "CREATE TABLE test (a INT8 UNIQUE)", "INSERT INTO test (a) VALUES (1); INSERT INTO test (a) VALUES (1)", true, ""},
+		{"Unique / Null", "CREATE TABLE test (a INT8 UNIQUE)", "INSERT INTO test (a) VALUES (NULL); INSERT INTO test (a) VALUES (NULL)", false, "a(Null): <nil>\na(Null): <nil>\n"},
+		{"Unique / Missing field", "CREATE TABLE test (a INT8 UNIQUE)", "INSERT INTO test (b) VALUES (1); INSERT INTO test (b) VALUES (1)", false, "b(Int64): 1\nb(Int64): 1\n"},
+		{"Update", "CREATE TABLE test (a INT8)", "INSERT INTO test (a) VALUES (1); UPDATE test SET a = 1000", true, ""},
+		{"Default", "CREATE TABLE test (a INT8 DEFAULT 10, b DEFAULT 'foo')", "INSERT INTO test (b) VALUES ('bar')", false, "b(String): \"bar\"\na(Int8): 10\n"},
+		{"Default / Not null", "CREATE TABLE test (a NOT NULL DEFAULT 1.5)", "INSERT INTO test (b) VALUES (1)", false, "b(Int64): 1\na(Float64): 1.5\n"},
+		{"Default / Invalid type", "CREATE TABLE test (a INT8 DEFAULT 'foo')", "INSERT INTO test (b) VALUES (1)", true, ""},
+		{"Check", "CREATE TABLE test (a INT8 CHECK (a > 0))", "INSERT INTO test (a) VALUES (-1)", true, ""},
+		{"Check / Ok", "CREATE TABLE test (a INT8 CHECK (a > 0))", "INSERT INTO test (a) VALUES (1)", false, "a(Int8): 1\n"},
+		{"Check / Null", "CREATE TABLE test (a INT8 CHECK (a > 0))", "INSERT INTO test (b) VALUES (1)", false, "b(Int64): 1\n"},
+		{"Check / Table", "CREATE TABLE test (a, b, CHECK (a < b))", "INSERT INTO test (a, b) VALUES (2, 1)", true, ""},
+		{"Check / Default", "CREATE TABLE test (a DEFAULT 0, CHECK (a > 0))", "INSERT INTO test (b) VALUES (1)", true, ""},
+		{"Check / Update", "CREATE TABLE test (a CHECK (a > 0))", "INSERT INTO test (a) VALUES (1); UPDATE test SET a = 0", true, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := New(memory.NewEngine())
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(test.create)
+			require.NoError(t, err)
+
+			err = db.Exec(test.query)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			res, err := db.Query("SELECT * FROM test")
+			require.NoError(t, err)
+			defer res.Close()
+
+			var buf bytes.Buffer
+			err = res.Iterate(func(r record.Record) error {
+				return recordutil.DumpRecord(&buf, r)
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.expected, buf.String())
+		})
+	}
+
+	t.Run("Violation error", func(t *testing.T) {
+		db, err := New(memory.NewEngine())
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test (a NOT NULL, CHECK (a >= 10))")
+		require.NoError(t, err)
+
+		err = db.Exec("INSERT INTO test (b) VALUES (1)")
+		require.Equal(t, &ConstraintViolationError{Table: "test", Field: "a", Constraint: "NOT NULL"}, err)
+
+		err = db.Exec("INSERT INTO test (a) VALUES (1)")
+		require.Equal(t, &ConstraintViolationError{Table: "test", Constraint: "CHECK (a >= 10)"}, err)
+
+		err = db.Exec("CREATE TABLE typed (a INT8)")
+		require.NoError(t, err)
+
+		err = db.Exec("INSERT INTO typed (a) VALUES ('foo')")
+		var cerr *ConstraintViolationError
+		require.True(t, errors.As(err, &cerr))
+		require.Equal(t, "typed", cerr.Table)
+		require.Equal(t, "a", cerr.Field)
+		require.Equal(t, "INT8", cerr.Constraint)
+		require.Error(t, cerr.Err)
+	})
+
+	t.Run("Drop", func(t *testing.T) {
+		db, err := New(memory.NewEngine())
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test (a INT8 UNIQUE) STRICT")
+		require.NoError(t, err)
+
+		err = db.Exec("DROP TABLE test")
+		require.NoError(t, err)
+
+		err = db.Exec("CREATE TABLE test; INSERT INTO test (a, b) VALUES (1000, 1); INSERT INTO test (a) VALUES (1000)")
+		require.NoError(t, err)
+	})
+}
+
+func TestParserCreateSequence(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected statement
+		errored  bool
+	}{
+		{"Basic", "CREATE SEQUENCE seq", createSequenceStmt{sequenceName: "seq"}, false},
+		{"If not exists", "CREATE SEQUENCE IF NOT EXISTS seq", createSequenceStmt{sequenceName: "seq", ifNotExists: true}, false},
+		{"Start", "CREATE SEQUENCE seq START WITH 10", createSequenceStmt{sequenceName: "seq", value: 9}, false},
+		{"Start without WITH", "CREATE SEQUENCE seq start -10", createSequenceStmt{sequenceName: "seq", value: -11}, false},
+		{"Start without value", "CREATE SEQUENCE seq START WITH", nil, true},
+		{"Invalid start", "CREATE SEQUENCE seq START WITH 1.5", nil, true},
+		{"No name", "CREATE SEQUENCE", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
+
 func TestParserCreateIndex(t *testing.T) {
 	tests := []struct {
 		name     string
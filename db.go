@@ -2,7 +2,11 @@ package genji
 
 import (
 	"bytes"
+	"context"
+	crand "crypto/rand"
 	"database/sql"
+	"fmt"
+	"io"
 	"math/rand"
 	"strings"
 	"time"
@@ -16,10 +20,15 @@ import (
 )
 
 var (
-	entropy          = rand.New(rand.NewSource(time.Now().UnixNano()))
-	separator   byte = 0x1F
-	indexTable       = "__genji.indexes"
-	indexPrefix      = "i"
+	entropy                = rand.New(rand.NewSource(time.Now().UnixNano()))
+	separator         byte = 0x1F
+	indexTable             = "__genji.indexes"
+	tableConfigTable       = "__genji.tables"
+	sequenceTable          = "__genji.sequences"
+	metaTable              = "__genji.meta"
+	fieldsTable            = "__genji.fields"
+	indexPrefix            = "i"
+	systemTablePrefix      = "__genji."
 )
 
 // Open creates a Genji database and wraps it around a *sql.DB instance.
@@ -42,26 +51,78 @@ func OpenDB(db *DB) (*sql.DB, error) {
 // and database administration methods.
 // DB is safe for concurrent use unless the given engine isn't.
 type DB struct {
-	ng engine.Engine
+	ng     engine.Engine
+	limits Limits
 }
 
 // New initializes the DB using the given engine.
+// Indexes written with an older version of the index format are rebuilt.
 func New(ng engine.Engine) (*DB, error) {
 	db := DB{
 		ng: ng,
 	}
 
+	var rebuildIndexes bool
 	err := db.Update(func(tx *Tx) error {
-		_, err := tx.GetTable(indexTable)
-		if err == ErrTableNotFound {
-			_, err = tx.CreateTable(indexTable)
+		// databases created by any version of Genji contain at least the index table
+		names, err := tx.tx.ListStores("")
+		if err != nil {
+			return err
 		}
-		return err
+		isNew := len(names) == 0
+
+		for _, name := range []string{indexTable, tableConfigTable, sequenceTable, metaTable, fieldsTable} {
+			_, err := tx.GetTable(name)
+			if err == ErrTableNotFound {
+				_, err = tx.CreateTable(name)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if isNew {
+			err = tx.setVersion(formatVersionKey, record.FormatVersion)
+			if err != nil {
+				return err
+			}
+
+			return tx.setVersion(indexFormatVersionKey, index.FormatVersion)
+		}
+
+		v, err := tx.version(formatVersionKey)
+		if err != nil {
+			return err
+		}
+
+		if v > record.FormatVersion {
+			return fmt.Errorf("unsupported database format version %d, the latest supported version is %d", v, record.FormatVersion)
+		}
+
+		v, err = tx.version(indexFormatVersionKey)
+		if err != nil {
+			return err
+		}
+
+		if v > index.FormatVersion {
+			return fmt.Errorf("unsupported index format version %d, the latest supported version is %d", v, index.FormatVersion)
+		}
+
+		// indexes written with an older format can't be read
+		rebuildIndexes = v < index.FormatVersion
+		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	if rebuildIndexes {
+		err = db.rebuildIndexes()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &db, nil
 }
 
@@ -73,6 +134,19 @@ func (db DB) Close() error {
 // Begin starts a new transaction.
 // The returned transaction must be closed either by calling Rollback or Commit.
 func (db DB) Begin(writable bool) (*Tx, error) {
+	return db.BeginContext(context.Background(), writable)
+}
+
+// BeginContext starts a new transaction bound to ctx. Once ctx is cancelled or its deadline
+// is exceeded, iterating over the tables of the transaction, including by running queries,
+// fails with the error returned by ctx.Err().
+// The returned transaction must be closed either by calling Rollback or Commit.
+func (db DB) BeginContext(ctx context.Context, writable bool) (*Tx, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
 	tx, err := db.ng.Begin(writable)
 	if err != nil {
 		return nil, err
@@ -82,6 +156,8 @@ func (db DB) Begin(writable bool) (*Tx, error) {
 		db:       &db,
 		tx:       tx,
 		writable: writable,
+		ctx:      ctx,
+		tables:   make(map[string]*cachedTable),
 	}, nil
 }
 
@@ -114,7 +190,12 @@ func (db DB) Update(fn func(tx *Tx) error) error {
 
 // Exec a query against the database without returning the result.
 func (db DB) Exec(q string, args ...interface{}) error {
-	res, err := db.Query(q, args...)
+	return db.ExecContext(context.Background(), q, args...)
+}
+
+// ExecContext runs a query against the database like Exec, aborting it if ctx is done.
+func (db DB) ExecContext(ctx context.Context, q string, args ...interface{}) error {
+	res, err := db.QueryContext(ctx, q, args...)
 	if err != nil {
 		return err
 	}
@@ -125,12 +206,31 @@ func (db DB) Exec(q string, args ...interface{}) error {
 // Query the database and return the result.
 // The returned result must always be closed after usage.
 func (db DB) Query(q string, args ...interface{}) (*Result, error) {
+	return db.QueryContext(context.Background(), q, args...)
+}
+
+// QueryContext queries the database like Query, the statements being run in transactions
+// bound to ctx. If ctx is done while the query runs or while the returned result is being read,
+// the query is aborted and the error returned by ctx.Err() is returned.
+// The returned result must always be closed after usage.
+func (db DB) QueryContext(ctx context.Context, q string, args ...interface{}) (*Result, error) {
+	pq, err := parseQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return pq.Run(ctx, &db, argsToNamedValues(args))
+}
+
+// Prepare parses the query once and returns a statement which can be run any number of times,
+// against the database or, using Tx.Stmt, within any transaction.
+func (db DB) Prepare(q string) (*Statement, error) {
 	pq, err := parseQuery(q)
 	if err != nil {
 		return nil, err
 	}
 
-	return pq.Run(&db, argsToNamedValues(args))
+	return &Statement{db: &db, q: pq}, nil
 }
 
 // ViewTable starts a read only transaction, fetches the selected table, calls fn with that table
@@ -168,6 +268,18 @@ type Tx struct {
 	db       *DB
 	tx       engine.Transaction
 	writable bool
+	ctx      context.Context
+	limiter  *statementLimiter // nil unless a statement with limits is running
+	tables   map[string]*cachedTable
+}
+
+// cachedTable holds the configuration of a table, parsed as a schema, and its field dictionary,
+// so that they are read once per transaction rather than every time the table is accessed.
+// The cache is shared by the copies of the transaction and entries are removed
+// when their table is created or dropped.
+type cachedTable struct {
+	schema *tableSchema // nil if the table has no configuration
+	fields *fieldDictionary
 }
 
 // Rollback the transaction. Can be used safely after commit.
@@ -197,7 +309,7 @@ func (tx *Tx) Promote() error {
 		return err
 	}
 
-	newTx, err := tx.db.Begin(true)
+	newTx, err := tx.db.BeginContext(tx.ctx, true)
 	if err != nil {
 		return err
 	}
@@ -206,6 +318,20 @@ func (tx *Tx) Promote() error {
 	return nil
 }
 
+// Context returns the context the transaction is bound to.
+func (tx *Tx) Context() context.Context {
+	return tx.ctx
+}
+
+// statementTx returns a copy of tx sharing the same underlying transaction, used to run a single
+// statement. It is bound to ctx and enforces the limits of the database and of ctx.
+func (tx *Tx) statementTx(ctx context.Context) *Tx {
+	newTx := *tx
+	newTx.ctx = ctx
+	newTx.limiter = newStatementLimiter(ctx, tx.db)
+	return &newTx
+}
+
 // Query the database withing the transaction and returns the result.
 // Closing the returned result after usage is not mandatory.
 func (tx *Tx) Query(q string, args ...interface{}) (*Result, error) {
@@ -214,7 +340,12 @@ func (tx *Tx) Query(q string, args ...interface{}) (*Result, error) {
 		return nil, err
 	}
 
-	return pq.Exec(tx, argsToNamedValues(args), false)
+	return pq.Exec(tx.ctx, tx, argsToNamedValues(args), false)
+}
+
+// Stmt returns a copy of the prepared statement s, running within tx.
+func (tx *Tx) Stmt(s *Statement) *Statement {
+	return &Statement{db: s.db, tx: tx, q: s.q}
 }
 
 // Exec a query against the database within tx and without returning the result.
@@ -230,6 +361,26 @@ func (tx *Tx) Exec(q string, args ...interface{}) error {
 // CreateTable creates a table with the given name.
 // If it already exists, returns ErrTableAlreadyExists.
 func (tx Tx) CreateTable(name string) (*Table, error) {
+	return tx.CreateTableWithConfig(name, nil)
+}
+
+// CreateTableWithConfig creates a table with the given name and configuration.
+// If cfg is not nil, it is stored alongside the table and used to validate records
+// and a unique index is created for every field declared as unique.
+// If it already exists, returns ErrTableAlreadyExists.
+func (tx Tx) CreateTableWithConfig(name string, cfg *TableConfig) (*Table, error) {
+	if cfg != nil {
+		err := cfg.validate()
+		if err != nil {
+			return nil, err
+		}
+
+		err = tx.validateForeignKeys(name, cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	err := tx.tx.CreateStore(name)
 	if err == engine.ErrStoreAlreadyExists {
 		return nil, ErrTableAlreadyExists
@@ -238,6 +389,37 @@ func (tx Tx) CreateTable(name string) (*Table, error) {
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to create table %q", name)
 	}
+	delete(tx.tables, name)
+
+	if cfg != nil {
+		ct, err := tx.GetTable(tableConfigTable)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = ct.Insert(&tableInfo{TableName: name, Config: *cfg})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to store configuration of table %q", name)
+		}
+
+		if cfg.KeyGenerator == SequenceKeyGenerator {
+			err = tx.CreateSequence(autoSequenceName(name))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, fc := range cfg.FieldConstraints {
+			if !fc.Unique {
+				continue
+			}
+
+			_, err = tx.CreateIndex(autoIndexName(name, fc.Name), name, fc.Name, index.Options{Unique: true})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
 
 	return tx.GetTable(name)
 }
@@ -258,6 +440,16 @@ func (tx Tx) GetTable(name string) (*Table, error) {
 		name:  name,
 	}
 
+	if !strings.HasPrefix(name, systemTablePrefix) {
+		ct, err := tx.cachedTable(name)
+		if err != nil {
+			return nil, err
+		}
+
+		t.schema = ct.schema
+		t.fields = ct.fields
+	}
+
 	t.indexes, err = t.Indexes()
 	if err != nil {
 		return nil, err
@@ -266,13 +458,85 @@ func (tx Tx) GetTable(name string) (*Table, error) {
 	return &t, nil
 }
 
-// DropTable deletes a table from the database.
+// cachedTable returns the schema and the field dictionary of a table, reading them
+// from the system tables the first time the table is accessed within the transaction.
+func (tx Tx) cachedTable(name string) (*cachedTable, error) {
+	if ct, ok := tx.tables[name]; ok {
+		return ct, nil
+	}
+
+	ct := cachedTable{
+		fields: newFieldDictionary(&tx, name),
+	}
+
+	cfg, err := readTableConfig(&tx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg != nil {
+		ct.schema, err = newTableSchema(name, cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if tx.tables != nil {
+		tx.tables[name] = &ct
+	}
+
+	return &ct, nil
+}
+
+// DropTable deletes a table from the database, alongside its configuration and its indexes.
 func (tx Tx) DropTable(name string) error {
-	err := tx.tx.DropStore(name)
+	t := Table{
+		tx:   &tx,
+		name: name,
+	}
+
+	indexes, err := t.Indexes()
+	if err != nil {
+		return err
+	}
+
+	err = tx.tx.DropStore(name)
 	if err == engine.ErrStoreNotFound {
 		return ErrTableNotFound
 	}
-	return err
+	if err != nil {
+		return err
+	}
+	delete(tx.tables, name)
+
+	ct, err := tx.GetTable(tableConfigTable)
+	if err != nil {
+		return err
+	}
+
+	err = ct.Delete([]byte(name))
+	if err != nil && err != ErrRecordNotFound {
+		return err
+	}
+
+	err = tx.DropSequence(autoSequenceName(name))
+	if err != nil && err != ErrSequenceNotFound {
+		return err
+	}
+
+	err = tx.dropFieldDictionary(name)
+	if err != nil {
+		return err
+	}
+
+	for _, idx := range indexes {
+		err = tx.DropIndex(idx.IndexName)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func buildIndexName(name string) string {
@@ -396,10 +660,12 @@ type Table struct {
 	store   engine.Store
 	name    string
 	indexes map[string]Index
+	schema  *tableSchema
+	fields  *fieldDictionary // nil for system tables
 }
 
 type encodedRecordWithKey struct {
-	record.EncodedRecord
+	record.LazyRecord
 
 	key []byte
 }
@@ -410,6 +676,8 @@ func (e encodedRecordWithKey) Key() []byte {
 
 // Iterate goes through all the records of the table and calls the given function by passing each one of them.
 // If the given function returns an error, the iteration stops.
+// If the context of the transaction is done, the iteration stops and the error of the context is returned,
+// and so does it if the running statement exceeds its limits.
 func (t Table) Iterate(fn func(r record.Record) error) error {
 	// To avoid unnecessary allocations, we create the slice once and reuse it
 	// at each call of the fn method.
@@ -417,9 +685,19 @@ func (t Table) Iterate(fn func(r record.Record) error) error {
 	// we can assume that it's thread safe.
 	// TODO(asdine) Add a mutex if proven necessary
 	var r encodedRecordWithKey
+	if t.fields != nil {
+		r.Dictionary = t.fields
+	}
 
 	return t.store.AscendGreaterOrEqual(nil, func(k, v []byte) error {
-		r.EncodedRecord = v
+		// checking every record allows long scans to be aborted
+		// even if most records are filtered out by the caller
+		err := t.checkScan()
+		if err != nil {
+			return err
+		}
+
+		r.Reset(v)
 		r.key = k
 		// r must be passed as pointer, not value, because passing a value to an interface
 		// requires an allocation, while it doesn't for a pointer.
@@ -427,6 +705,44 @@ func (t Table) Iterate(fn func(r record.Record) error) error {
 	})
 }
 
+// checkScan is called before reading each record of the table. It returns an error
+// if the context of the transaction is done or if the running statement exceeded its limits.
+func (t Table) checkScan() error {
+	err := t.tx.ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	// system tables are read by most statements, only user records count
+	if t.tx.limiter == nil || strings.HasPrefix(t.name, systemTablePrefix) {
+		return nil
+	}
+
+	return t.tx.limiter.recordScanned()
+}
+
+// checkInterrupted returns an error if the context of the transaction is done
+// or if the running statement exceeded its timeout. Loops processing data already
+// read, which don't call checkScan, call it on every iteration.
+func (tx *Tx) checkInterrupted() error {
+	err := tx.ctx.Err()
+	if err != nil || tx.limiter == nil {
+		return err
+	}
+
+	return tx.limiter.checkTimeout()
+}
+
+// buffered must be called every time the running statement keeps n more bytes in memory.
+// It returns an error if the statement exceeded its limits.
+func (tx *Tx) buffered(n int) error {
+	if tx.limiter == nil {
+		return nil
+	}
+
+	return tx.limiter.memoryUsed(n)
+}
+
 // GetRecord returns one record by key.
 func (t Table) GetRecord(key []byte) (record.Record, error) {
 	v, err := t.store.Get(key)
@@ -437,7 +753,17 @@ func (t Table) GetRecord(key []byte) (record.Record, error) {
 		return nil, errors.Wrapf(err, "failed to fetch record %q", key)
 	}
 
-	return record.EncodedRecord(v), err
+	return t.newRecord(v), err
+}
+
+// newRecord returns a record decoding data using the field dictionary of the table, if any.
+func (t Table) newRecord(data []byte) *record.LazyRecord {
+	r := record.NewLazyRecord(data)
+	if t.fields != nil {
+		r.Dictionary = t.fields
+	}
+
+	return r
 }
 
 // A PrimaryKeyer is a record that generates a key based on its primary key.
@@ -449,14 +775,33 @@ type PrimaryKeyer interface {
 // If the record implements the table.Pker interface, it will be used to generate a key,
 // otherwise it will be generated automatically. Note that there are no ordering guarantees
 // regarding the key generated by default.
+// If the table has a configuration, default values are set for missing fields, the record is
+// validated and its declared fields are converted to their declared type before being stored.
+// If a constraint is not satisfied, a *ConstraintViolationError is returned.
 func (t Table) Insert(r record.Record) ([]byte, error) {
-	v, err := record.Encode(r)
+	var err error
+
+	pker, isPker := r.(PrimaryKeyer)
+
+	if t.schema != nil {
+		r, err = t.schema.validateRecord(t.tx, r, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = t.checkForeignKeys(r)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := t.encodeRecord(r)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to encode record")
 	}
 
 	var key []byte
-	if pker, ok := r.(PrimaryKeyer); ok {
+	if isPker {
 		key, err = pker.PrimaryKey()
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to generate key from PrimaryKey method")
@@ -464,11 +809,24 @@ func (t Table) Insert(r record.Record) ([]byte, error) {
 		if len(key) == 0 {
 			return nil, errors.New("primary key must not be empty")
 		}
-	} else {
-		id, err := ulid.New(ulid.Timestamp(time.Now()), entropy)
-		if err == nil {
-			key, err = id.MarshalText()
+
+		// make sure the keys generated later don't collide with this one.
+		// only integer keys can collide with the keys generated by the sequence,
+		// other PrimaryKeyer implementations can return keys of any type
+		kr, ok := pker.(keyedRecord)
+		if ok && kr.integer && t.schema != nil && t.schema.cfg.KeyGenerator == SequenceKeyGenerator {
+			n, err := value.DecodeInt64(key)
+			if err != nil {
+				return nil, err
+			}
+
+			err = t.tx.raiseSequence(autoSequenceName(t.name), n)
+			if err != nil {
+				return nil, err
+			}
 		}
+	} else {
+		key, err = t.generateKey()
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to generate key")
 		}
@@ -485,45 +843,126 @@ func (t Table) Insert(r record.Record) ([]byte, error) {
 	}
 
 	for _, idx := range t.indexes {
-		f, err := r.GetField(idx.FieldName)
+		values, err := indexedValues(idx.FieldName, r)
 		if err != nil {
-			continue
+			return nil, err
 		}
 
-		err = idx.Set(f.Data, key)
-		if err != nil {
-			if err == index.ErrDuplicate {
-				return nil, ErrDuplicateRecord
+		for _, v := range values {
+			err = idx.Set(v, key)
+			if err != nil {
+				if err == index.ErrDuplicate {
+					return nil, ErrDuplicateRecord
+				}
+
+				return nil, err
 			}
+		}
+	}
+
+	return key, nil
+}
 
+// generateKey generates a key using the key generator of the table.
+func (t Table) generateKey() ([]byte, error) {
+	if t.schema != nil && t.schema.cfg.KeyGenerator == SequenceKeyGenerator {
+		n, err := t.tx.NextValue(autoSequenceName(t.name))
+		if err != nil {
 			return nil, err
 		}
+
+		return value.EncodeInt64(n), nil
 	}
 
-	return key, nil
+	if t.schema != nil {
+		switch t.schema.cfg.KeyGenerator {
+		case UUIDKeyGenerator:
+			u, err := newUUID(4, time.Now())
+			return value.EncodeUUID(u), err
+		case OrderedUUIDKeyGenerator:
+			u, err := newUUID(7, time.Now())
+			return value.EncodeUUID(u), err
+		}
+	}
+
+	id, err := ulid.New(ulid.Timestamp(time.Now()), entropy)
+	if err != nil {
+		return nil, err
+	}
+
+	return id.MarshalText()
+}
+
+// newUUID generates a random UUID of the given version, either 4 or 7.
+// Version 7 UUIDs start with the Unix time of t in milliseconds.
+func newUUID(version byte, t time.Time) ([16]byte, error) {
+	var u [16]byte
+
+	_, err := io.ReadFull(crand.Reader, u[:])
+	if err != nil {
+		return u, err
+	}
+
+	if version == 7 {
+		ms := uint64(t.UnixNano() / int64(time.Millisecond))
+		for i := 0; i < 6; i++ {
+			u[i] = byte(ms >> uint(40-8*i))
+		}
+	}
+
+	u[6] = u[6]&0x0F | version<<4
+	// RFC 4122 variant
+	u[8] = u[8]&0x3F | 0x80
+
+	return u, nil
 }
 
 // Delete a record by key.
 // Indexes are automatically updated.
+// If the record is referenced by foreign keys, the action of each foreign key is applied
+// to the referencing records once the record is deleted.
 func (t Table) Delete(key []byte) error {
 	r, err := t.GetRecord(key)
 	if err != nil {
 		return err
 	}
 
-	for _, idx := range t.indexes {
-		f, err := r.GetField(idx.FieldName)
+	refs, err := t.references()
+	if err != nil {
+		return err
+	}
+
+	if len(refs) > 0 {
+		err = t.restrictReferences(refs, key, r, nil)
 		if err != nil {
 			return err
 		}
 
-		err = idx.Delete(f.Data, key)
+		// the record must remain readable after being deleted from the store
+		// to apply the actions of the foreign keys.
+		r = t.newRecord(append([]byte{}, r.(*record.LazyRecord).EncodedRecord...))
+	}
+
+	for _, idx := range t.indexes {
+		values, err := indexedValues(idx.FieldName, r)
 		if err != nil {
 			return err
 		}
+
+		for _, v := range values {
+			err = idx.Delete(v, key)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = t.store.Delete(key)
+	if err != nil {
+		return err
 	}
 
-	return t.store.Delete(key)
+	return t.applyDeleteActions(refs, key, r)
 }
 
 type pkWrapper struct {
@@ -538,6 +977,8 @@ func (p pkWrapper) PrimaryKey() ([]byte, error) {
 // Replace a record by key.
 // An error is returned if the key doesn't exist.
 // Indexes are automatically updated.
+// If the table has a configuration, the record is validated and its declared fields are
+// converted to their declared type before being stored.
 func (t Table) Replace(key []byte, r record.Record) error {
 	// make sure key exists
 	old, err := t.GetRecord(key)
@@ -545,21 +986,45 @@ func (t Table) Replace(key []byte, r record.Record) error {
 		return err
 	}
 
-	// remove key from indexes
-	for _, idx := range t.indexes {
-		f, err := old.GetField(idx.FieldName)
+	if t.schema != nil {
+		r, err = t.schema.validateRecord(t.tx, r, false)
 		if err != nil {
 			return err
 		}
+	}
+
+	err = t.checkForeignKeys(r)
+	if err != nil {
+		return err
+	}
 
-		err = idx.Delete(f.Data, key)
+	refs, err := t.references()
+	if err != nil {
+		return err
+	}
+
+	err = t.restrictReferences(refs, key, old, r)
+	if err != nil {
+		return err
+	}
+
+	// remove key from indexes
+	for _, idx := range t.indexes {
+		values, err := indexedValues(idx.FieldName, old)
 		if err != nil {
 			return err
 		}
+
+		for _, v := range values {
+			err = idx.Delete(v, key)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	// encode new record
-	v, err := record.Encode(r)
+	v, err := t.encodeRecord(r)
 	if err != nil {
 		return errors.Wrap(err, "failed to encode record")
 	}
@@ -572,23 +1037,135 @@ func (t Table) Replace(key []byte, r record.Record) error {
 
 	// update indexes
 	for _, idx := range t.indexes {
-		f, err := r.GetField(idx.FieldName)
+		values, err := indexedValues(idx.FieldName, r)
 		if err != nil {
+			return err
+		}
+
+		for _, v := range values {
+			err = idx.Set(v, key)
+			if err != nil {
+				if err == index.ErrDuplicate {
+					return ErrDuplicateRecord
+				}
+
+				return err
+			}
+		}
+	}
+
+	return err
+}
+
+// encodeRecord encodes r using the field dictionary of the table, if any,
+// and compresses it if the table is configured to do so.
+func (t Table) encodeRecord(r record.Record) ([]byte, error) {
+	var v []byte
+	var err error
+
+	if t.fields != nil {
+		v, err = record.EncodeWithDictionary(r, t.fields)
+	} else {
+		v, err = record.Encode(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if t.schema != nil && t.schema.cfg.Compressed {
+		return record.Compress(v)
+	}
+
+	return v, nil
+}
+
+// indexedValues returns the values of the selected field that must be stored in an index.
+// Values are stored using the canonical encoding of the index package.
+// Null values are not indexed and each distinct element of an array gets its own entry,
+// so that records can be looked up by any of their elements.
+func indexedValues(fieldName string, r record.Record) ([][]byte, error) {
+	f, err := fieldSelector(fieldName).SelectField(r)
+	if err != nil || f.Type == value.Null {
+		return nil, nil
+	}
+
+	if f.Type != value.Array {
+		v, err := index.EncodeValue(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{v}, nil
+	}
+
+	elems, err := f.DecodeToArray()
+	if err != nil {
+		return nil, err
+	}
+
+	var values [][]byte
+	for _, e := range elems {
+		if e.Type == value.Null {
 			continue
 		}
 
-		err = idx.Set(f.Data, key)
+		v, err := index.EncodeValue(e)
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		if !containsBytes(values, v) {
+			values = append(values, v)
 		}
 	}
 
-	return err
+	return values, nil
+}
+
+func containsBytes(l [][]byte, b []byte) bool {
+	for _, x := range l {
+		if bytes.Equal(x, b) {
+			return true
+		}
+	}
+
+	return false
 }
 
 // Truncate deletes all the records from the table.
+// If the table is referenced by foreign keys, records are deleted one by one
+// so that the action of each foreign key is applied, like with Delete.
 func (t Table) Truncate() error {
-	return t.store.Truncate()
+	refs, err := t.references()
+	if err != nil {
+		return err
+	}
+
+	if len(refs) == 0 {
+		return t.store.Truncate()
+	}
+
+	// stores can't be modified while being iterated on
+	var keys [][]byte
+	err = t.store.AscendGreaterOrEqual(nil, func(k, _ []byte) error {
+		keys = append(keys, append([]byte{}, k...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		err = t.Delete(k)
+		// the record might have been deleted by a foreign key cascade
+		if err == ErrRecordNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // TableName returns the name of the table.
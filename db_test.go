@@ -1,11 +1,17 @@
 package genji_test
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/asdine/genji"
+	"github.com/asdine/genji/engine"
 	"github.com/asdine/genji/engine/memory"
 	"github.com/asdine/genji/index"
 	"github.com/asdine/genji/record"
@@ -148,6 +154,100 @@ func newTestTable(t testing.TB) (*genji.Table, func()) {
 	return tb, fn
 }
 
+// countingEngine counts the keys read from the stores of the engine using Get.
+type countingEngine struct {
+	engine.Engine
+	gets map[string]int
+}
+
+func (ng *countingEngine) Begin(writable bool) (engine.Transaction, error) {
+	tx, err := ng.Engine.Begin(writable)
+	if err != nil {
+		return nil, err
+	}
+
+	return &countingTx{Transaction: tx, gets: ng.gets}, nil
+}
+
+type countingTx struct {
+	engine.Transaction
+	gets map[string]int
+}
+
+func (tx *countingTx) Store(name string) (engine.Store, error) {
+	st, err := tx.Transaction.Store(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &countingStore{Store: st, name: name, gets: tx.gets}, nil
+}
+
+type countingStore struct {
+	engine.Store
+	name string
+	gets map[string]int
+}
+
+func (s *countingStore) Get(k []byte) ([]byte, error) {
+	s.gets[s.name]++
+	return s.Store.Get(k)
+}
+
+func TestTxGetTable(t *testing.T) {
+	ng := countingEngine{Engine: memory.NewEngine(), gets: make(map[string]int)}
+
+	db, err := genji.New(&ng)
+	require.NoError(t, err)
+	defer db.Close()
+
+	tx, err := db.Begin(true)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	err = tx.Exec("CREATE TABLE test (a INT8 NOT NULL)")
+	require.NoError(t, err)
+	err = tx.Exec("INSERT INTO test (a) VALUES (1)")
+	require.NoError(t, err)
+
+	t.Run("Cache", func(t *testing.T) {
+		configs, fields := ng.gets["__genji.tables"], ng.gets["__genji.fields"]
+
+		for i := 0; i < 3; i++ {
+			_, err = tx.GetTable("test")
+			require.NoError(t, err)
+			err = tx.Exec("INSERT INTO test (a) VALUES (1)")
+			require.NoError(t, err)
+		}
+
+		// the configuration and the field dictionary are only read once per transaction
+		require.Equal(t, configs, ng.gets["__genji.tables"])
+		require.Equal(t, fields, ng.gets["__genji.fields"])
+	})
+
+	t.Run("Drop and create", func(t *testing.T) {
+		err = tx.Exec("DROP TABLE test")
+		require.NoError(t, err)
+		err = tx.Exec("CREATE TABLE test (b INT8 NOT NULL)")
+		require.NoError(t, err)
+
+		err = tx.Exec("INSERT INTO test (a) VALUES (1)")
+		require.Error(t, err)
+		err = tx.Exec("INSERT INTO test (b) VALUES (2)")
+		require.NoError(t, err)
+
+		res, err := tx.Query("SELECT * FROM test")
+		require.NoError(t, err)
+		r, err := res.First()
+		require.NoError(t, res.Close())
+		require.NoError(t, err)
+
+		f, err := r.GetField("b")
+		require.NoError(t, err)
+		require.Equal(t, value.NewInt8(2), f.Value)
+	})
+}
+
 func TestTxCreateIndex(t *testing.T) {
 	t.Run("Should create an index and return it", func(t *testing.T) {
 		tx, cleanup := newTestDB(t)
@@ -328,6 +428,42 @@ func TestTableInsert(t *testing.T) {
 		require.NotEqual(t, key1, key2)
 	})
 
+	t.Run("Should generate UUID keys", func(t *testing.T) {
+		tests := []struct {
+			generator genji.KeyGenerator
+			version   byte
+		}{
+			{genji.UUIDKeyGenerator, 4},
+			{genji.OrderedUUIDKeyGenerator, 7},
+		}
+
+		for _, test := range tests {
+			tx, cleanup := newTestDB(t)
+			defer cleanup()
+
+			tb, err := tx.CreateTableWithConfig("test", &genji.TableConfig{KeyGenerator: test.generator})
+			require.NoError(t, err)
+
+			var prev []byte
+			for i := 0; i < 3; i++ {
+				key, err := tb.Insert(newRecord())
+				require.NoError(t, err)
+
+				u, err := value.DecodeUUID(key)
+				require.NoError(t, err)
+				require.Equal(t, test.version, u[6]>>4)
+				require.Equal(t, byte(0x80), u[8]&0xC0)
+
+				// ordered UUIDs generated during different milliseconds are sorted
+				if test.generator == genji.OrderedUUIDKeyGenerator && prev != nil {
+					require.Equal(t, -1, bytes.Compare(prev, key))
+				}
+				prev = key
+				time.Sleep(time.Millisecond)
+			}
+		}
+	})
+
 	t.Run("Should support PrimaryKeyer interface", func(t *testing.T) {
 		tb, cleanup := newTestTable(t)
 		defer cleanup()
@@ -419,9 +555,12 @@ func TestTableInsert(t *testing.T) {
 		require.NoError(t, err)
 		require.NotEmpty(t, key)
 
+		enc, err := index.EncodeValue(foo.Value)
+		require.NoError(t, err)
+
 		var count int
 		err = idx.AscendGreaterOrEqual(nil, func(v, k []byte) error {
-			require.Equal(t, v, foo.Data)
+			require.Equal(t, enc, v)
 			require.Equal(t, key, k)
 			count++
 			return nil
@@ -604,6 +743,149 @@ func TestTableIndexes(t *testing.T) {
 	})
 }
 
+func TestTableCompression(t *testing.T) {
+	ng := memory.NewEngine()
+
+	db, err := genji.New(ng)
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE events COMPRESSED AUTOINCREMENT; CREATE INDEX idx_events_msg ON events (msg)")
+	require.NoError(t, err)
+
+	msg := strings.Repeat("connection refused ", 20)
+	for i := 1; i <= 3; i++ {
+		err = db.Exec("INSERT INTO events (id, msg) VALUES (?, ?)", i, msg)
+		require.NoError(t, err)
+	}
+
+	err = db.Exec("UPDATE events SET msg = 'ok' WHERE id = 2")
+	require.NoError(t, err)
+
+	tx, err := ng.Begin(false)
+	require.NoError(t, err)
+	st, err := tx.Store("events")
+	require.NoError(t, err)
+	v, err := st.Get(value.EncodeInt64(1))
+	require.NoError(t, err)
+	require.Less(t, len(v), len(msg)/2)
+	require.NoError(t, tx.Rollback())
+
+	res, err := db.Query("SELECT id, msg FROM events WHERE msg = ?", msg)
+	require.NoError(t, err)
+	defer res.Close()
+
+	var buf bytes.Buffer
+	err = recordutil.IteratorToCSV(&buf, res)
+	require.NoError(t, err)
+	require.Equal(t, fmt.Sprintf("1,%s\n3,%s\n", msg, msg), buf.String())
+
+	buf.Reset()
+	err = db.ViewTable("events", func(_ *genji.Tx, tb *genji.Table) error {
+		r, err := tb.GetRecord(value.EncodeInt64(2))
+		if err != nil {
+			return err
+		}
+
+		return recordutil.DumpRecord(&buf, r)
+	})
+	require.NoError(t, err)
+	require.Equal(t, "id(Int): 2\nmsg(String): \"ok\"\n", buf.String())
+}
+
+func TestTableFieldDictionary(t *testing.T) {
+	ng := memory.NewEngine()
+
+	db, err := genji.New(ng)
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE customers; CREATE INDEX idx_customers_city ON customers (shipping_city)")
+	require.NoError(t, err)
+
+	// explicit keys make the order of full table scans deterministic
+	err = db.Exec("INSERT INTO customers RECORDS (customer_name: 'john', shipping_city: 'Lyon') KEY 1, (customer_name: 'jane', shipping_city: 'Paris') KEY 2")
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO customers RECORDS (customer_name: 'bob', customer_age: 20) KEY 3")
+	require.NoError(t, err)
+
+	// field names are stored once, in the dictionary of the table
+	tx, err := ng.Begin(false)
+	require.NoError(t, err)
+	st, err := tx.Store("customers")
+	require.NoError(t, err)
+	err = st.AscendGreaterOrEqual(nil, func(k, v []byte) error {
+		require.NotContains(t, string(v), "customer_name")
+		return nil
+	})
+	require.NoError(t, err)
+
+	st, err = tx.Store("__genji.fields")
+	require.NoError(t, err)
+	var count int
+	err = st.AscendGreaterOrEqual(nil, func(k, v []byte) error {
+		count++
+		return nil
+	})
+	require.NoError(t, err)
+	// one entry per identifier and one per name
+	require.Equal(t, 6, count)
+	require.NoError(t, tx.Rollback())
+
+	res, err := db.Query("SELECT customer_name, customer_age FROM customers WHERE shipping_city = 'Paris' OR customer_age > 10")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = recordutil.IteratorToCSV(&buf, res)
+	require.NoError(t, err)
+	require.NoError(t, res.Close())
+	require.Equal(t, "jane\nbob,20\n", buf.String())
+
+	t.Run("Records without dictionary", func(t *testing.T) {
+		// records stored before the dictionary was introduced contain the names of their fields
+		data, err := record.Encode(record.NewFieldBuffer(record.NewStringField("customer_name", "alice")))
+		require.NoError(t, err)
+
+		tx, err := ng.Begin(true)
+		require.NoError(t, err)
+		st, err := tx.Store("customers")
+		require.NoError(t, err)
+		err = st.Put([]byte("alice"), data)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		err = db.ViewTable("customers", func(_ *genji.Tx, tb *genji.Table) error {
+			r, err := tb.GetRecord([]byte("alice"))
+			if err != nil {
+				return err
+			}
+
+			f, err := r.GetField("customer_name")
+			if err != nil {
+				return err
+			}
+			require.Equal(t, "alice", string(f.Data))
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("Drop", func(t *testing.T) {
+		err := db.Exec("DROP TABLE customers")
+		require.NoError(t, err)
+
+		tx, err := ng.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		st, err := tx.Store("__genji.fields")
+		require.NoError(t, err)
+		err = st.AscendGreaterOrEqual(nil, func(k, v []byte) error {
+			return fmt.Errorf("unexpected key %q", k)
+		})
+		require.NoError(t, err)
+	})
+}
+
 // BenchmarkTableInsert benchmarks the Insert method with 1, 10, 1000 and 10000 successive insertions.
 func BenchmarkTableInsert(b *testing.B) {
 	for size := 1; size <= 10000; size *= 10 {
@@ -662,3 +944,385 @@ func BenchmarkTableScan(b *testing.B) {
 		})
 	}
 }
+
+// BenchmarkTableScanWideRecords benchmarks a scan of 1, 10, 1000 and 10000 records of 100 fields,
+// reading three fields of each record.
+func BenchmarkTableScanWideRecords(b *testing.B) {
+	for size := 1; size <= 10000; size *= 10 {
+		b.Run(fmt.Sprintf("%.05d", size), func(b *testing.B) {
+			tb, cleanup := newTestTable(b)
+			defer cleanup()
+
+			var fields []record.Field
+
+			for i := int64(0); i < 100; i++ {
+				fields = append(fields, record.NewInt64Field(fmt.Sprintf("name-%d", i), i))
+			}
+
+			rec := record.FieldBuffer(fields)
+
+			for i := 0; i < size; i++ {
+				_, err := tb.Insert(rec)
+				require.NoError(b, err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tb.Iterate(func(r record.Record) error {
+					r.GetField("name-10")
+					r.GetField("name-50")
+					r.GetField("name-99")
+					return nil
+				})
+			}
+			b.StopTimer()
+		})
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	ng := memory.NewEngine()
+
+	db, err := genji.New(ng)
+	require.NoError(t, err)
+	defer db.Close()
+
+	v, err := db.FormatVersion()
+	require.NoError(t, err)
+	require.Equal(t, record.FormatVersion, v)
+
+	err = db.Exec("CREATE TABLE test; CREATE INDEX idx_test_a ON test (a); CREATE TABLE checked (a INT8 NOT NULL)")
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO test (a, b) VALUES (1, {c: 'foo'}), (2, {c: 'bar'})")
+	require.NoError(t, err)
+
+	// simulate a database created before the format was versioned,
+	// by stripping the version of every record of the table and of the nested documents
+	old := make(map[string][]byte)
+	err = db.ViewTable("test", func(_ *genji.Tx, tb *genji.Table) error {
+		return tb.Iterate(func(r record.Record) error {
+			var fb record.FieldBuffer
+			err := fb.ScanRecord(r)
+			require.NoError(t, err)
+			fb[1].Data = fb[1].Data[3:]
+			data, err := record.Encode(fb)
+			require.NoError(t, err)
+			old[string(r.(record.Keyer).Key())] = data[3:]
+			return nil
+		})
+	})
+	require.NoError(t, err)
+
+	tx, err := ng.Begin(true)
+	require.NoError(t, err)
+	meta, err := tx.Store("__genji.meta")
+	require.NoError(t, err)
+	err = meta.Delete([]byte("format"))
+	require.NoError(t, err)
+	st, err := tx.Store("test")
+	require.NoError(t, err)
+	for k, v := range old {
+		err = st.Put([]byte(k), v)
+		require.NoError(t, err)
+	}
+	// old records that don't satisfy the constraints of their table are upgraded as well
+	data, err := record.Encode(record.NewFieldBuffer(record.NewInt8Field("b", 1)))
+	require.NoError(t, err)
+	st, err = tx.Store("checked")
+	require.NoError(t, err)
+	err = st.Put([]byte("legacy"), data[3:])
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	db, err = genji.New(ng)
+	require.NoError(t, err)
+
+	v, err = db.FormatVersion()
+	require.NoError(t, err)
+	require.EqualValues(t, 0, v)
+
+	// old records can be read
+	res, err := db.Query("SELECT b FROM test WHERE a = 2")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	err = recordutil.IteratorToJSON(&buf, res)
+	require.NoError(t, err)
+	require.NoError(t, res.Close())
+	require.Equal(t, `{"b":{"c":"bar"}}`+"\n", buf.String())
+
+	err = db.Upgrade()
+	require.NoError(t, err)
+
+	v, err = db.FormatVersion()
+	require.NoError(t, err)
+	require.Equal(t, record.FormatVersion, v)
+
+	tx, err = ng.Begin(false)
+	require.NoError(t, err)
+	st, err = tx.Store("test")
+	require.NoError(t, err)
+	err = st.AscendGreaterOrEqual(nil, func(k, data []byte) error {
+		v, err := record.DecodeVersion(data)
+		require.NoError(t, err)
+		require.Equal(t, record.FormatVersion, v)
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+
+	err = db.ViewTable("test", func(_ *genji.Tx, tb *genji.Table) error {
+		return tb.Iterate(func(r record.Record) error {
+			f, err := r.GetField("b")
+			require.NoError(t, err)
+			v, err := record.DecodeVersion(f.Data)
+			require.NoError(t, err)
+			require.Equal(t, record.FormatVersion, v)
+			return nil
+		})
+	})
+	require.NoError(t, err)
+
+	// the index is still usable
+	res, err = db.Query("SELECT b FROM test WHERE a = 1")
+	require.NoError(t, err)
+	buf.Reset()
+	err = recordutil.IteratorToJSON(&buf, res)
+	require.NoError(t, err)
+	require.NoError(t, res.Close())
+	require.Equal(t, `{"b":{"c":"foo"}}`+"\n", buf.String())
+
+	t.Run("Unsupported version", func(t *testing.T) {
+		tx, err := ng.Begin(true)
+		require.NoError(t, err)
+		meta, err := tx.Store("__genji.meta")
+		require.NoError(t, err)
+		data, err := record.Encode(record.NewFieldBuffer(record.NewUint8Field("Version", record.FormatVersion+1)))
+		require.NoError(t, err)
+		err = meta.Put([]byte("format"), data)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		_, err = genji.New(ng)
+		require.Error(t, err)
+	})
+}
+
+func TestRebuildIndexes(t *testing.T) {
+	ng := memory.NewEngine()
+
+	db, err := genji.New(ng)
+	require.NoError(t, err)
+
+	err = db.Exec("CREATE TABLE test; CREATE INDEX idx_test_a ON test (a); CREATE UNIQUE INDEX idx_test_b ON test (b)")
+	require.NoError(t, err)
+	for i := 1; i <= 5; i++ {
+		err = db.Exec("INSERT INTO test (a, b) VALUES (?, ?)", i, fmt.Sprintf("foo%d", i))
+		require.NoError(t, err)
+	}
+
+	// simulate indexes written by the version 0 of the format,
+	// in which entries of list indexes are not suffixed with the length of the key
+	downgrade := func() {
+		tx, err := ng.Begin(true)
+		require.NoError(t, err)
+		st, err := tx.Store("i\x1fidx_test_a")
+		require.NoError(t, err)
+		var entries [][]byte
+		err = st.AscendGreaterOrEqual(nil, func(k, _ []byte) error {
+			entries = append(entries, append([]byte{}, k[:len(k)-4]...))
+			return nil
+		})
+		require.NoError(t, err)
+		require.NoError(t, st.Truncate())
+		for _, k := range entries {
+			require.NoError(t, st.Put(k, nil))
+		}
+		meta, err := tx.Store("__genji.meta")
+		require.NoError(t, err)
+		require.NoError(t, meta.Delete([]byte("indexFormat")))
+		require.NoError(t, tx.Commit())
+	}
+
+	iterateIndex := func(db *genji.DB) error {
+		return db.View(func(tx *genji.Tx) error {
+			idx, err := tx.GetIndex("idx_test_a")
+			if err != nil {
+				return err
+			}
+
+			return idx.AscendGreaterOrEqual(nil, func(value, key []byte) error {
+				return nil
+			})
+		})
+	}
+
+	check := func(db *genji.DB) {
+		require.NoError(t, iterateIndex(db))
+
+		res, err := db.Query("SELECT a FROM test WHERE a > 2")
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		err = recordutil.IteratorToJSON(&buf, res)
+		// close the result before any assertion to release the transaction
+		require.NoError(t, res.Close())
+		require.NoError(t, err)
+		require.Equal(t, "{\"a\":3}\n{\"a\":4}\n{\"a\":5}\n", buf.String())
+
+		err = db.Exec("INSERT INTO test (a, b) VALUES (6, 'foo1')")
+		require.Equal(t, genji.ErrDuplicateRecord, err)
+
+		tx, err := ng.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		meta, err := tx.Store("__genji.meta")
+		require.NoError(t, err)
+		_, err = meta.Get([]byte("indexFormat"))
+		require.NoError(t, err)
+	}
+
+	downgrade()
+
+	// legacy entries are detected
+	require.Equal(t, index.ErrInvalidEntry, iterateIndex(db))
+
+	// indexes are rebuilt when the database is opened
+	db, err = genji.New(ng)
+	require.NoError(t, err)
+	defer db.Close()
+	check(db)
+
+	// and when the database is upgraded
+	downgrade()
+	err = db.Upgrade()
+	require.NoError(t, err)
+	check(db)
+}
+
+func TestQueryContext(t *testing.T) {
+	db, err := genji.New(memory.NewEngine())
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test; CREATE INDEX idx_test_a ON test (a)")
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		err = db.Exec("INSERT INTO test (a) VALUES (?)", i)
+		require.NoError(t, err)
+	}
+
+	for _, q := range []string{"SELECT * FROM test", "SELECT * FROM test WHERE a >= 0"} {
+		t.Run(q, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			res, err := db.QueryContext(ctx, q)
+			require.NoError(t, err)
+			defer res.Close()
+
+			var count int
+			err = res.Iterate(func(r record.Record) error {
+				count++
+				cancel()
+				return nil
+			})
+			require.Equal(t, context.Canceled, err)
+			require.Equal(t, 1, count)
+		})
+	}
+
+	t.Run("Done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		defer cancel()
+		<-ctx.Done()
+
+		err := db.ExecContext(ctx, "DELETE FROM test")
+		require.Equal(t, context.DeadlineExceeded, err)
+
+		_, err = db.BeginContext(ctx, false)
+		require.Equal(t, context.DeadlineExceeded, err)
+	})
+
+	t.Run("Transaction", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		tx, err := db.BeginContext(ctx, false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		require.Equal(t, ctx, tx.Context())
+
+		// promoting the transaction keeps its context
+		err = tx.Exec("DELETE FROM test WHERE a = 100")
+		require.NoError(t, err)
+		require.True(t, tx.Writable())
+		require.Equal(t, ctx, tx.Context())
+
+		cancel()
+
+		err = tx.Exec("DELETE FROM test")
+		require.Equal(t, context.Canceled, err)
+	})
+}
+
+func TestPrepare(t *testing.T) {
+	db, err := genji.New(memory.NewEngine())
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test")
+	require.NoError(t, err)
+
+	_, err = db.Prepare("SELECT FROM test")
+	require.Error(t, err)
+
+	insert, err := db.Prepare("INSERT INTO test (a, b) VALUES (?, ?)")
+	require.NoError(t, err)
+	require.Equal(t, 2, insert.NumInput())
+
+	selectA, err := db.Prepare("SELECT * FROM test WHERE a >= $a")
+	require.NoError(t, err)
+	require.Equal(t, -1, selectA.NumInput())
+
+	count := func(t *testing.T, s *genji.Statement, args ...interface{}) int {
+		res, err := s.Query(args...)
+		require.NoError(t, err)
+		defer res.Close()
+
+		n, err := res.Count()
+		require.NoError(t, err)
+		return n
+	}
+
+	for i := 0; i < 5; i++ {
+		err = insert.Exec(i, "foo")
+		require.NoError(t, err)
+	}
+	require.Equal(t, 3, count(t, selectA, sql.Named("a", 2)))
+
+	t.Run("Transaction", func(t *testing.T) {
+		tx, err := db.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		// the transaction gets promoted
+		err = tx.Stmt(insert).Exec(10, "bar")
+		require.NoError(t, err)
+		require.True(t, tx.Writable())
+		require.Equal(t, 4, count(t, tx.Stmt(selectA), sql.Named("a", 2)))
+		require.NoError(t, tx.Rollback())
+
+		// the statement can be used in another transaction
+		tx, err = db.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		require.Equal(t, 3, count(t, tx.Stmt(selectA), sql.Named("a", 2)))
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := insert.ExecContext(ctx, 20, "baz")
+		require.Equal(t, context.Canceled, err)
+		require.Equal(t, 5, count(t, selectA, sql.Named("a", 0)))
+	})
+}
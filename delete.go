@@ -92,7 +92,16 @@ func (stmt deleteStmt) Run(tx *Tx, args []driver.NamedValue) (Result, error) {
 		keys = keys[:i]
 
 		for _, key := range keys {
+			err = tx.checkInterrupted()
+			if err != nil {
+				return res, err
+			}
+
 			err = t.Delete(key)
+			// the record might have been deleted by a foreign key cascade
+			if err == ErrRecordNotFound {
+				continue
+			}
 			if err != nil {
 				return res, err
 			}
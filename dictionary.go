@@ -0,0 +1,209 @@
+package genji
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/asdine/genji/engine"
+	"github.com/asdine/genji/record"
+	"github.com/asdine/genji/value"
+)
+
+// prefixes of the keys of the field dictionaries, stored after the name of the table.
+const (
+	fieldIDPrefix   byte = 'i' // identifier to name
+	fieldNamePrefix byte = 'n' // name to identifier
+)
+
+// fieldDictionary is the field dictionary of a table. It associates the names of the top-level
+// fields of the records of the table with integer identifiers, so that the names are stored once,
+// in the __genji.fields system table, rather than in every record.
+// Identifiers are assigned in the order in which field names are first written, starting at 0,
+// and are never reused, even if no record contains the field anymore.
+// The dictionary is shared by every instance of the table within a transaction and its entries
+// are cached for the lifetime of the transaction; cache misses are looked up in the store.
+type fieldDictionary struct {
+	tx        *Tx
+	tableName string
+	ids       map[string]uint64
+	names     map[uint64]string
+}
+
+func newFieldDictionary(tx *Tx, tableName string) *fieldDictionary {
+	return &fieldDictionary{
+		tx:        tx,
+		tableName: tableName,
+	}
+}
+
+// FieldID returns the identifier of a field name. If the name isn't part of the dictionary,
+// it is assigned the next identifier.
+func (d *fieldDictionary) FieldID(name string) (uint64, error) {
+	if id, ok := d.ids[name]; ok {
+		return id, nil
+	}
+
+	s, err := d.tx.tx.Store(fieldsTable)
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := s.Get(d.nameKey(name))
+	if err == nil {
+		f, err := record.EncodedRecord(v).GetField("ID")
+		if err != nil {
+			return 0, err
+		}
+
+		id, err := f.DecodeToUint64()
+		if err != nil {
+			return 0, err
+		}
+
+		d.cache(id, name)
+		return id, nil
+	}
+	if err != engine.ErrKeyNotFound {
+		return 0, err
+	}
+
+	id, err := d.nextID(s)
+	if err != nil {
+		return 0, err
+	}
+
+	v, err = record.Encode(record.NewFieldBuffer(
+		record.NewStringField("Name", name),
+		record.NewUint64Field("ID", id),
+	))
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.Put(d.idKey(id), v)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.Put(d.nameKey(name), v)
+	if err != nil {
+		return 0, err
+	}
+
+	d.cache(id, name)
+	return id, nil
+}
+
+// FieldName returns the name associated with an identifier.
+func (d *fieldDictionary) FieldName(id uint64) (string, error) {
+	if name, ok := d.names[id]; ok {
+		return name, nil
+	}
+
+	s, err := d.tx.tx.Store(fieldsTable)
+	if err != nil {
+		return "", err
+	}
+
+	v, err := s.Get(d.idKey(id))
+	if err == engine.ErrKeyNotFound {
+		return "", fmt.Errorf("field %d not found in the dictionary of table %q", id, d.tableName)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	f, err := record.EncodedRecord(v).GetField("Name")
+	if err != nil {
+		return "", err
+	}
+
+	name, err := f.DecodeToString()
+	if err != nil {
+		return "", err
+	}
+
+	d.cache(id, name)
+	return name, nil
+}
+
+func (d *fieldDictionary) cache(id uint64, name string) {
+	if d.ids == nil {
+		d.ids = make(map[string]uint64)
+		d.names = make(map[uint64]string)
+	}
+
+	d.ids[name] = id
+	d.names[id] = name
+}
+
+// nextID returns the identifier following the greatest identifier of the dictionary.
+func (d *fieldDictionary) nextID(s engine.Store) (uint64, error) {
+	prefix := d.idKey(0)
+	prefix = prefix[:len(prefix)-8]
+
+	var next uint64
+	err := s.DescendLessOrEqual(d.idKey(^uint64(0)), func(k, _ []byte) error {
+		if bytes.HasPrefix(k, prefix) {
+			id, err := value.DecodeUint64(k[len(prefix):])
+			if err != nil {
+				return err
+			}
+			next = id + 1
+		}
+
+		return errStop
+	})
+	if err != nil && err != errStop {
+		return 0, err
+	}
+
+	return next, nil
+}
+
+func dictionaryPrefix(tableName string) []byte {
+	buf := make([]byte, 0, len(tableName)+10)
+	buf = append(buf, tableName...)
+	return append(buf, separator)
+}
+
+func (d *fieldDictionary) idKey(id uint64) []byte {
+	return append(append(dictionaryPrefix(d.tableName), fieldIDPrefix), value.EncodeUint64(id)...)
+}
+
+func (d *fieldDictionary) nameKey(name string) []byte {
+	return append(append(dictionaryPrefix(d.tableName), fieldNamePrefix), name...)
+}
+
+// dropFieldDictionary deletes the field dictionary of a table.
+func (tx Tx) dropFieldDictionary(tableName string) error {
+	s, err := tx.tx.Store(fieldsTable)
+	if err != nil {
+		return err
+	}
+
+	prefix := dictionaryPrefix(tableName)
+
+	// stores can't be modified while being iterated on
+	var keys [][]byte
+	err = s.AscendGreaterOrEqual(prefix, func(k, _ []byte) error {
+		if !bytes.HasPrefix(k, prefix) {
+			return errStop
+		}
+
+		keys = append(keys, append([]byte{}, k...))
+		return nil
+	})
+	if err != nil && err != errStop {
+		return err
+	}
+
+	for _, k := range keys {
+		err = s.Delete(k)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -2,7 +2,7 @@
 Package genji implements a SQL database on top of key-value stores.
 Genji supports various engines that write data on-disk, like BoltDB or Badger, and in memory.
 
-Genji tables are schemaless and can be mapped to Go structures without reflection: Genji relies on code generation to translate data to and from Go structures.
+Genji tables are schemaless by default and can be mapped to Go structures without reflection: Genji relies on code generation to translate data to and from Go structures.
 
 Engine and key value stores
 
@@ -13,6 +13,11 @@ Out of the box, Genji provides three implementations: BoltDB, Badger and in-memo
 
 See the engine package documentation for more details.
 
+Records are encoded using a versioned format, see the record package. Records written by previous versions
+of Genji remain readable and are rewritten using the latest format when they are modified.
+The DB.Upgrade method, or the genji upgrade command, rewrites all the records of a database at once
+and rebuilds all of its indexes.
+
 Field, Record, Table and Stream
 
 Genji defines its own semantic to describe data.
@@ -23,7 +28,7 @@ is to map structures and maps to tables, though it's not limited to that.
 That's why the triplet "field", "record" and "table" was chosen.
 
 A field is a piece of information that has a type, content, and a name.
-The field is equivalent to the SQL column, though it might contain nested fields in the future.
+The field is equivalent to the SQL column, though it can also contain a nested record, called a document.
 
 A record is a group of fields. It is an interface that can be implemented manually or by using Genji's code generation.
 This is equivalent to the SQL row. It is managed by the record package, which also provides ways to encode and decode records.
@@ -57,6 +62,91 @@ or:
 
   CREATE TABLE tableName IF NOT EXISTS
 
+A list of fields can optionally be declared, with or without a type, and followed by constraints:
+
+  CREATE TABLE tableName (fieldNameA STRING NOT NULL, fieldNameB INT8, fieldNameC STRING UNIQUE, fieldNameD NOT NULL)
+
+Supported types are BYTES, STRING, BOOL, UINT, UINT8, UINT16, UINT32, UINT64, INT, INT8, INT16, INT32, INT64,
+FLOAT32, FLOAT64, DECIMAL, TIMESTAMP, DURATION and UUID. Declared fields are converted to their type when records are inserted or updated.
+The conversion fails if it loses information, for example when converting 1000 to INT8 or 1.5 to INT64.
+
+Supported constraints are:
+
+  NOT NULL      The field must be present in every record and can't be null
+  UNIQUE        Two records can't share the same value for that field, except null. A unique index is automatically created
+  DEFAULT expr  The expression is evaluated and used as the value of the field if it is missing from an inserted record
+  CHECK (expr)  The expression must be truthy or null for every record inserted or updated
+
+  REFERENCES table[(field)] [ON DELETE action]
+                The value must match a record of the referenced table, see foreign keys below
+
+CHECK constraints can also be declared at the table level, alongside fields:
+
+  CREATE TABLE tableName (fieldNameA INT8 DEFAULT 0 CHECK (fieldNameA >= 0), fieldNameB, CHECK (fieldNameA < fieldNameB))
+
+Constraint violations are reported as a *ConstraintViolationError.
+
+A foreign key references either a field covered by a unique index, or the primary key of the referenced table
+if no field is specified:
+
+  CREATE TABLE users (email STRING UNIQUE)
+  CREATE TABLE posts (author STRING REFERENCES users(email) ON DELETE CASCADE, category REFERENCES categories)
+
+When a referenced record is deleted, the ON DELETE action is applied to the records referencing it:
+
+  RESTRICT  The deletion fails. This is the default action
+  CASCADE   The referencing records are deleted as well
+  SET NULL  The referencing field of the referencing records is set to null
+
+Modifying the referenced field of a record that is still referenced fails. Truncating a referenced table applies
+the ON DELETE action to every record, like deleting them one by one. Dropping a table doesn't check foreign keys.
+
+Fields that aren't declared are still allowed, unless the table is strict:
+
+  CREATE TABLE tableName (fieldNameA STRING, fieldNameB INT8) STRICT
+
+By default, records inserted without a primary key are given a random ULID as key. With AUTOINCREMENT,
+keys are generated from a sequence owned by the table instead, starting at 1, so records are stored in insertion order.
+The generated key is then returned by the LastInsertId method of the database/sql result.
+
+  CREATE TABLE tableName (fieldNameA STRING) AUTOINCREMENT
+
+With UUID, keys are random version 4 UUIDs. With UUID ORDERED, keys are version 7 UUIDs, which
+start with a timestamp and are stored in insertion order, like ULIDs. UUID keys are stored as 16 bytes,
+see value.EncodeUUID.
+
+  CREATE TABLE tableName (fieldNameA STRING) UUID ORDERED
+
+With COMPRESSED, records are compressed with DEFLATE before being stored, which saves space for tables
+containing repetitive data, like logs or events, at the cost of some CPU time.
+Compressed records are decompressed transparently when they are read.
+
+  CREATE TABLE tableName COMPRESSED
+
+The configuration of each table is stored in the __genji.tables system table.
+The names of the fields of the records are not repeated in every record: each table has a dictionary,
+stored in the __genji.fields system table, which associates field names with small integer identifiers.
+Records are encoded using those identifiers, only the names of the fields of nested documents are
+stored in the records.
+
+The CREATE SEQUENCE statement
+
+A sequence generates monotonic integers, starting at 1:
+
+  CREATE SEQUENCE sequenceName
+  CREATE SEQUENCE IF NOT EXISTS sequenceName
+
+or at any other value:
+
+  CREATE SEQUENCE sequenceName START WITH 100
+
+Its next value is returned by the NEXTVAL function, which can be used in any expression, including default values:
+
+  CREATE TABLE tableName (id DEFAULT NEXTVAL('sequenceName'))
+  INSERT INTO tableName (id) VALUES (NEXTVAL('sequenceName'))
+
+Sequences are updated within the transaction, if it is rolled back the values are generated again.
+
 The CREATE INDEX statement
 
 Only one-field indexes are currently supported:
@@ -71,6 +161,17 @@ with if not exists:
 
   CREATE UNIQUE INDEX IF NOT EXISTS indexName ON tableName (fieldName)
 
+Fields of nested documents can be indexed using their path:
+
+  CREATE INDEX indexName ON tableName (fieldName.nestedFieldName)
+
+When an indexed field contains an array, each distinct element of the array gets its own entry in the index,
+which allows looking up records by one of their elements. Unique indexes apply to each element.
+
+Indexes store values using an encoding that sorts all numbers together, whatever their type,
+which means an index on an INT8 field can be used to evaluate age > 18.5 and that a unique index
+considers 1 and 1.0 to be the same value.
+
 The DROP TABLE statement
 
 This will return an error if the table doesn't exists.
@@ -91,6 +192,11 @@ This won't:
 
   DROP INDEX IF EXISTS indexName
 
+The DROP SEQUENCE statement
+
+  DROP SEQUENCE sequenceName
+  DROP SEQUENCE IF EXISTS sequenceName
+
 The INSERT statement
 
 Since tables are schemaless, providing a list of field names is mandatory when using the VALUES clause.
@@ -103,6 +209,13 @@ Note that field names are forbidden when using the RECORDS clause.
 
   INSERT INTO tableName RECORDS (fieldNameA: 10, fieldNameB: true, fieldNameC: "bar"), (fieldNameA: "bab", fieldNameD: 3.14)
 
+Records are inserted with a generated key, unless a key is provided with the KEY clause.
+Integer keys are encoded like the keys of AUTOINCREMENT tables and UUIDs like the keys of UUID tables,
+while strings and bytes are used as is. Inserting an integer key in an AUTOINCREMENT table makes sure
+the keys generated later are greater.
+
+  INSERT INTO tableName RECORDS (fieldNameA: 10) KEY 1, (fieldNameA: 20) KEY 2
+
 The SELECT statement
 
 Explicit field names:
@@ -113,6 +226,10 @@ Using the wildcard:
 
   SELECT * FROM tableName
 
+Using expressions. The resulting fields are named after the expression:
+
+  SELECT fieldNameA, LENGTH(fieldNameB) FROM tableName
+
 With the WHERE clause. See below for documentation about expressions.
 
   SELECT * FROM tableName WHERE <expression>
@@ -142,18 +259,85 @@ Litteral values:
 
   10    Integers, interpreted as int64
   3.14  Decimals, interpreted as float64
+  1e-10 Numbers with an exponent, interpreted as float64
   true  Booleans, interpreted as bool
   "foo" Strings, interpreted as string
   'foo' Strings, interpreted as string
+  x'0a' Bytes, written in hexadecimal, interpreted as []byte
+  NULL  The null value
+  {a: 1, b: {c: 'foo'}}  Documents, interpreted as nested records
+  ['foo', 1, [true]]     Arrays, ordered lists of values of any type
+  1h30m                  Durations, interpreted as time.Duration. Units are ns, u, ms, s, m, h, d and w
+  DECIMAL('12.50')       Exact decimal numbers, interpreted as *big.Rat
+  UUID('f47ac10b-58cc-4372-a567-0e02b2c3d479')  UUIDs, interpreted as [16]byte
 
 Identifiers:
 
-  foo   Any string without quotes is interpreted as a field name
+  foo      Any string without quotes is interpreted as a field name
+  foo.bar  Dotted paths select fields of nested documents
+  foo[0]   Selects an element of an array, starting at 0
+
+Fields that are missing from a record evaluate to null. Null values are never indexed
+and null references don't need to match a referenced record.
+
+Records passed as parameters are stored as documents and documents are returned as records by the
+database/sql driver, arrays can be passed as []interface{} and are returned as such. See the record and recordutil packages to create documents from Go maps or JSON objects.
+
+Functions:
+
+  NEXTVAL('seq')  Increments the sequence and returns its new value
+  LENGTH(expr)    Returns the number of elements of an array, fields of a document or bytes of a string
+  NOW()           Returns the current time as a timestamp, in UTC
+
+Timestamps are created from time.Time values passed as parameters or by NOW(). They are stored
+in UTC and compared chronologically.
+
+Decimals are stored exactly and compared exactly with other numbers, which makes them suitable for
+money or integers that don't fit in a float64. Numbers that don't have a finite decimal expansion,
+like the result of DECIMAL('1') / 3, are rounded to 34 significant digits.
+The DECIMAL function also converts strings and numbers at runtime, e.g. DECIMAL(?).
+With the database/sql package, decimals are passed as *big.Rat and returned as strings.
+
+UUIDs are stored as 16 bytes and sorted byte by byte. Any Go type defined as a [16]byte, which is the case
+of the UUID types of most packages, can be passed as a parameter. The UUID function parses strings
+at runtime, e.g. UUID(?), and strings inserted in UUID fields are parsed as well, but a UUID is never equal to a string:
+WHERE id = 'f47ac10b-58cc-4372-a567-0e02b2c3d479' must be written WHERE id = UUID('f47ac10b-58cc-4372-a567-0e02b2c3d479').
+With the database/sql package, UUIDs are returned in their canonical string form.
+
+Type conversions
+
+Values can be explicitly converted to another type with CAST or the :: operator,
+using any of the type names supported by CREATE TABLE:
+
+  CAST(<expr> AS <type>)
+  <expr>::<type>
+
+Conversions follow these rules:
+
+  numbers to numbers      Allowed if the value fits in the target type without losing its fractional part
+  any number to DECIMAL   Always allowed, floats use their shortest decimal representation
+  STRING to BYTES         Always allowed, and the other way around
+  values to STRING        Numbers, booleans, durations and UUIDs use their text form, timestamps use RFC 3339
+  STRING to numbers       The string is parsed, e.g. CAST('12' AS INT8)
+  STRING to BOOL          Accepts true, false, 1, 0, t, f and their variants in upper case
+  STRING to TIMESTAMP     The string must follow RFC 3339
+  STRING to DURATION      Accepts the Go duration syntax, e.g. '1h30m'
+  STRING to UUID          Same forms as the UUID function
+  BOOL to integers        true is 1, false is 0
+  integers to BOOL        0 is false, any other value is true
+  integers to DURATION    The integer is a number of nanoseconds, and the other way around
+
+Casting null always returns null. Any other conversion, or a conversion that would lose information,
+like CAST(300 AS INT8), CAST(1.5 AS INT64) or CAST('foo' AS INT64), returns an error.
 
 Binary operators: Comparison operators
 
 During comparison, only the values of numbers are compared, not the types,
 which allows comparing signed integers with unsigned integers or floats for example.
+Numbers are compared without losing precision: a float is compared using its shortest
+decimal representation, so an int64 too large to be represented by a float64 is never equal to it.
+Strings and bytes can also be compared with one another. Values of other different types are never equal,
+greater or lesser than each other: the comparison evaluates to false, whether an index is used or not.
 
 When evaluating a binary expression, the left and right expressions are evaluated first
 then compared.
@@ -164,12 +348,49 @@ then compared.
  <exprA> < <exprB>  Evaluates to true if exprA is lesser than exprB
  <exprA> <= <exprB> Evaluates to true if exprA is lesser than or equal to exprB
 
+Comparing a value with null evaluates to null, which is falsy, so WHERE a = NULL never matches.
+Use IS and IS NOT instead, which consider null equal to null:
+
+ <exprA> IS <exprB>     Evaluates to true if exprA and exprB are equal or both null
+ <exprA> IS NOT <exprB> Evaluates to true if exprA and exprB are different or if only one of them is null
+
+Arrays and lists can be searched for a value:
+
+ <expr> IN <array>      Evaluates to true if one of the elements of the array is equal to expr
+ <expr> IN (a, b, c)    Evaluates to true if expr is equal to a, b or c
+ <exprA> = ANY(<array>) Evaluates to true if the comparison is true for at least one element of the array
+ <exprA> = ALL(<array>) Evaluates to true if the comparison is true for every element of the array
+
+ANY and ALL can be used with any comparison operator. Field IN array expressions can use the index
+of the array field.
+
+Binary operators: Arithmetic operators
+
+ <exprA> + <exprB>  Adds exprB to exprA
+ <exprA> - <exprB>  Subtracts exprB from exprA
+ <exprA> * <exprB>  Multiplies exprA by exprB
+ <exprA> / <exprB>  Divides exprA by exprB
+ <exprA> % <exprB>  Returns the remainder of the division of exprA by exprB
+
+Operations on integers return an INT64, or a UINT64 if the result only fits in an unsigned integer,
+and return an error if it fits in neither. Operations involving a decimal return a DECIMAL
+and other numbers return a FLOAT64. Durations can be added to
+or subtracted from timestamps and durations, and multiplied or divided by integers.
+Subtracting two timestamps returns a duration:
+
+  SELECT * FROM tableName WHERE createdAt > NOW() - 1h
 
 Binary operators: Logical operators
 
  <exprA> AND <exprB>   Evaluates to true if exprA and exprB evaluate to true
  <exprA> OR <exprB>    Evaluates to true if exprA or exprB evaluate to true
 
+Logical operators follow three-valued logic: NULL AND false evaluates to false, NULL OR true to true
+and any other combination involving null evaluates to null.
+
+When using the database/sql package, null values are returned as nil and can be scanned
+into sql.NullString, sql.NullInt64, etc.
+
 Parameters
 
 Genji SQL supports two kind of parameters: Positional parameters and named parameters
@@ -5,9 +5,13 @@ import (
 	"database/sql/driver"
 	"errors"
 	"io"
+	"math/big"
+	"reflect"
 	"sync"
+	"time"
 
 	"github.com/asdine/genji/record"
+	"github.com/asdine/genji/value"
 )
 
 type connector struct {
@@ -61,15 +65,14 @@ type conn struct {
 
 // Prepare returns a prepared statement, bound to this connection.
 func (c *conn) Prepare(q string) (driver.Stmt, error) {
-	pq, err := parseQuery(q)
+	ps, err := c.db.Prepare(q)
 	if err != nil {
 		return nil, err
 	}
 
 	return stmt{
-		db:            c.db,
+		ps:            ps,
 		tx:            c.tx,
-		q:             pq,
 		nonPromotable: c.nonPromotable,
 	}, nil
 }
@@ -91,6 +94,7 @@ func (c *conn) Begin() (driver.Tx, error) {
 // BeginTx starts and returns a new transaction.
 // It uses the ReadOnly option to determine whether to start a read-only or read/write transaction.
 // If the Isolation option is non zero, an error is returned.
+// The transaction is bound to ctx, while statements executed within it use their own context.
 func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
 	if opts.Isolation != 0 {
 		return nil, errors.New("isolation levels are not supported")
@@ -104,7 +108,7 @@ func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, e
 		c.nonPromotable = true
 	}
 
-	c.tx, err = c.db.Begin(false)
+	c.tx, err = c.db.BeginContext(ctx, false)
 	return c, err
 }
 
@@ -125,14 +129,14 @@ func (c *conn) Rollback() error {
 // Stmt is a prepared statement. It is bound to a Conn and not
 // used by multiple goroutines concurrently.
 type stmt struct {
-	db            *DB
+	ps            *Statement
 	tx            *Tx
-	q             query
 	nonPromotable bool
 }
 
-// NumInput returns the number of placeholder parameters.
-func (s stmt) NumInput() int { return -1 }
+// NumInput returns the number of placeholder parameters,
+// or -1 if the statement uses named parameters.
+func (s stmt) NumInput() int { return s.ps.NumInput() }
 
 // Exec executes a query that doesn't return rows, such
 // as an INSERT or UPDATE.
@@ -141,10 +145,17 @@ func (s stmt) Exec(args []driver.Value) (driver.Result, error) {
 }
 
 // CheckNamedValue has the same behaviour as driver.DefaultParamaterConverter, except that
-// it allows record.Records to be passed as parameters.
+// it allows record.Records, maps and slices to be passed as parameters, to be used as documents and arrays,
+// durations, which would otherwise be converted to integers, *big.Rat, to be used as decimals,
+// and any type defined as a [16]byte, to be used as UUIDs.
 // It implements the driver.NamedValueChecker interface.
 func (s stmt) CheckNamedValue(nv *driver.NamedValue) error {
-	if _, ok := nv.Value.(record.Record); ok {
+	switch nv.Value.(type) {
+	case record.Record, map[string]interface{}, []interface{}, time.Duration, *big.Rat, [16]byte:
+		return nil
+	}
+
+	if t := reflect.TypeOf(nv.Value); t != nil && t.Kind() == reflect.Array && t.Len() == 16 && t.Elem().Kind() == reflect.Uint8 {
 		return nil
 	}
 
@@ -168,18 +179,18 @@ func (s stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver
 	// if calling ExecContext within a transaction, use it,
 	// otherwise use DB.
 	if s.tx != nil {
-		res, err = s.q.Exec(s.tx, args, s.nonPromotable)
+		res, err = s.ps.q.Exec(ctx, s.tx, args, s.nonPromotable)
 	} else {
-		res, err = s.q.Run(s.db, args)
+		res, err = s.ps.q.Run(ctx, s.ps.db, args)
 	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	// s.q.Run might return a stream if the last Statement is a Select,
+	// s.ps.q.Run might return a stream if the last Statement is a Select,
 	// make sure the result is closed before returning so any transaction
-	// created by s.q.Run is closed.
+	// created by s.ps.q.Run is closed.
 	return res, res.Close()
 }
 
@@ -202,9 +213,9 @@ func (s stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (drive
 	// if calling QueryContext within a transaction, use it,
 	// otherwise use DB.
 	if s.tx != nil {
-		res, err = s.q.Exec(s.tx, args, s.nonPromotable)
+		res, err = s.ps.q.Exec(ctx, s.tx, args, s.nonPromotable)
 	} else {
-		res, err = s.q.Run(s.db, args)
+		res, err = s.ps.q.Run(ctx, s.ps.db, args)
 	}
 
 	if err != nil {
@@ -212,17 +223,17 @@ func (s stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (drive
 	}
 
 	rs := newRecordStream(res)
-	if len(s.q.Statements) == 0 {
+	if len(s.ps.q.Statements) == 0 {
 		return rs, nil
 	}
 
-	lastStmt := s.q.Statements[len(s.q.Statements)-1]
+	lastStmt := s.ps.q.Statements[len(s.ps.q.Statements)-1]
 
 	slct, ok := lastStmt.(selectStmt)
-	if ok && len(slct.FieldSelectors) > 0 {
-		rs.fields = make([]string, len(slct.FieldSelectors))
-		for i := range slct.FieldSelectors {
-			rs.fields[i] = slct.FieldSelectors[i].Name()
+	if ok && len(slct.Projection) > 0 {
+		rs.fields = make([]string, len(slct.Projection))
+		for i := range slct.Projection {
+			rs.fields[i] = projectionName(slct.Projection[i])
 		}
 	}
 
@@ -234,6 +245,37 @@ func (s stmt) Close() error {
 	return nil
 }
 
+// driverValue returns the Go value of v. Documents are returned as records
+// so they can be scanned by record.Scanner implementations and arrays as slices.
+func driverValue(v value.Value) (driver.Value, error) {
+	switch v.Type {
+	case value.Document:
+		return record.DecodeDocument(v)
+	case value.Array:
+		values, err := v.DecodeToArray()
+		if err != nil {
+			return nil, err
+		}
+
+		l := make([]interface{}, len(values))
+		for i := range values {
+			l[i], err = driverValue(values[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+		return l, nil
+	case value.Decimal:
+		// decimals are returned as strings to avoid losing precision,
+		// they can be scanned into strings or floats.
+		return v.String(), nil
+	case value.UUID:
+		return v.String(), nil
+	}
+
+	return v.Decode()
+}
+
 type recordStream struct {
 	res      *Result
 	cancelFn func()
@@ -337,12 +379,14 @@ func (rs *recordStream) Next(dest []driver.Value) error {
 	}
 
 	for i := range rs.fields {
+		// missing fields are returned as null
 		f, err := rec.r.GetField(rs.fields[i])
 		if err != nil {
-			return err
+			dest[i] = nil
+			continue
 		}
 
-		dest[i], err = f.Decode()
+		dest[i], err = driverValue(f.Value)
 		if err != nil {
 			return err
 		}
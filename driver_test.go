@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"math/big"
 	"testing"
 	"time"
 
@@ -119,6 +120,24 @@ func TestDriver(t *testing.T) {
 		require.Equal(t, 10, count)
 	})
 
+	t.Run("Expressions", func(t *testing.T) {
+		rows, err := dbx.Query("SELECT a, CAST(b AS STRING) FROM test WHERE a = 1")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		columns, err := rows.Columns()
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "CAST(b AS STRING)"}, columns)
+
+		var a int
+		var b string
+		require.True(t, rows.Next())
+		require.NoError(t, rows.Scan(&a, &b))
+		require.Equal(t, 1, a)
+		require.Equal(t, "2", b)
+		require.NoError(t, rows.Err())
+	})
+
 	t.Run("Params", func(t *testing.T) {
 		rows, err := dbx.Query("SELECT a FROM test WHERE a = ? AND b = ?", 5, 6)
 		require.NoError(t, err)
@@ -153,6 +172,41 @@ func TestDriver(t *testing.T) {
 		require.Equal(t, 1, count)
 	})
 
+	t.Run("Null", func(t *testing.T) {
+		var a sql.NullInt64
+		var d sql.NullString
+		err := dbx.QueryRow("SELECT a, d FROM test WHERE a = ?", 5).Scan(&a, &d)
+		require.NoError(t, err)
+		require.Equal(t, sql.NullInt64{Int64: 5, Valid: true}, a)
+		require.False(t, d.Valid)
+	})
+
+	t.Run("Decimal", func(t *testing.T) {
+		_, err := dbx.Exec("CREATE TABLE prices")
+		require.NoError(t, err)
+		_, err = dbx.Exec("INSERT INTO prices (a, b) VALUES (?, ?)", big.NewRat(1, 8), big.NewRat(1, 4))
+		require.NoError(t, err)
+
+		var s string
+		var f float64
+		err = dbx.QueryRow("SELECT a, b FROM prices").Scan(&s, &f)
+		require.NoError(t, err)
+		require.Equal(t, "0.125", s)
+		require.Equal(t, 0.25, f)
+	})
+
+	t.Run("UUID", func(t *testing.T) {
+		type myUUID [16]byte
+
+		_, err := dbx.Exec("CREATE TABLE ids; INSERT INTO ids (a) VALUES (?)", myUUID{15: 1})
+		require.NoError(t, err)
+
+		var s string
+		err = dbx.QueryRow("SELECT a FROM ids WHERE a = UUID('00000000-0000-0000-0000-000000000001')").Scan(&s)
+		require.NoError(t, err)
+		require.Equal(t, "00000000-0000-0000-0000-000000000001", s)
+	})
+
 	t.Run("Transactions", func(t *testing.T) {
 		tx, err := dbx.Begin()
 		require.NoError(t, err)
@@ -234,4 +288,48 @@ func TestDriver(t *testing.T) {
 		`)
 		require.Equal(t, err, engine.ErrTransactionReadOnly)
 	})
+
+	t.Run("Context", func(t *testing.T) {
+		tx, err := dbx.BeginTx(context.Background(), nil)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		rows, err := tx.QueryContext(ctx, "SELECT * FROM test")
+		require.NoError(t, err)
+		require.True(t, rows.Next())
+		cancel()
+		for rows.Next() {
+		}
+		require.Equal(t, context.Canceled, rows.Err())
+
+		// the transaction isn't bound to the context of the query
+		var count int
+		rows, err = tx.Query("SELECT * FROM test")
+		require.NoError(t, err)
+		defer rows.Close()
+		for rows.Next() {
+			count++
+		}
+		require.NoError(t, rows.Err())
+		require.Equal(t, 11, count)
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM test")
+		require.Equal(t, context.Canceled, err)
+	})
+
+	t.Run("NumInput", func(t *testing.T) {
+		_, err := dbx.Exec("INSERT INTO test (a, b, c) VALUES (?, ?)", 1, 2, 3)
+		require.EqualError(t, err, "sql: expected 2 arguments, got 3")
+
+		stmt, err := dbx.Prepare("SELECT * FROM test WHERE a = $a AND b = $a")
+		require.NoError(t, err)
+		defer stmt.Close()
+
+		var rt rectest
+		err = stmt.QueryRow(sql.Named("a", 1)).Scan(&rt)
+		require.Equal(t, sql.ErrNoRows, err)
+	})
 }
@@ -16,9 +16,11 @@ func (p *parser) parseDropStatement() (statement, error) {
 		return p.parseDropTableStatement()
 	case scanner.INDEX:
 		return p.parseDropIndexStatement()
+	case scanner.SEQUENCE:
+		return p.parseDropSequenceStatement()
 	}
 
-	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX"}, pos)
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX", "SEQUENCE"}, pos)
 }
 
 // parseDropTableStatement parses a drop table string and returns a Statement AST object.
@@ -128,3 +130,57 @@ func (stmt dropIndexStmt) Run(tx *Tx, args []driver.NamedValue) (Result, error)
 
 	return res, err
 }
+
+// parseDropSequenceStatement parses a drop sequence string and returns a Statement AST object.
+// This function assumes the DROP SEQUENCE tokens have already been consumed.
+func (p *parser) parseDropSequenceStatement() (dropSequenceStmt, error) {
+	var stmt dropSequenceStmt
+	var err error
+
+	// Parse "IF"
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.IF {
+		// Parse "EXISTS"
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.EXISTS {
+			return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"EXISTS"}, pos)
+		}
+		stmt.ifExists = true
+	} else {
+		p.Unscan()
+	}
+
+	// Parse sequence name
+	stmt.sequenceName, err = p.ParseIdent()
+	if err != nil {
+		return stmt, err
+	}
+
+	return stmt, nil
+}
+
+// dropSequenceStmt is a DSL that allows creating a DROP SEQUENCE query.
+type dropSequenceStmt struct {
+	sequenceName string
+	ifExists     bool
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt dropSequenceStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the DropSequence statement in the given transaction.
+// It implements the Statement interface.
+func (stmt dropSequenceStmt) Run(tx *Tx, args []driver.NamedValue) (Result, error) {
+	var res Result
+
+	if stmt.sequenceName == "" {
+		return res, errors.New("missing sequence name")
+	}
+
+	err := tx.DropSequence(stmt.sequenceName)
+	if err == ErrSequenceNotFound && stmt.ifExists {
+		err = nil
+	}
+
+	return res, err
+}
@@ -18,6 +18,8 @@ func TestParserDrop(t *testing.T) {
 		{"Drop table If not exists", "DROP TABLE IF EXISTS test", dropTableStmt{tableName: "test", ifExists: true}, false},
 		{"Drop index", "DROP INDEX test", dropIndexStmt{indexName: "test"}, false},
 		{"Drop index if exists", "DROP INDEX IF EXISTS test", dropIndexStmt{indexName: "test", ifExists: true}, false},
+		{"Drop sequence", "DROP SEQUENCE test", dropSequenceStmt{sequenceName: "test"}, false},
+		{"Drop sequence if exists", "DROP SEQUENCE IF EXISTS test", dropSequenceStmt{sequenceName: "test", ifExists: true}, false},
 	}
 
 	for _, test := range tests {
@@ -44,6 +46,9 @@ func TestDropStmt(t *testing.T) {
 		{"Drop table If not exists", "DROP TABLE IF EXISTS test", false},
 		{"Drop index", "DROP INDEX idx", false},
 		{"Drop index if exists", "DROP INDEX IF EXISTS idx", false},
+		{"Drop sequence", "DROP SEQUENCE seq", false},
+		{"Drop unknown sequence", "DROP SEQUENCE foo", true},
+		{"Drop unknown sequence if exists", "DROP SEQUENCE IF EXISTS foo", false},
 	}
 
 	for _, test := range tests {
@@ -52,7 +57,7 @@ func TestDropStmt(t *testing.T) {
 			require.NoError(t, err)
 			defer db.Close()
 
-			err = db.Exec("CREATE TABLE test; CREATE INDEX idx ON test (foo)")
+			err = db.Exec("CREATE TABLE test; CREATE INDEX idx ON test (foo); CREATE SEQUENCE seq")
 			require.NoError(t, err)
 
 			err = db.Exec(test.query)
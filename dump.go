@@ -0,0 +1,319 @@
+package genji
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/asdine/genji/record"
+	"github.com/asdine/genji/value"
+)
+
+// number of records written by each INSERT statement of a dump.
+const dumpBatchSize = 100
+
+// Dump writes the SQL statements reproducing the database to w: a CREATE TABLE statement for every table,
+// followed by the CREATE INDEX statements of its indexes, the CREATE SEQUENCE statements of the sequences
+// and finally the INSERT statements of the records of every table. Tables are created after the tables
+// they reference and their records are inserted with their keys, so that foreign keys remain valid.
+// The dump is written within a single read transaction and can be replayed using Load.
+//
+// Unlike backups, dumps are meant to be read and edited by humans, but they don't preserve everything:
+// the sequence of an AUTOINCREMENT table restarts after the largest key of the table.
+func (db DB) Dump(w io.Writer) error {
+	return db.View(func(tx *Tx) error {
+		bw := bufio.NewWriter(w)
+
+		err := tx.dump(bw)
+		if err != nil {
+			return err
+		}
+
+		return bw.Flush()
+	})
+}
+
+func (tx *Tx) dump(w *bufio.Writer) error {
+	names, err := tx.tx.ListStores("")
+	if err != nil {
+		return err
+	}
+
+	tables := make(map[string]*Table)
+	for _, name := range names {
+		if strings.HasPrefix(name, systemTablePrefix) || strings.HasPrefix(name, buildIndexName("")) {
+			continue
+		}
+
+		tables[name], err = tx.GetTable(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	sorted := sortTables(tables)
+
+	for _, t := range sorted {
+		var cfg *TableConfig
+		if t.schema != nil {
+			cfg = t.schema.cfg
+		}
+
+		fmt.Fprintf(w, "%s;\n", createTableString(t.name, cfg))
+
+		indexes := make([]Index, 0, len(t.indexes))
+		for _, idx := range t.indexes {
+			// indexes of unique fields are created with the table
+			if !strings.HasPrefix(idx.IndexName, autoIndexPrefix) {
+				indexes = append(indexes, idx)
+			}
+		}
+		sort.Slice(indexes, func(i, j int) bool { return indexes[i].IndexName < indexes[j].IndexName })
+
+		for _, idx := range indexes {
+			fmt.Fprintf(w, "%s;\n", createIndexString(idx))
+		}
+	}
+
+	err = tx.dumpSequences(w)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range sorted {
+		err = t.dumpRecords(w)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dumpSequences writes the CREATE SEQUENCE statements of the sequences that don't belong to a table.
+func (tx *Tx) dumpSequences(w *bufio.Writer) error {
+	s, err := tx.tx.Store(sequenceTable)
+	if err != nil {
+		return err
+	}
+
+	return s.AscendGreaterOrEqual(nil, func(k, v []byte) error {
+		name := string(k)
+		if strings.HasPrefix(name, autoSequenceName("")) {
+			return nil
+		}
+
+		n, err := decodeSequenceValue(v)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "CREATE SEQUENCE %s", fieldNameString(name))
+		if n != 0 {
+			fmt.Fprintf(w, " START WITH %d", n+1)
+		}
+		_, err = w.WriteString(";\n")
+		return err
+	})
+}
+
+// dumpRecords writes the INSERT statements of the records of the table,
+// each of them inserting up to dumpBatchSize records.
+func (t *Table) dumpRecords(w *bufio.Writer) error {
+	var cfg *TableConfig
+	if t.schema != nil {
+		cfg = t.schema.cfg
+	}
+
+	var count int
+	err := t.Iterate(func(r record.Record) error {
+		if count%dumpBatchSize == 0 {
+			if count > 0 {
+				w.WriteString(";\n")
+			}
+			fmt.Fprintf(w, "\nINSERT INTO %s RECORDS\n", fieldNameString(t.name))
+		} else {
+			w.WriteString(",\n")
+		}
+		count++
+
+		var fields []string
+		err := r.Iterate(func(f record.Field) error {
+			fields = append(fields, fieldNameString(f.Name)+": "+litteralValue{f.Value}.String())
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(fields) == 0 {
+			return fmt.Errorf("table %q contains an empty record which can't be written as SQL", t.name)
+		}
+
+		_, err = fmt.Fprintf(w, "  (%s) KEY %s", strings.Join(fields, ", "), keyString(cfg, r.(record.Keyer).Key()))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		_, err = w.WriteString(";\n")
+	}
+	return err
+}
+
+// sortTables returns the tables sorted by name, except that tables are always placed after
+// the tables they reference.
+func sortTables(tables map[string]*Table) []*Table {
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sorted := make([]*Table, 0, len(tables))
+	visited := make(map[string]bool, len(tables))
+
+	var visit func(name string)
+	visit = func(name string) {
+		t, ok := tables[name]
+		if !ok || visited[name] {
+			return
+		}
+		visited[name] = true
+
+		if t.schema != nil {
+			for _, fc := range t.schema.cfg.FieldConstraints {
+				if fc.ForeignKey != nil {
+					visit(fc.ForeignKey.Table)
+				}
+			}
+		}
+
+		sorted = append(sorted, t)
+	}
+
+	for _, name := range names {
+		visit(name)
+	}
+
+	return sorted
+}
+
+// createTableString returns the CREATE TABLE statement creating a table with the given configuration.
+func createTableString(tableName string, cfg *TableConfig) string {
+	var buf strings.Builder
+
+	buf.WriteString("CREATE TABLE ")
+	buf.WriteString(fieldNameString(tableName))
+	if cfg == nil {
+		return buf.String()
+	}
+
+	var constraints []string
+	for _, fc := range cfg.FieldConstraints {
+		constraints = append(constraints, fieldConstraintString(&fc))
+	}
+	for _, c := range cfg.Checks {
+		constraints = append(constraints, "CHECK ("+c+")")
+	}
+	if len(constraints) > 0 {
+		buf.WriteString(" (\n  ")
+		buf.WriteString(strings.Join(constraints, ",\n  "))
+		buf.WriteString("\n)")
+	}
+
+	if cfg.Strict {
+		buf.WriteString(" STRICT")
+	}
+
+	switch cfg.KeyGenerator {
+	case SequenceKeyGenerator:
+		buf.WriteString(" AUTOINCREMENT")
+	case UUIDKeyGenerator:
+		buf.WriteString(" UUID")
+	case OrderedUUIDKeyGenerator:
+		buf.WriteString(" UUID ORDERED")
+	}
+
+	if cfg.Compressed {
+		buf.WriteString(" COMPRESSED")
+	}
+
+	return buf.String()
+}
+
+// fieldConstraintString returns the SQL representation of a field constraint,
+// as expected by CREATE TABLE.
+func fieldConstraintString(fc *FieldConstraint) string {
+	var buf strings.Builder
+
+	buf.WriteString(fieldNameString(fc.Name))
+	if fc.Type != 0 {
+		buf.WriteString(" " + strings.ToUpper(fc.Type.String()))
+	}
+	if fc.NotNull {
+		buf.WriteString(" NOT NULL")
+	}
+	if fc.Unique {
+		buf.WriteString(" UNIQUE")
+	}
+	if fc.DefaultValue != "" {
+		buf.WriteString(" DEFAULT " + fc.DefaultValue)
+	}
+	if fc.ForeignKey != nil {
+		buf.WriteString(" " + fc.ForeignKey.String())
+	}
+
+	return buf.String()
+}
+
+// createIndexString returns the CREATE INDEX statement creating the given index.
+func createIndexString(idx Index) string {
+	var buf strings.Builder
+
+	buf.WriteString("CREATE ")
+	if idx.Unique {
+		buf.WriteString("UNIQUE ")
+	}
+	buf.WriteString("INDEX " + fieldNameString(idx.IndexName))
+	buf.WriteString(" ON " + fieldNameString(idx.TableName))
+	buf.WriteString(" (" + fieldSelector(idx.FieldName).String() + ")")
+
+	return buf.String()
+}
+
+// keyString returns the key of a record as an SQL litteral. Keys generated by the table
+// are written using the type they were generated from.
+func keyString(cfg *TableConfig, key []byte) string {
+	v := value.NewBytes(key)
+	if utf8.Valid(key) {
+		v = value.NewString(string(key))
+	}
+
+	if cfg != nil {
+		switch {
+		case cfg.KeyGenerator == SequenceKeyGenerator && len(key) == 8:
+			v = value.Value{Type: value.Int64, Data: key}
+		case (cfg.KeyGenerator == UUIDKeyGenerator || cfg.KeyGenerator == OrderedUUIDKeyGenerator) && len(key) == 16:
+			v = value.Value{Type: value.UUID, Data: key}
+		}
+	}
+
+	return litteralValue{v}.String()
+}
+
+// Load executes the statements read from r, like the ones written by Dump, within a single transaction.
+// Statements are read and executed one at a time. If any of them fails, the transaction is rolled back
+// and the database is left untouched.
+func (db DB) Load(r io.Reader) error {
+	return db.Update(func(tx *Tx) error {
+		return newParser(r).parseStatements(func(s statement) error {
+			_, err := s.Run(tx, nil)
+			return err
+		})
+	})
+}
@@ -0,0 +1,201 @@
+package genji_test
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asdine/genji"
+	"github.com/asdine/genji/engine/memory"
+	"github.com/asdine/genji/record"
+	"github.com/asdine/genji/value"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpLoad(t *testing.T) {
+	db, err := genji.New(memory.NewEngine())
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE writers (name STRING NOT NULL UNIQUE, CHECK (LENGTH(name) > 0)) AUTOINCREMENT;
+		CREATE TABLE books (title STRING NOT NULL, writer INT64 REFERENCES writers ON DELETE CASCADE, isbn DEFAULT 'unknown') STRICT;
+		CREATE TABLE misc COMPRESSED;
+		CREATE INDEX idx_misc_doc_a ON misc (doc.a);
+		CREATE SEQUENCE seq;
+		CREATE SEQUENCE unused;
+		INSERT INTO books RECORDS (title: NEXTVAL('seq')::STRING);
+		INSERT INTO books RECORDS (title: NEXTVAL('seq')::STRING);
+	`)
+	require.NoError(t, err)
+
+	// more than one INSERT statement is needed to dump the writers
+	for i := 1; i <= 120; i++ {
+		err = db.Exec("INSERT INTO writers RECORDS (name: ?)", fmt.Sprintf("writer %d", i))
+		require.NoError(t, err)
+	}
+	err = db.Exec(`INSERT INTO books RECORDS (title: 'a\nb', writer: 12)`)
+	require.NoError(t, err)
+
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	err = db.UpdateTable("misc", func(_ *genji.Tx, tb *genji.Table) error {
+		doc, err := record.NewDocumentField("doc", record.NewFieldBuffer(record.NewInt8Field("a", 1)))
+		if err != nil {
+			return err
+		}
+
+		_, err = tb.Insert(record.NewFieldBuffer(
+			record.NewBytesField("bytes", []byte{0, 1, 0xff}),
+			record.NewStringField("first name", "it's\r\n"),
+			record.NewUint16Field("uint16", 10),
+			record.NewFloat32Field("float32", 1.25),
+			record.NewTimestampField("ts", ts),
+			record.NewNullField("null"),
+			doc,
+			record.Field{Name: "array", Value: value.NewArray(value.NewDuration(time.Hour), value.NewUint8(1))},
+		))
+		return err
+	})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = db.Dump(&buf)
+	require.NoError(t, err)
+	dump := buf.String()
+
+	// tables are created after the tables they reference
+	require.Contains(t, dump, `CREATE TABLE writers (
+  name STRING NOT NULL UNIQUE,
+  CHECK (LENGTH(name) > 0)
+) AUTOINCREMENT;
+CREATE TABLE books (
+  title STRING NOT NULL,
+  writer INT64 REFERENCES writers ON DELETE CASCADE,
+  isbn DEFAULT 'unknown'
+) STRICT;
+CREATE TABLE misc COMPRESSED;
+CREATE INDEX idx_misc_doc_a ON misc (doc.a);
+CREATE SEQUENCE seq START WITH 3;
+CREATE SEQUENCE unused;
+`)
+	require.Contains(t, dump, `(title: 'a\nb', writer: 12, isbn: 'unknown') KEY `)
+	require.Contains(t, dump, "(name: 'writer 120') KEY 120;\n")
+	require.Contains(t, dump, `(bytes: x'0001ff', "first name": 'it\'s\r\n', uint16: CAST(10 AS UINT16), float32: CAST(1.25 AS FLOAT32), `+
+		`ts: CAST('2020-01-02T03:04:05.000000006Z' AS TIMESTAMP), "null": NULL, doc: {a: CAST(1 AS INT8)}, array: [1h, CAST(1 AS UINT8)]) KEY '`)
+	require.Equal(t, 2, strings.Count(dump, "INSERT INTO writers RECORDS"))
+
+	t.Run("Load", func(t *testing.T) {
+		other, err := genji.New(memory.NewEngine())
+		require.NoError(t, err)
+		defer other.Close()
+
+		err = other.Load(strings.NewReader(dump))
+		require.NoError(t, err)
+
+		// the dump of the loaded database is identical
+		var buf bytes.Buffer
+		err = other.Dump(&buf)
+		require.NoError(t, err)
+		require.Equal(t, dump, buf.String())
+
+		err = other.Update(func(tx *genji.Tx) error {
+			n, err := tx.NextValue("seq")
+			require.Equal(t, int64(3), n)
+			return err
+		})
+		require.NoError(t, err)
+
+		// keys generated after loading don't collide with the loaded ones
+		err = other.Exec("INSERT INTO writers RECORDS (name: 'writer 121')")
+		require.NoError(t, err)
+
+		// constraints are loaded as well
+		err = other.Exec("DELETE FROM writers WHERE name = 'writer 12'")
+		require.NoError(t, err)
+		res, err := other.Query("SELECT * FROM books WHERE writer = 12")
+		require.NoError(t, err)
+		n, err := res.Count()
+		require.NoError(t, err)
+		require.NoError(t, res.Close())
+		require.Equal(t, 0, n)
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		other, err := genji.New(memory.NewEngine())
+		require.NoError(t, err)
+		defer other.Close()
+
+		err = other.Load(strings.NewReader("CREATE TABLE foo; INSERT INTO foo RECORDS (a: 1) KEY 1, (a: 2) KEY 1"))
+		require.Equal(t, genji.ErrDuplicateRecord, err)
+
+		// nothing was loaded
+		err = other.View(func(tx *genji.Tx) error {
+			_, err := tx.GetTable("foo")
+			return err
+		})
+		require.Equal(t, genji.ErrTableNotFound, err)
+
+		err = other.Load(strings.NewReader("CREATE TABLE foo; CREATE"))
+		require.Error(t, err)
+	})
+}
+
+func TestDumpLoadFloats(t *testing.T) {
+	db, err := genji.New(memory.NewEngine())
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE floats")
+	require.NoError(t, err)
+
+	fields := []record.Field{
+		record.NewFloat64Field("large", 1e300),
+		record.NewFloat64Field("negative", -1e300),
+		record.NewFloat64Field("small", 1e-300),
+		record.NewFloat64Field("denormal", 5e-324),
+		record.NewFloat64Field("max", math.MaxFloat64),
+		record.NewFloat64Field("integer", 100),
+		record.NewFloat64Field("fraction", 0.1),
+		record.NewFloat32Field("float32", math.MaxFloat32),
+		record.NewFloat32Field("small32", math.SmallestNonzeroFloat32),
+	}
+
+	err = db.UpdateTable("floats", func(_ *genji.Tx, tb *genji.Table) error {
+		_, err := tb.Insert(record.NewFieldBuffer(fields...))
+		return err
+	})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = db.Dump(&buf)
+	require.NoError(t, err)
+	dump := buf.String()
+
+	// floats are written with an exponent rather than with hundreds of digits
+	require.Contains(t, dump, "(large: 1e+300, negative: -1e+300, small: 1e-300, denormal: 5e-324, ")
+	require.Contains(t, dump, "integer: 100.0, fraction: 0.1, ")
+
+	other, err := genji.New(memory.NewEngine())
+	require.NoError(t, err)
+	defer other.Close()
+
+	err = other.Load(strings.NewReader(dump))
+	require.NoError(t, err)
+
+	// values are loaded with the same type and value
+	err = other.ViewTable("floats", func(_ *genji.Tx, tb *genji.Table) error {
+		return tb.Iterate(func(r record.Record) error {
+			for _, want := range fields {
+				f, err := r.GetField(want.Name)
+				require.NoError(t, err)
+				require.Equal(t, want.Type, f.Type, want.Name)
+				require.Equal(t, want.Data, f.Data, want.Name)
+			}
+			return nil
+		})
+	})
+	require.NoError(t, err)
+}
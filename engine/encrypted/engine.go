@@ -0,0 +1,359 @@
+// Package encrypted implements an engine that encrypts the data of any other engine.
+//
+// Values are encrypted with AES-GCM, using a random nonce for each write. The name of the store
+// and the key of each value are authenticated with it, so that a value copied to another key
+// can't be decrypted.
+//
+// Keys are stored in clear by default, which preserves their order but exposes them, and with them the
+// values of indexed fields, which are part of the keys of indexes. With the EncryptKeys option, keys are
+// encrypted deterministically: a given key is always encrypted the same way, which allows to look it up,
+// but the order of the keys is lost. Ordered iterations then decrypt and sort all the keys of the store in
+// memory, however few keys they read. Since Genji reads indexes and tables using ordered iterations,
+// every query, including index lookups, then costs a full scan of the stores it reads:
+// EncryptKeys is only suited for small databases and is unsuitable for large or indexed tables.
+//
+// The names of the stores are never encrypted.
+package encrypted
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/asdine/genji/engine"
+)
+
+// checkStore is the name of the store used to verify that the engine is opened
+// with the right key and options. It is hidden from the list of stores.
+const checkStore = "__encrypted"
+
+var checkKey = []byte("check")
+
+// flags stored in the check store.
+const (
+	encryptedKeysFlag byte = 1 << iota
+)
+
+// ErrInvalidKey is returned when opening an engine with a key different from
+// the one used to encrypt its data.
+var ErrInvalidKey = errors.New("invalid encryption key")
+
+// Options of the encrypted engine.
+type Options struct {
+	// EncryptKeys enables the encryption of keys.
+	// It must be set to the same value every time the engine is opened.
+	// Every ordered iteration then decrypts and sorts all the keys of the store,
+	// which makes it unsuitable for large or indexed tables.
+	EncryptKeys bool
+}
+
+// Engine wraps an engine and encrypts the keys and values it stores.
+type Engine struct {
+	ng          engine.Engine
+	encryptKeys bool
+
+	// mu protects c during a key rotation.
+	mu sync.RWMutex
+	c  *crypter
+}
+
+// NewEngine creates an engine that encrypts the data stored in ng using key, which must be
+// 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256.
+// If ng already contains encrypted data, key must be the one used to encrypt it,
+// otherwise ErrInvalidKey is returned. opts can be nil.
+func NewEngine(ng engine.Engine, key []byte, opts *Options) (*Engine, error) {
+	if opts == nil {
+		opts = new(Options)
+	}
+
+	c, err := newCrypter(key)
+	if err != nil {
+		return nil, err
+	}
+
+	e := Engine{
+		ng:          ng,
+		encryptKeys: opts.EncryptKeys,
+		c:           c,
+	}
+
+	err = e.check()
+	if err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+// check verifies the key and options against the ones the data was encrypted with,
+// or records them if the engine doesn't contain encrypted data yet.
+func (e *Engine) check() error {
+	var flags byte
+	if e.encryptKeys {
+		flags |= encryptedKeysFlag
+	}
+
+	tx, err := e.ng.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	st, err := tx.Store(checkStore)
+	if err == engine.ErrStoreNotFound {
+		err = tx.CreateStore(checkStore)
+		if err != nil {
+			return err
+		}
+
+		st, err = tx.Store(checkStore)
+		if err != nil {
+			return err
+		}
+
+		v, err := e.c.encryptValue(checkStore, checkKey, []byte{flags})
+		if err != nil {
+			return err
+		}
+
+		err = st.Put(checkKey, v)
+		if err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	}
+	if err != nil {
+		return err
+	}
+
+	v, err := st.Get(checkKey)
+	if err != nil {
+		return err
+	}
+
+	v, err = e.c.decryptValue(checkStore, checkKey, v)
+	if err != nil {
+		return ErrInvalidKey
+	}
+
+	if len(v) != 1 || v[0] != flags {
+		return fmt.Errorf("EncryptKeys option must be set to %v", !e.encryptKeys)
+	}
+
+	return nil
+}
+
+// Begin a transaction on the underlying engine.
+func (e *Engine) Begin(writable bool) (engine.Transaction, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	tx, err := e.ng.Begin(writable)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transaction{
+		tx:          tx,
+		c:           e.c,
+		encryptKeys: e.encryptKeys,
+	}, nil
+}
+
+// Close the underlying engine.
+func (e *Engine) Close() error {
+	return e.ng.Close()
+}
+
+// Rotate re-encrypts all the data of the engine using newKey, in a single transaction.
+// New transactions are blocked until the rotation completes, transactions already opened
+// must be closed for the rotation to start.
+// Once Rotate returns successfully, the engine must be opened with newKey.
+func (e *Engine) Rotate(newKey []byte) error {
+	c, err := newCrypter(newKey)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	tx, err := e.ng.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	names, err := tx.ListStores("")
+	if err != nil {
+		return err
+	}
+
+	old := transaction{tx: tx, c: e.c, encryptKeys: e.encryptKeys}
+	for _, name := range names {
+		err = old.reencryptStore(name, c)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	e.c = c
+	return nil
+}
+
+type transaction struct {
+	tx          engine.Transaction
+	c           *crypter
+	encryptKeys bool
+}
+
+func (t *transaction) Rollback() error {
+	return t.tx.Rollback()
+}
+
+func (t *transaction) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *transaction) Store(name string) (engine.Store, error) {
+	st, err := t.tx.Store(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &store{
+		st:          st,
+		name:        name,
+		c:           t.c,
+		encryptKeys: t.encryptKeys,
+	}, nil
+}
+
+func (t *transaction) CreateStore(name string) error {
+	return t.tx.CreateStore(name)
+}
+
+func (t *transaction) DropStore(name string) error {
+	return t.tx.DropStore(name)
+}
+
+// ListStores returns the stores of the underlying engine, except the one
+// used internally by the encrypted engine.
+func (t *transaction) ListStores(prefix string) ([]string, error) {
+	names, err := t.tx.ListStores(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	list := names[:0]
+	for _, name := range names {
+		if name != checkStore {
+			list = append(list, name)
+		}
+	}
+
+	return list, nil
+}
+
+// reencryptStore re-encrypts the content of a store with c.
+func (t *transaction) reencryptStore(name string, c *crypter) error {
+	st, err := t.tx.Store(name)
+	if err != nil {
+		return err
+	}
+
+	// the key of the check store is never encrypted
+	encryptKeys := t.encryptKeys && name != checkStore
+
+	old := store{st: st, name: name, c: t.c, encryptKeys: encryptKeys}
+
+	// stores can't be modified while being iterated on
+	var keys, values [][]byte
+	err = old.AscendGreaterOrEqual(nil, func(k, v []byte) error {
+		keys = append(keys, append([]byte{}, k...))
+		values = append(values, append([]byte{}, v...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// encrypted keys change with the encryption key, the old ones must be removed
+	if encryptKeys {
+		for _, k := range keys {
+			err = old.Delete(k)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	s := store{st: st, name: name, c: c, encryptKeys: encryptKeys}
+	for i := range keys {
+		err = s.Put(keys[i], values[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// crypter encrypts and decrypts keys and values using keys derived from
+// the key provided by the user.
+type crypter struct {
+	values cipher.AEAD
+	keys   cipher.AEAD
+	// nonceKey is used to derive the nonces of the keys from their content.
+	nonceKey []byte
+}
+
+func newCrypter(key []byte) (*crypter, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("invalid key size %d, must be 16, 24 or 32 bytes", len(key))
+	}
+
+	values, err := newAEAD(deriveKey(key, "values"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := newAEAD(deriveKey(key, "keys"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &crypter{
+		values:   values,
+		keys:     keys,
+		nonceKey: deriveKey(key, "nonces"),
+	}, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// deriveKey derives a key of the same size as key, dedicated to the given purpose.
+func deriveKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)[:len(key)]
+}
@@ -0,0 +1,126 @@
+package encrypted_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/asdine/genji"
+	"github.com/asdine/genji/engine"
+	"github.com/asdine/genji/engine/encrypted"
+	"github.com/asdine/genji/engine/enginetest"
+	"github.com/asdine/genji/engine/memory"
+	"github.com/asdine/genji/record/recordutil"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	key    = bytes.Repeat([]byte{1}, 32)
+	newKey = bytes.Repeat([]byte{2}, 32)
+)
+
+func builder(opts *encrypted.Options) enginetest.Builder {
+	return func() (engine.Engine, func()) {
+		ng, err := encrypted.NewEngine(memory.NewEngine(), key, opts)
+		if err != nil {
+			panic(err)
+		}
+
+		return ng, func() { ng.Close() }
+	}
+}
+
+func TestEncryptedEngine(t *testing.T) {
+	enginetest.TestSuite(t, builder(nil))
+}
+
+func TestEncryptedEngineWithEncryptedKeys(t *testing.T) {
+	enginetest.TestSuite(t, builder(&encrypted.Options{EncryptKeys: true}))
+}
+
+// contains reports whether any key or value of the underlying engine contains s.
+func contains(t *testing.T, ng engine.Engine, s string) bool {
+	tx, err := ng.Begin(false)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	names, err := tx.ListStores("")
+	require.NoError(t, err)
+
+	var found bool
+	for _, name := range names {
+		st, err := tx.Store(name)
+		require.NoError(t, err)
+
+		err = st.AscendGreaterOrEqual(nil, func(k, v []byte) error {
+			if bytes.Contains(k, []byte(s)) || bytes.Contains(v, []byte(s)) {
+				found = true
+			}
+			return nil
+		})
+		require.NoError(t, err)
+	}
+
+	return found
+}
+
+func TestEngine(t *testing.T) {
+	for _, encryptKeys := range []bool{false, true} {
+		opts := encrypted.Options{EncryptKeys: encryptKeys}
+
+		mem := memory.NewEngine()
+		ng, err := encrypted.NewEngine(mem, key, &opts)
+		require.NoError(t, err)
+
+		db, err := genji.New(ng)
+		require.NoError(t, err)
+
+		err = db.Exec(`
+			CREATE TABLE customers;
+			CREATE INDEX idx_customers_email ON customers (email);
+			INSERT INTO customers (name, email) VALUES ('John Smith', 'john@example.com'), ('Jane Doe', 'jane@example.com');
+		`)
+		require.NoError(t, err)
+
+		require.False(t, contains(t, mem, "John Smith"))
+		// indexed values are part of the keys of the index
+		require.Equal(t, !encryptKeys, contains(t, mem, "jane@example.com"))
+
+		query := func(ng engine.Engine) string {
+			db, err := genji.New(ng)
+			require.NoError(t, err)
+
+			res, err := db.Query("SELECT name FROM customers WHERE email > 'j'")
+			require.NoError(t, err)
+			defer res.Close()
+
+			var buf bytes.Buffer
+			err = recordutil.IteratorToCSV(&buf, res)
+			require.NoError(t, err)
+			return buf.String()
+		}
+
+		require.Equal(t, "Jane Doe\nJohn Smith\n", query(ng))
+
+		_, err = encrypted.NewEngine(mem, newKey, &opts)
+		require.Equal(t, encrypted.ErrInvalidKey, err)
+
+		_, err = encrypted.NewEngine(mem, key, &encrypted.Options{EncryptKeys: !encryptKeys})
+		require.Error(t, err)
+
+		err = ng.Rotate(newKey)
+		require.NoError(t, err)
+		require.Equal(t, "Jane Doe\nJohn Smith\n", query(ng))
+
+		_, err = encrypted.NewEngine(mem, key, &opts)
+		require.Equal(t, encrypted.ErrInvalidKey, err)
+
+		ng, err = encrypted.NewEngine(mem, newKey, &opts)
+		require.NoError(t, err)
+		require.Equal(t, "Jane Doe\nJohn Smith\n", query(ng))
+	}
+}
+
+func TestInvalidKeySize(t *testing.T) {
+	_, err := encrypted.NewEngine(memory.NewEngine(), []byte("too short"), nil)
+	require.Error(t, err)
+}
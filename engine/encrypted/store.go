@@ -0,0 +1,257 @@
+package encrypted
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/asdine/genji/engine"
+)
+
+var errCorrupted = errors.New("encrypted data corrupted or encrypted with another key")
+
+// A store encrypts the keys and values it writes to the underlying store
+// and decrypts them when they are read.
+type store struct {
+	st          engine.Store
+	name        string
+	c           *crypter
+	encryptKeys bool
+}
+
+// Get returns the decrypted value associated with k.
+func (s *store) Get(k []byte) ([]byte, error) {
+	v, err := s.st.Get(s.encryptKey(k))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.c.decryptValue(s.name, k, v)
+}
+
+// Put encrypts v and stores it under k.
+func (s *store) Put(k, v []byte) error {
+	if len(k) == 0 {
+		return errors.New("empty keys are forbidden")
+	}
+
+	ev, err := s.c.encryptValue(s.name, k, v)
+	if err != nil {
+		return err
+	}
+
+	return s.st.Put(s.encryptKey(k), ev)
+}
+
+// Delete the value associated with k.
+func (s *store) Delete(k []byte) error {
+	return s.st.Delete(s.encryptKey(k))
+}
+
+// Truncate the underlying store.
+func (s *store) Truncate() error {
+	return s.st.Truncate()
+}
+
+// AscendGreaterOrEqual iterates over the decrypted key value pairs in increasing order.
+func (s *store) AscendGreaterOrEqual(pivot []byte, fn func(k, v []byte) error) error {
+	if !s.encryptKeys {
+		return s.st.AscendGreaterOrEqual(pivot, func(k, v []byte) error {
+			v, err := s.c.decryptValue(s.name, k, v)
+			if err != nil {
+				return err
+			}
+
+			return fn(k, v)
+		})
+	}
+
+	keys, err := s.sortedKeys()
+	if err != nil {
+		return err
+	}
+
+	i := sort.Search(len(keys), func(i int) bool {
+		return bytes.Compare(keys[i].k, pivot) >= 0
+	})
+
+	for ; i < len(keys); i++ {
+		err = s.callWithValue(keys[i], fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DescendLessOrEqual iterates over the decrypted key value pairs in decreasing order.
+func (s *store) DescendLessOrEqual(pivot []byte, fn func(k, v []byte) error) error {
+	if !s.encryptKeys {
+		return s.st.DescendLessOrEqual(pivot, func(k, v []byte) error {
+			v, err := s.c.decryptValue(s.name, k, v)
+			if err != nil {
+				return err
+			}
+
+			return fn(k, v)
+		})
+	}
+
+	keys, err := s.sortedKeys()
+	if err != nil {
+		return err
+	}
+
+	i := len(keys) - 1
+	if len(pivot) > 0 {
+		i = sort.Search(len(keys), func(i int) bool {
+			return bytes.Compare(keys[i].k, pivot) > 0
+		}) - 1
+	}
+
+	for ; i >= 0; i-- {
+		err = s.callWithValue(keys[i], fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type keyPair struct {
+	k, encrypted []byte
+}
+
+// sortedKeys decrypts all the keys of the store and sorts them.
+// It is called by every ordered iteration when keys are encrypted, so its cost, in time
+// and memory, is proportional to the size of the store, whatever the pivot and
+// the number of keys actually read.
+func (s *store) sortedKeys() ([]keyPair, error) {
+	var keys []keyPair
+
+	err := s.st.AscendGreaterOrEqual(nil, func(k, _ []byte) error {
+		dk, err := s.c.decryptKey(s.name, k)
+		if err != nil {
+			return err
+		}
+
+		keys = append(keys, keyPair{k: dk, encrypted: append([]byte{}, k...)})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i].k, keys[j].k) < 0
+	})
+
+	return keys, nil
+}
+
+// callWithValue reads the value of the given key and passes it to fn.
+// Keys deleted since the store was scanned are skipped.
+func (s *store) callWithValue(kp keyPair, fn func(k, v []byte) error) error {
+	v, err := s.st.Get(kp.encrypted)
+	if err == engine.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	v, err = s.c.decryptValue(s.name, kp.k, v)
+	if err != nil {
+		return err
+	}
+
+	return fn(kp.k, v)
+}
+
+func (s *store) encryptKey(k []byte) []byte {
+	if !s.encryptKeys {
+		return k
+	}
+
+	return s.c.encryptKey(s.name, k)
+}
+
+// additionalData returns the data authenticated along with the content of the given key or value,
+// which binds it to its location.
+func additionalData(storeName string, k []byte) []byte {
+	var size [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(size[:], uint64(len(storeName)))
+
+	ad := make([]byte, 0, n+len(storeName)+len(k))
+	ad = append(ad, size[:n]...)
+	ad = append(ad, storeName...)
+	return append(ad, k...)
+}
+
+// encryptValue encrypts v with a random nonce, which is prepended to the result.
+// It returns an error if the nonce can't be generated.
+func (c *crypter) encryptValue(storeName string, k, v []byte) ([]byte, error) {
+	nonce := make([]byte, c.values.NonceSize(), c.values.NonceSize()+len(v)+c.values.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return c.values.Seal(nonce, nonce, v, additionalData(storeName, k)), nil
+}
+
+func (c *crypter) decryptValue(storeName string, k, v []byte) ([]byte, error) {
+	ns := c.values.NonceSize()
+	if len(v) < ns+c.values.Overhead() {
+		return nil, errCorrupted
+	}
+
+	data, err := c.values.Open(nil, v[:ns], v[ns:], additionalData(storeName, k))
+	if err != nil {
+		return nil, errCorrupted
+	}
+
+	return data, nil
+}
+
+// encryptKey encrypts k deterministically: the nonce is derived from the name of the store
+// and from k, so that the same key is always encrypted the same way.
+func (c *crypter) encryptKey(storeName string, k []byte) []byte {
+	nonce := c.keyNonce(storeName, k)
+	return c.keys.Seal(nonce, nonce, k, []byte(storeName))
+}
+
+func (c *crypter) decryptKey(storeName string, k []byte) ([]byte, error) {
+	ns := c.keys.NonceSize()
+	if len(k) < ns+c.keys.Overhead() {
+		return nil, errCorrupted
+	}
+
+	data, err := c.keys.Open(nil, k[:ns], k[ns:], []byte(storeName))
+	if err != nil {
+		return nil, errCorrupted
+	}
+
+	if !hmac.Equal(k[:ns], c.keyNonce(storeName, data)) {
+		return nil, errCorrupted
+	}
+
+	return data, nil
+}
+
+func (c *crypter) keyNonce(storeName string, k []byte) []byte {
+	mac := hmac.New(sha256.New, c.nonceKey)
+	mac.Write(additionalData(storeName, k))
+	nonce := mac.Sum(nil)[:c.keys.NonceSize()]
+
+	// the nonce is used as the beginning of the encrypted key, allocate enough room for the rest
+	buf := make([]byte, len(nonce), len(nonce)+len(k)+c.keys.Overhead())
+	copy(buf, nonce)
+	return buf
+}
@@ -0,0 +1,93 @@
+package encrypted
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/asdine/genji/engine"
+	"github.com/asdine/genji/engine/memory"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStore counts the keys read from the underlying store.
+type countingStore struct {
+	engine.Store
+	read int
+}
+
+func (s *countingStore) AscendGreaterOrEqual(pivot []byte, fn func(k, v []byte) error) error {
+	return s.Store.AscendGreaterOrEqual(pivot, func(k, v []byte) error {
+		s.read++
+		return fn(k, v)
+	})
+}
+
+func (s *countingStore) DescendLessOrEqual(pivot []byte, fn func(k, v []byte) error) error {
+	return s.Store.DescendLessOrEqual(pivot, func(k, v []byte) error {
+		s.read++
+		return fn(k, v)
+	})
+}
+
+// TestStoreEncryptedKeysFullScan documents the limit of the EncryptKeys option:
+// ordered iterations read every key of the store, however few keys they return.
+func TestStoreEncryptedKeysFullScan(t *testing.T) {
+	c, err := newCrypter(bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+
+	errStop := errors.New("stop")
+	const size = 100
+
+	for _, encryptKeys := range []bool{false, true} {
+		t.Run(fmt.Sprintf("EncryptKeys=%v", encryptKeys), func(t *testing.T) {
+			ng := memory.NewEngine()
+			tx, err := ng.Begin(true)
+			require.NoError(t, err)
+			defer tx.Rollback()
+
+			require.NoError(t, tx.CreateStore("test"))
+			st, err := tx.Store("test")
+			require.NoError(t, err)
+
+			cs := countingStore{Store: st}
+			s := store{st: &cs, name: "test", c: c, encryptKeys: encryptKeys}
+
+			for i := 0; i < size; i++ {
+				err = s.Put([]byte(fmt.Sprintf("k%03d", i)), []byte("v"))
+				require.NoError(t, err)
+			}
+
+			var keys []string
+			read := func(k, v []byte) error {
+				keys = append(keys, string(k))
+				if len(keys) == 2 {
+					return errStop
+				}
+				return nil
+			}
+
+			cs.read = 0
+			err = s.AscendGreaterOrEqual([]byte("k050"), read)
+			require.Equal(t, errStop, err)
+			require.Equal(t, []string{"k050", "k051"}, keys)
+			if encryptKeys {
+				require.Equal(t, size, cs.read)
+			} else {
+				require.Equal(t, 2, cs.read)
+			}
+
+			keys = nil
+			cs.read = 0
+			err = s.DescendLessOrEqual([]byte("k050"), read)
+			require.Equal(t, errStop, err)
+			require.Equal(t, []string{"k050", "k049"}, keys)
+			if encryptKeys {
+				require.Equal(t, size, cs.read)
+			} else {
+				require.Equal(t, 2, cs.read)
+			}
+		})
+	}
+}
@@ -841,6 +841,41 @@ func TestStoreTruncate(t *testing.T, builder Builder) {
 		})
 		require.NoError(t, err)
 	})
+
+	t.Run("Should persist after commit", func(t *testing.T) {
+		ng, cleanup := builder()
+		defer cleanup()
+
+		tx, err := ng.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		err = tx.CreateStore("test")
+		require.NoError(t, err)
+		st, err := tx.Store("test")
+		require.NoError(t, err)
+		err = st.Put([]byte("foo"), []byte("FOO"))
+		require.NoError(t, err)
+		err = tx.Commit()
+		require.NoError(t, err)
+
+		tx, err = ng.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		st, err = tx.Store("test")
+		require.NoError(t, err)
+		err = st.Truncate()
+		require.NoError(t, err)
+		err = tx.Commit()
+		require.NoError(t, err)
+
+		tx, err = ng.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		st, err = tx.Store("test")
+		require.NoError(t, err)
+		_, err = st.Get([]byte("foo"))
+		require.Equal(t, engine.ErrKeyNotFound, err)
+	})
 }
 
 // TestQueries test simple queries against the engine.
@@ -0,0 +1,104 @@
+package logfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/asdine/genji/engine"
+)
+
+// operations stored in a batch.
+const (
+	opCreateStore byte = iota + 1 // store name
+	opDropStore                   // store name
+	opPut                         // store name, key, value
+	opDelete                      // store name, key
+	opTruncate                    // store name
+)
+
+// argument count of each operation.
+var opArgs = map[byte]int{
+	opCreateStore: 1,
+	opDropStore:   1,
+	opPut:         3,
+	opDelete:      2,
+	opTruncate:    1,
+}
+
+// A batch contains the operations of a transaction. Each operation is encoded
+// as its code followed by its arguments, each prefixed by its length.
+type batch struct {
+	bytes.Buffer
+}
+
+func (b *batch) add(op byte, name string, args ...[]byte) {
+	var buf [binary.MaxVarintLen64]byte
+
+	b.WriteByte(op)
+	n := binary.PutUvarint(buf[:], uint64(len(name)))
+	b.Write(buf[:n])
+	b.WriteString(name)
+
+	for _, arg := range args {
+		n = binary.PutUvarint(buf[:], uint64(len(arg)))
+		b.Write(buf[:n])
+		b.Write(arg)
+	}
+}
+
+// replay applies the operations of a batch to a transaction.
+// The keys and values passed to the stores point to the batch, which must not be reused.
+func replay(tx engine.Transaction, data []byte) error {
+	var args [3][]byte
+
+	for len(data) > 0 {
+		op := data[0]
+		count, ok := opArgs[op]
+		if !ok {
+			return fmt.Errorf("unknown operation %d", op)
+		}
+		data = data[1:]
+
+		for i := 0; i < count; i++ {
+			size, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < size {
+				return errors.New("corrupted batch")
+			}
+
+			args[i] = data[n : n+int(size) : n+int(size)]
+			data = data[n+int(size):]
+		}
+
+		name := string(args[0])
+
+		var err error
+		switch op {
+		case opCreateStore:
+			err = tx.CreateStore(name)
+		case opDropStore:
+			err = tx.DropStore(name)
+		default:
+			var st engine.Store
+			st, err = tx.Store(name)
+			if err != nil {
+				break
+			}
+
+			switch op {
+			case opPut:
+				err = st.Put(args[1], args[2])
+			case opDelete:
+				err = st.Delete(args[1])
+			case opTruncate:
+				err = st.Truncate()
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("can't replay operation on store %q: %v", name, err)
+		}
+	}
+
+	return nil
+}
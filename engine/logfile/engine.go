@@ -0,0 +1,577 @@
+// Package logfile implements an engine storing data in a single append-only file.
+//
+// The data is kept in memory, using the memory engine, and every committed transaction
+// is appended to the file as a batch of operations. When the engine is opened, the batches are
+// replayed to rebuild the data. A batch only partially written at the end of the file, for example
+// after a crash, is discarded. A batch damaged in the middle of the file is not: the transactions
+// committed after it would be lost, NewEngine returns ErrCorrupted instead.
+//
+// Overwritten and deleted data accumulates in the file until it is compacted: the content of the
+// database is then written to a new file, which replaces the old one. Compaction happens
+// automatically when the file grows past a configurable threshold, or when Compact is called.
+//
+// The file must not be opened by more than one engine at a time.
+package logfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/asdine/genji/engine"
+	"github.com/asdine/genji/engine/memory"
+)
+
+const (
+	magic         = "GENJILOG"
+	formatVersion = 1
+	headerSize    = len(magic) + 1
+	// size of the length and checksum preceding each batch.
+	frameSize = 8
+	// size above which the content of a store is split into multiple batches during compactions.
+	snapshotBatchSize = 1 << 20
+)
+
+// Default values of the options.
+const (
+	DefaultCompactionMinSize = 64 << 20
+	DefaultCompactionRatio   = 2
+)
+
+// ErrCorrupted is returned when opening a file containing a damaged batch
+// followed by other data, which can't be the result of an interrupted write.
+var ErrCorrupted = errors.New("log file corrupted")
+
+// Options of the engine.
+type Options struct {
+	// NoSync disables the call to fsync after each commit. Committed transactions
+	// can be lost if the system crashes, but writes are much faster.
+	NoSync bool
+	// CompactionMinSize is the size, in bytes, under which the file is never compacted automatically.
+	// Defaults to DefaultCompactionMinSize. Set it to a negative value to disable automatic compaction.
+	CompactionMinSize int64
+	// CompactionRatio is the growth of the file, since it was last compacted or opened,
+	// that triggers a compaction. Defaults to DefaultCompactionRatio.
+	CompactionRatio float64
+	// OnCompactionError is called with the error of a failed automatic compaction.
+	// The transaction that triggered it is committed anyway and the compaction is attempted
+	// again on the next commit. Errors are ignored if nil.
+	OnCompactionError func(error)
+}
+
+// Engine stores data in memory and logs every change to a file.
+type Engine struct {
+	mem  *memory.Engine
+	path string
+	opts Options
+
+	// mu protects the fields below. Changes to the file are also serialized by the memory engine,
+	// which only runs one read/write transaction at a time: writes happen during the commit of
+	// a read/write transaction and compactions within one.
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+	// size of the file after it was last compacted or opened.
+	baseSize int64
+}
+
+// NewEngine opens the log file located at path, creating it if it doesn't exist,
+// and loads its content in memory. opts can be nil.
+func NewEngine(path string, opts *Options) (*Engine, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.CompactionMinSize == 0 {
+		o.CompactionMinSize = DefaultCompactionMinSize
+	}
+	if o.CompactionRatio <= 1 {
+		o.CompactionRatio = DefaultCompactionRatio
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	ng := Engine{
+		mem:  memory.NewEngine(),
+		path: path,
+		opts: o,
+		f:    f,
+	}
+
+	err = ng.load()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &ng, nil
+}
+
+// load replays the content of the file and truncates any incomplete batch.
+func (ng *Engine) load() error {
+	fi, err := ng.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if fi.Size() == 0 {
+		err = writeHeader(ng.f)
+		if err != nil {
+			return err
+		}
+
+		ng.size = int64(headerSize)
+		ng.baseSize = ng.size
+		return ng.f.Sync()
+	}
+
+	r := bufio.NewReader(ng.f)
+	err = readHeader(r)
+	if err != nil {
+		return err
+	}
+
+	tx, err := ng.mem.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	size := int64(headerSize)
+	for {
+		batch, err := readBatch(r, size, fi.Size())
+		if err == io.EOF {
+			break
+		}
+		if err == errIncompleteBatch {
+			// the end of the file was not written completely
+			err = ng.f.Truncate(size)
+			if err != nil {
+				return err
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		err = replay(tx, batch)
+		if err != nil {
+			return err
+		}
+
+		size += int64(frameSize + len(batch))
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	_, err = ng.f.Seek(size, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	ng.size = size
+	ng.baseSize = size
+	return nil
+}
+
+// Begin a transaction.
+func (ng *Engine) Begin(writable bool) (engine.Transaction, error) {
+	tx, err := ng.mem.Begin(writable)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transaction{
+		ng: ng,
+		tx: tx,
+	}, nil
+}
+
+// Close the engine and the file after all the transactions have completed.
+func (ng *Engine) Close() error {
+	err := ng.mem.Close()
+	if err != nil {
+		return err
+	}
+
+	ng.mu.Lock()
+	defer ng.mu.Unlock()
+
+	return ng.f.Close()
+}
+
+// Size returns the size of the file.
+func (ng *Engine) Size() int64 {
+	ng.mu.Lock()
+	defer ng.mu.Unlock()
+
+	return ng.size
+}
+
+// write appends a batch to the file.
+func (ng *Engine) write(batch []byte) error {
+	ng.mu.Lock()
+	defer ng.mu.Unlock()
+
+	// the frame and the batch are written at once
+	var buf bytes.Buffer
+	buf.Grow(frameSize + len(batch))
+	_, err := writeFrame(&buf, batch)
+	if err != nil {
+		return err
+	}
+
+	_, err = ng.f.Write(buf.Bytes())
+	if err == nil && !ng.opts.NoSync {
+		err = ng.f.Sync()
+	}
+	if err != nil {
+		// remove what might have been written so that the next batches are not lost
+		ng.f.Truncate(ng.size)
+		ng.f.Seek(ng.size, io.SeekStart)
+		return err
+	}
+
+	ng.size += int64(buf.Len())
+	return nil
+}
+
+func (ng *Engine) shouldCompact() bool {
+	ng.mu.Lock()
+	defer ng.mu.Unlock()
+
+	if ng.opts.CompactionMinSize < 0 || ng.size < ng.opts.CompactionMinSize {
+		return false
+	}
+
+	return float64(ng.size) >= float64(ng.baseSize)*ng.opts.CompactionRatio
+}
+
+// Compact rewrites the file so that it only contains the current content of the database.
+// The new file is written next to the old one before replacing it, writes are blocked
+// during the compaction.
+func (ng *Engine) Compact() error {
+	// a read-only transaction would let a concurrent commit append its changes
+	// to the old file after the snapshot was taken, they would be lost
+	tx, err := ng.mem.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ng.mu.Lock()
+	defer ng.mu.Unlock()
+
+	tmpPath := ng.path + ".compact"
+	f, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	size, err := writeSnapshot(f, tx)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	err = os.Rename(tmpPath, ng.path)
+	if err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	syncDir(filepath.Dir(ng.path))
+
+	ng.f.Close()
+	ng.f = f
+	ng.size = size
+	ng.baseSize = size
+	return nil
+}
+
+// writeSnapshot writes the content of every store to f, one batch per store.
+func writeSnapshot(f *os.File, tx engine.Transaction) (int64, error) {
+	w := bufio.NewWriter(f)
+
+	err := writeHeader(w)
+	if err != nil {
+		return 0, err
+	}
+	size := int64(headerSize)
+
+	names, err := tx.ListStores("")
+	if err != nil {
+		return 0, err
+	}
+
+	for _, name := range names {
+		var b batch
+		b.add(opCreateStore, name)
+
+		st, err := tx.Store(name)
+		if err != nil {
+			return 0, err
+		}
+
+		err = st.AscendGreaterOrEqual(nil, func(k, v []byte) error {
+			b.add(opPut, name, k, v)
+
+			// large stores are split into multiple batches
+			if b.Len() < snapshotBatchSize {
+				return nil
+			}
+
+			n, err := writeFrame(w, b.Bytes())
+			size += n
+			b.Reset()
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		if b.Len() > 0 {
+			n, err := writeFrame(w, b.Bytes())
+			if err != nil {
+				return 0, err
+			}
+			size += n
+		}
+	}
+
+	return size, w.Flush()
+}
+
+// syncDir makes sure the renaming of a file is durable.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
+
+func writeHeader(w io.Writer) error {
+	_, err := w.Write(append([]byte(magic), formatVersion))
+	return err
+}
+
+func readHeader(r io.Reader) error {
+	var buf [headerSize]byte
+	_, err := io.ReadFull(r, buf[:])
+	if err != nil || string(buf[:len(magic)]) != magic {
+		return errors.New("not a log file")
+	}
+
+	if buf[len(magic)] != formatVersion {
+		return errors.New("unsupported log file version")
+	}
+
+	return nil
+}
+
+func writeFrame(w io.Writer, batch []byte) (int64, error) {
+	if uint64(len(batch)) > math.MaxUint32 {
+		return 0, errors.New("transaction too large")
+	}
+
+	var frame [frameSize]byte
+	binary.BigEndian.PutUint32(frame[:], uint32(len(batch)))
+	binary.BigEndian.PutUint32(frame[4:], crc32.ChecksumIEEE(batch))
+
+	_, err := w.Write(frame[:])
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = w.Write(batch)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(frameSize + len(batch)), nil
+}
+
+var errIncompleteBatch = errors.New("incomplete batch")
+
+// readBatch reads the next batch of the file, located at offset, and verifies its checksum.
+// It returns errIncompleteBatch if the batch runs to the end of the file, which happens
+// when the last write was interrupted, and ErrCorrupted if it is followed by other data.
+// fileSize is used to reject lengths larger than the rest of the file before allocating the batch.
+func readBatch(r *bufio.Reader, offset, fileSize int64) ([]byte, error) {
+	var frame [frameSize]byte
+	n, err := io.ReadFull(r, frame[:])
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil || n < frameSize {
+		return nil, errIncompleteBatch
+	}
+
+	// a damaged length can't be told apart from a batch that was not written completely
+	size := int64(binary.BigEndian.Uint32(frame[:]))
+	if size > fileSize-offset-frameSize {
+		return nil, errIncompleteBatch
+	}
+
+	batch := make([]byte, size)
+	_, err = io.ReadFull(r, batch)
+	if err != nil {
+		return nil, errIncompleteBatch
+	}
+
+	if crc32.ChecksumIEEE(batch) != binary.BigEndian.Uint32(frame[4:]) {
+		if _, err := r.Peek(1); err == io.EOF {
+			return nil, errIncompleteBatch
+		}
+
+		return nil, fmt.Errorf("%w: invalid checksum of the batch at offset %d", ErrCorrupted, offset)
+	}
+
+	return batch, nil
+}
+
+type transaction struct {
+	ng    *Engine
+	tx    engine.Transaction
+	batch batch
+}
+
+// Rollback the transaction, nothing is written to the file.
+func (t *transaction) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// Commit appends the changes made by the transaction to the file, then applies them in memory.
+// If the file reaches the compaction threshold, it is compacted before returning.
+// Compaction failures don't fail the commit, they are reported to Options.OnCompactionError.
+func (t *transaction) Commit() error {
+	if t.batch.Len() == 0 {
+		return t.tx.Commit()
+	}
+
+	err := t.ng.write(t.batch.Bytes())
+	if err != nil {
+		t.tx.Rollback()
+		return err
+	}
+
+	err = t.tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	// the transaction is durable at this point, a failed compaction
+	// leaves the file as is and is attempted again on the next commit
+	if t.ng.shouldCompact() {
+		err = t.ng.Compact()
+		if err != nil && t.ng.opts.OnCompactionError != nil {
+			t.ng.opts.OnCompactionError(err)
+		}
+	}
+
+	return nil
+}
+
+func (t *transaction) Store(name string) (engine.Store, error) {
+	st, err := t.tx.Store(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &store{st: st, name: name, tx: t}, nil
+}
+
+func (t *transaction) CreateStore(name string) error {
+	err := t.tx.CreateStore(name)
+	if err != nil {
+		return err
+	}
+
+	t.batch.add(opCreateStore, name)
+	return nil
+}
+
+func (t *transaction) DropStore(name string) error {
+	err := t.tx.DropStore(name)
+	if err != nil {
+		return err
+	}
+
+	t.batch.add(opDropStore, name)
+	return nil
+}
+
+func (t *transaction) ListStores(prefix string) ([]string, error) {
+	return t.tx.ListStores(prefix)
+}
+
+// A store logs every successful change made to the underlying memory store.
+type store struct {
+	st   engine.Store
+	name string
+	tx   *transaction
+}
+
+func (s *store) Get(k []byte) ([]byte, error) {
+	return s.st.Get(k)
+}
+
+func (s *store) Put(k, v []byte) error {
+	err := s.st.Put(k, v)
+	if err != nil {
+		return err
+	}
+
+	s.tx.batch.add(opPut, s.name, k, v)
+	return nil
+}
+
+func (s *store) Delete(k []byte) error {
+	err := s.st.Delete(k)
+	if err != nil {
+		return err
+	}
+
+	s.tx.batch.add(opDelete, s.name, k)
+	return nil
+}
+
+func (s *store) Truncate() error {
+	err := s.st.Truncate()
+	if err != nil {
+		return err
+	}
+
+	s.tx.batch.add(opTruncate, s.name)
+	return nil
+}
+
+func (s *store) AscendGreaterOrEqual(pivot []byte, fn func(k, v []byte) error) error {
+	return s.st.AscendGreaterOrEqual(pivot, fn)
+}
+
+func (s *store) DescendLessOrEqual(pivot []byte, fn func(k, v []byte) error) error {
+	return s.st.DescendLessOrEqual(pivot, fn)
+}
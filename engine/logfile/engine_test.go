@@ -0,0 +1,333 @@
+package logfile_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+
+	"github.com/asdine/genji/engine"
+	"github.com/asdine/genji/engine/enginetest"
+	"github.com/asdine/genji/engine/logfile"
+	"github.com/stretchr/testify/require"
+)
+
+func builder(t testing.TB) func() (engine.Engine, func()) {
+	return func() (engine.Engine, func()) {
+		dir, cleanup := tempDir(t)
+		ng, err := logfile.NewEngine(path.Join(dir, "test.log"), &logfile.Options{NoSync: true})
+		require.NoError(t, err)
+		return ng, func() {
+			ng.Close()
+			cleanup()
+		}
+	}
+}
+
+func TestLogFileEngine(t *testing.T) {
+	enginetest.TestSuite(t, builder(t))
+}
+
+func BenchmarkLogFileEngineStorePut(b *testing.B) {
+	enginetest.BenchmarkStorePut(b, builder(b))
+}
+
+func BenchmarkLogFileEngineTableScan(b *testing.B) {
+	enginetest.BenchmarkStoreScan(b, builder(b))
+}
+
+func tempDir(t require.TestingT) (string, func()) {
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+
+	return dir, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+// update runs fn in a read/write transaction on the store "test", creating it if necessary.
+func update(t *testing.T, ng engine.Engine, fn func(st engine.Store)) {
+	tx, err := ng.Begin(true)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	st, err := tx.Store("test")
+	if err == engine.ErrStoreNotFound {
+		require.NoError(t, tx.CreateStore("test"))
+		st, err = tx.Store("test")
+	}
+	require.NoError(t, err)
+
+	fn(st)
+	require.NoError(t, tx.Commit())
+}
+
+// dump returns the content of the store "test".
+func dump(t *testing.T, ng engine.Engine) string {
+	tx, err := ng.Begin(false)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	st, err := tx.Store("test")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = st.AscendGreaterOrEqual(nil, func(k, v []byte) error {
+		fmt.Fprintf(&buf, "%s=%s\n", k, v)
+		return nil
+	})
+	require.NoError(t, err)
+	return buf.String()
+}
+
+func TestReopen(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	p := path.Join(dir, "test.log")
+
+	ng, err := logfile.NewEngine(p, nil)
+	require.NoError(t, err)
+
+	update(t, ng, func(st engine.Store) {
+		require.NoError(t, st.Put([]byte("a"), []byte("1")))
+		require.NoError(t, st.Put([]byte("b"), []byte("2")))
+		require.NoError(t, st.Put([]byte("c"), []byte("3")))
+	})
+	update(t, ng, func(st engine.Store) {
+		require.NoError(t, st.Delete([]byte("b")))
+		require.NoError(t, st.Put([]byte("c"), []byte("4")))
+	})
+
+	// rolled back transactions are not written
+	tx, err := ng.Begin(true)
+	require.NoError(t, err)
+	require.NoError(t, tx.CreateStore("other"))
+	require.NoError(t, tx.Rollback())
+
+	require.NoError(t, ng.Close())
+
+	ng, err = logfile.NewEngine(p, nil)
+	require.NoError(t, err)
+	require.Equal(t, "a=1\nc=4\n", dump(t, ng))
+
+	tx, err = ng.Begin(false)
+	require.NoError(t, err)
+	list, err := tx.ListStores("")
+	require.NoError(t, err)
+	require.Equal(t, []string{"test"}, list)
+	require.NoError(t, tx.Rollback())
+
+	update(t, ng, func(st engine.Store) {
+		require.NoError(t, st.Truncate())
+		require.NoError(t, st.Put([]byte("d"), []byte("5")))
+	})
+	require.NoError(t, ng.Close())
+
+	ng, err = logfile.NewEngine(p, nil)
+	require.NoError(t, err)
+	defer ng.Close()
+	require.Equal(t, "d=5\n", dump(t, ng))
+}
+
+func TestIncompleteBatch(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	p := path.Join(dir, "test.log")
+
+	ng, err := logfile.NewEngine(p, nil)
+	require.NoError(t, err)
+	update(t, ng, func(st engine.Store) {
+		require.NoError(t, st.Put([]byte("a"), []byte("1")))
+	})
+	size := ng.Size()
+	update(t, ng, func(st engine.Store) {
+		require.NoError(t, st.Put([]byte("b"), []byte("2")))
+	})
+	require.NoError(t, ng.Close())
+
+	// simulate a crash in the middle of the last write
+	err = os.Truncate(p, size+5)
+	require.NoError(t, err)
+
+	ng, err = logfile.NewEngine(p, nil)
+	require.NoError(t, err)
+	require.Equal(t, "a=1\n", dump(t, ng))
+	require.Equal(t, size, ng.Size())
+
+	// the next batches are written after the last complete one
+	update(t, ng, func(st engine.Store) {
+		require.NoError(t, st.Put([]byte("c"), []byte("3")))
+	})
+	require.NoError(t, ng.Close())
+
+	ng, err = logfile.NewEngine(p, nil)
+	require.NoError(t, err)
+	defer ng.Close()
+	require.Equal(t, "a=1\nc=3\n", dump(t, ng))
+}
+
+func TestCorruptedBatch(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	p := path.Join(dir, "test.log")
+
+	ng, err := logfile.NewEngine(p, nil)
+	require.NoError(t, err)
+	var sizes []int64
+	for _, k := range []string{"a", "b", "c"} {
+		update(t, ng, func(st engine.Store) {
+			require.NoError(t, st.Put([]byte(k), []byte("1")))
+		})
+		sizes = append(sizes, ng.Size())
+	}
+	require.NoError(t, ng.Close())
+
+	// flip the last byte of a batch
+	corrupt := func(offset int64) {
+		f, err := os.OpenFile(p, os.O_RDWR, 0600)
+		require.NoError(t, err)
+		defer f.Close()
+
+		b := make([]byte, 1)
+		_, err = f.ReadAt(b, offset)
+		require.NoError(t, err)
+		b[0] ^= 0xFF
+		_, err = f.WriteAt(b, offset)
+		require.NoError(t, err)
+	}
+
+	t.Run("Middle", func(t *testing.T) {
+		// the transactions committed after the damaged batch must not be discarded
+		corrupt(sizes[1] - 1)
+		defer corrupt(sizes[1] - 1)
+
+		_, err := logfile.NewEngine(p, nil)
+		require.True(t, errors.Is(err, logfile.ErrCorrupted))
+
+		fi, err := os.Stat(p)
+		require.NoError(t, err)
+		require.Equal(t, sizes[2], fi.Size())
+	})
+
+	t.Run("End", func(t *testing.T) {
+		// a damaged batch at the end of the file is the result of an interrupted write
+		corrupt(sizes[2] - 1)
+
+		ng, err := logfile.NewEngine(p, nil)
+		require.NoError(t, err)
+		defer ng.Close()
+		require.Equal(t, "a=1\nb=1\n", dump(t, ng))
+		require.Equal(t, sizes[1], ng.Size())
+	})
+
+	t.Run("Length", func(t *testing.T) {
+		// a damaged length larger than the rest of the file is not allocated
+		corrupt(sizes[0])
+
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		ng, err := logfile.NewEngine(p, nil)
+		runtime.ReadMemStats(&after)
+		require.NoError(t, err)
+		defer ng.Close()
+		require.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20))
+		require.Equal(t, "a=1\n", dump(t, ng))
+		require.Equal(t, sizes[0], ng.Size())
+	})
+}
+
+func TestInvalidFile(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	p := path.Join(dir, "test.log")
+
+	err := ioutil.WriteFile(p, []byte("not a log file"), 0600)
+	require.NoError(t, err)
+
+	_, err = logfile.NewEngine(p, nil)
+	require.Error(t, err)
+}
+
+func TestCompaction(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	p := path.Join(dir, "test.log")
+
+	ng, err := logfile.NewEngine(p, &logfile.Options{CompactionMinSize: 4096})
+	require.NoError(t, err)
+
+	// overwrite the same keys until the file is compacted
+	var compacted bool
+	for i := 0; i < 100 && !compacted; i++ {
+		before := ng.Size()
+		update(t, ng, func(st engine.Store) {
+			for j := 0; j < 10; j++ {
+				require.NoError(t, st.Put([]byte(fmt.Sprintf("key%d", j)), []byte(fmt.Sprintf("value%d", i))))
+			}
+		})
+		compacted = ng.Size() < before
+	}
+	require.True(t, compacted)
+
+	fi, err := os.Stat(p)
+	require.NoError(t, err)
+	require.Equal(t, ng.Size(), fi.Size())
+	require.Less(t, fi.Size(), int64(4096))
+
+	update(t, ng, func(st engine.Store) {
+		require.NoError(t, st.Delete([]byte("key0")))
+	})
+	expected := dump(t, ng)
+	require.NotContains(t, expected, "key0")
+
+	require.NoError(t, ng.Compact())
+	require.NoError(t, ng.Close())
+
+	ng, err = logfile.NewEngine(p, nil)
+	require.NoError(t, err)
+	defer ng.Close()
+	require.Equal(t, expected, dump(t, ng))
+}
+
+func TestCompactionError(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	p := path.Join(dir, "test.log")
+
+	// the temporary file of the compaction can't be created
+	err := os.Mkdir(p+".compact", 0700)
+	require.NoError(t, err)
+
+	var compactionErrs int
+	ng, err := logfile.NewEngine(p, &logfile.Options{
+		CompactionMinSize: 256,
+		OnCompactionError: func(err error) {
+			compactionErrs++
+		},
+	})
+	require.NoError(t, err)
+	defer ng.Close()
+
+	// transactions are committed even if the compaction fails
+	var i int
+	for ; compactionErrs < 2; i++ {
+		update(t, ng, func(st engine.Store) {
+			require.NoError(t, st.Put([]byte("key"), []byte(fmt.Sprintf("value%d", i))))
+		})
+	}
+	require.Equal(t, fmt.Sprintf("key=value%d\n", i-1), dump(t, ng))
+
+	// the compaction is attempted again on the next commit
+	require.NoError(t, os.Remove(p+".compact"))
+	before := ng.Size()
+	update(t, ng, func(st engine.Store) {
+		require.NoError(t, st.Put([]byte("key"), []byte("last")))
+	})
+	require.Less(t, ng.Size(), before)
+	require.Equal(t, 2, compactionErrs)
+	require.Equal(t, "key=last\n", dump(t, ng))
+}
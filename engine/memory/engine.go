@@ -1,3 +1,9 @@
+// Package memory implements an in-memory engine.
+//
+// Transactions use snapshot isolation: each store is a copy-on-write btree, a read-only transaction
+// reads the trees as they were committed when it started, and a read/write transaction works on
+// clones of those trees, which replace them when it commits. Readers never block the writer and vice versa.
+// Only one read/write transaction can run at a time.
 package memory
 
 import (
@@ -11,10 +17,21 @@ import (
 )
 
 type Engine struct {
+	// txs is held for reading by every transaction during its lifetime,
+	// so that Close can wait for all of them to complete.
+	txs sync.RWMutex
+	// writer is held by the read/write transaction during its lifetime.
+	writer sync.Mutex
+
+	// mu protects the fields below.
+	mu     sync.RWMutex
 	closed bool
+	// stores contains the trees committed by the last read/write transaction. Neither the map
+	// nor the trees are modified once committed, they are replaced by the next commit.
 	stores map[string]*btree.BTree
-
-	mu sync.RWMutex
+	// number of committed transactions that modified the engine.
+	changes  uint64
+	autosave *autosave
 }
 
 func NewEngine() *Engine {
@@ -23,21 +40,44 @@ func NewEngine() *Engine {
 	}
 }
 
+// Begin a transaction. Read-only transactions see the data committed before they started.
+// If writable is true, Begin blocks until the current read/write transaction, if any, completes.
 func (ng *Engine) Begin(writable bool) (engine.Transaction, error) {
+	ng.txs.RLock()
 	if writable {
-		ng.mu.Lock()
-	} else {
-		ng.mu.RLock()
+		ng.writer.Lock()
 	}
 
-	if ng.closed {
+	ng.mu.RLock()
+	closed, stores := ng.closed, ng.stores
+	ng.mu.RUnlock()
+
+	tx := transaction{ng: ng, writable: writable, stores: stores}
+
+	if closed {
+		tx.release()
 		return nil, errors.New("engine closed")
 	}
 
-	return &transaction{ng: ng, writable: writable}, nil
+	if writable {
+		// clones share their nodes with the committed trees until they are modified
+		tx.stores = make(map[string]*btree.BTree, len(stores))
+		for name, tr := range stores {
+			tx.stores[name] = tr.Clone()
+		}
+	}
+
+	return &tx, nil
 }
 
+// Close the engine after all the transactions have completed.
+// If autosave is enabled, a last snapshot is written and its error, if any, is returned.
 func (ng *Engine) Close() error {
+	saveErr := ng.stopAutoSave()
+
+	ng.txs.Lock()
+	defer ng.txs.Unlock()
+
 	ng.mu.Lock()
 	defer ng.mu.Unlock()
 	if ng.closed {
@@ -45,33 +85,34 @@ func (ng *Engine) Close() error {
 	}
 
 	ng.closed = true
-	return nil
+	return saveErr
 }
 
 type transaction struct {
-	ng         *Engine
-	writable   bool
-	onRollback []func() // called during a rollback
-	onCommit   []func() // called during a commit
+	ng       *Engine
+	writable bool
+	// stores contains the committed trees for read-only transactions,
+	// and their clones for read/write transactions.
+	stores     map[string]*btree.BTree
+	modified   bool
 	terminated bool
 }
 
+// release the locks held by the transaction.
+func (tx *transaction) release() {
+	if tx.writable {
+		tx.ng.writer.Unlock()
+	}
+	tx.ng.txs.RUnlock()
+}
+
 func (tx *transaction) Rollback() error {
 	if tx.terminated {
 		return nil
 	}
 
-	for _, undo := range tx.onRollback {
-		undo()
-	}
-
 	tx.terminated = true
-
-	if tx.writable {
-		tx.ng.mu.Unlock()
-	} else {
-		tx.ng.mu.RUnlock()
-	}
+	tx.release()
 
 	return nil
 }
@@ -87,21 +128,20 @@ func (tx *transaction) Commit() error {
 
 	tx.terminated = true
 
-	if tx.writable {
-		for _, fn := range tx.onCommit {
-			fn()
-		}
-
+	if tx.modified {
+		tx.ng.mu.Lock()
+		tx.ng.stores = tx.stores
+		tx.ng.changes++
 		tx.ng.mu.Unlock()
-	} else {
-		tx.ng.mu.RUnlock()
 	}
 
+	tx.release()
+
 	return nil
 }
 
 func (tx *transaction) Store(name string) (engine.Store, error) {
-	tr, ok := tx.ng.stores[name]
+	tr, ok := tx.stores[name]
 	if !ok {
 		return nil, engine.ErrStoreNotFound
 	}
@@ -110,8 +150,8 @@ func (tx *transaction) Store(name string) (engine.Store, error) {
 }
 
 func (tx *transaction) ListStores(prefix string) ([]string, error) {
-	list := make([]string, 0, len(tx.ng.stores))
-	for name := range tx.ng.stores {
+	list := make([]string, 0, len(tx.stores))
+	for name := range tx.stores {
 		if strings.HasPrefix(name, prefix) {
 			list = append(list, name)
 		}
@@ -132,13 +172,8 @@ func (tx *transaction) CreateStore(name string) error {
 		return engine.ErrStoreAlreadyExists
 	}
 
-	tr := btree.New(3)
-
-	tx.ng.stores[name] = tr
-
-	tx.onRollback = append(tx.onRollback, func() {
-		delete(tx.ng.stores, name)
-	})
+	tx.stores[name] = btree.New(3)
+	tx.modified = true
 
 	return nil
 }
@@ -148,16 +183,13 @@ func (tx *transaction) DropStore(name string) error {
 		return engine.ErrTransactionReadOnly
 	}
 
-	rb, ok := tx.ng.stores[name]
+	_, ok := tx.stores[name]
 	if !ok {
 		return engine.ErrStoreNotFound
 	}
 
-	delete(tx.ng.stores, name)
-
-	tx.onRollback = append(tx.onRollback, func() {
-		tx.ng.stores[name] = rb
-	})
+	delete(tx.stores, name)
+	tx.modified = true
 
 	return nil
 }
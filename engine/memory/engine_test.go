@@ -2,10 +2,12 @@ package memory_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/asdine/genji/engine"
 	"github.com/asdine/genji/engine/enginetest"
 	"github.com/asdine/genji/engine/memory"
+	"github.com/stretchr/testify/require"
 )
 
 func builder() (engine.Engine, func()) {
@@ -17,6 +19,122 @@ func TestMemoryEngine(t *testing.T) {
 	enginetest.TestSuite(t, builder)
 }
 
+func TestSnapshotIsolation(t *testing.T) {
+	ng := memory.NewEngine()
+	defer ng.Close()
+
+	tx, err := ng.Begin(true)
+	require.NoError(t, err)
+	require.NoError(t, tx.CreateStore("test"))
+	st, err := tx.Store("test")
+	require.NoError(t, err)
+	require.NoError(t, st.Put([]byte("a"), []byte("1")))
+	require.NoError(t, st.Put([]byte("b"), []byte("2")))
+	require.NoError(t, tx.Commit())
+
+	// a long running read transaction
+	rtx, err := ng.Begin(false)
+	require.NoError(t, err)
+	defer rtx.Rollback()
+	rst, err := rtx.Store("test")
+	require.NoError(t, err)
+
+	// doesn't block writers
+	done := make(chan error)
+	go func() {
+		tx, err := ng.Begin(true)
+		if err != nil {
+			done <- err
+			return
+		}
+		defer tx.Rollback()
+
+		st, err := tx.Store("test")
+		if err != nil {
+			done <- err
+			return
+		}
+		if err = st.Put([]byte("a"), []byte("10")); err != nil {
+			done <- err
+			return
+		}
+		if err = st.Delete([]byte("b")); err != nil {
+			done <- err
+			return
+		}
+		if err = tx.CreateStore("other"); err != nil {
+			done <- err
+			return
+		}
+		done <- tx.Commit()
+	}()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the writer is blocked by the reader")
+	}
+
+	// which don't affect it
+	v, err := rst.Get([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("1"), v)
+	v, err = rst.Get([]byte("b"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("2"), v)
+	_, err = rtx.Store("other")
+	require.Equal(t, engine.ErrStoreNotFound, err)
+
+	// new transactions see the changes
+	tx, err = ng.Begin(false)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	st, err = tx.Store("test")
+	require.NoError(t, err)
+	v, err = st.Get([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("10"), v)
+	_, err = st.Get([]byte("b"))
+	require.Equal(t, engine.ErrKeyNotFound, err)
+}
+
+func TestRollback(t *testing.T) {
+	ng := memory.NewEngine()
+	defer ng.Close()
+
+	tx, err := ng.Begin(true)
+	require.NoError(t, err)
+	require.NoError(t, tx.CreateStore("test"))
+	st, err := tx.Store("test")
+	require.NoError(t, err)
+	require.NoError(t, st.Put([]byte("a"), []byte("1")))
+	require.NoError(t, tx.Commit())
+
+	tx, err = ng.Begin(true)
+	require.NoError(t, err)
+	st, err = tx.Store("test")
+	require.NoError(t, err)
+	require.NoError(t, st.Truncate())
+	require.NoError(t, st.Put([]byte("b"), []byte("2")))
+	require.NoError(t, tx.DropStore("test"))
+	require.NoError(t, tx.Rollback())
+
+	tx, err = ng.Begin(false)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	st, err = tx.Store("test")
+	require.NoError(t, err)
+
+	var keys []string
+	err = st.AscendGreaterOrEqual(nil, func(k, v []byte) error {
+		keys = append(keys, string(k))
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, keys)
+}
+
 func BenchmarkMemoryEngineStorePut(b *testing.B) {
 	enginetest.BenchmarkStorePut(b, builder)
 }
@@ -0,0 +1,229 @@
+package memory
+
+import (
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/asdine/genji/internal/archive"
+	"github.com/google/btree"
+)
+
+const (
+	snapshotMagic   = "GENJIMEM"
+	snapshotVersion = 1
+)
+
+// SaveTo writes a snapshot of all the stores to w. The snapshot is taken within a read-only transaction:
+// it is consistent and doesn't prevent other transactions from writing.
+//
+// A snapshot starts with a header and the number of stores, followed by each store: its name,
+// its number of items and the items themselves, each key and value being prefixed by its length.
+// It ends with the CRC-32 checksum of all the preceding bytes.
+func (ng *Engine) SaveTo(w io.Writer) error {
+	tx, err := ng.Begin(false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	sw := archive.NewWriter(w)
+
+	sw.Write([]byte(snapshotMagic))
+	sw.Write([]byte{snapshotVersion})
+	stores := tx.(*transaction).stores
+	sw.WriteUvarint(uint64(len(stores)))
+
+	names, err := tx.ListStores("")
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		tr := stores[name]
+
+		sw.WriteBytes([]byte(name))
+		sw.WriteUvarint(uint64(tr.Len()))
+
+		tr.Ascend(func(i btree.Item) bool {
+			it := i.(*item)
+			sw.WriteBytes(it.k)
+			sw.WriteBytes(it.v)
+			return sw.Err() == nil
+		})
+	}
+
+	return sw.Close()
+}
+
+// LoadFrom replaces the content of the engine by the snapshot read from r, which must have been created by SaveTo.
+// The snapshot is read entirely and verified before replacing anything.
+func (ng *Engine) LoadFrom(r io.Reader) error {
+	sr := archive.NewReader(r, errCorruptedSnapshot)
+
+	header := sr.Read(len(snapshotMagic) + 1)
+	if sr.Err() != nil || string(header[:len(snapshotMagic)]) != snapshotMagic {
+		return errors.New("not a memory engine snapshot")
+	}
+	if header[len(snapshotMagic)] != snapshotVersion {
+		return errors.New("unsupported snapshot version")
+	}
+
+	stores := make(map[string]*btree.BTree)
+
+	count := sr.ReadUvarint()
+	for i := uint64(0); i < count && sr.Err() == nil; i++ {
+		name := string(sr.ReadBytes())
+		tr := btree.New(3)
+
+		items := sr.ReadUvarint()
+		for j := uint64(0); j < items && sr.Err() == nil; j++ {
+			k := sr.ReadBytes()
+			v := sr.ReadBytes()
+			tr.ReplaceOrInsert(&item{k: k, v: v})
+		}
+
+		stores[name] = tr
+	}
+
+	err := sr.Close()
+	if err != nil {
+		return err
+	}
+
+	tx, err := ng.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	wtx := tx.(*transaction)
+	wtx.stores = stores
+	wtx.modified = true
+	return tx.Commit()
+}
+
+// SaveFile writes a snapshot to the file located at path. The snapshot is written to a temporary file first,
+// which then replaces the one at path, so that a complete snapshot is always available.
+func (ng *Engine) SaveFile(path string) error {
+	tmpPath := path + ".tmp"
+
+	f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	err = ng.SaveTo(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// LoadFile loads the snapshot stored in the file located at path.
+// If the file doesn't exist, the engine is left untouched and no error is returned.
+func (ng *Engine) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return ng.LoadFrom(f)
+}
+
+// AutoSave saves a snapshot to the file located at path every interval, if the content of the engine changed
+// since the last snapshot, and a last time when the engine is closed.
+// Errors are ignored until the engine is closed: the snapshot is written again at the next interval.
+// It must only be called once.
+func (ng *Engine) AutoSave(path string, interval time.Duration) error {
+	ng.mu.Lock()
+	defer ng.mu.Unlock()
+
+	if ng.autosave != nil {
+		return errors.New("autosave already enabled")
+	}
+
+	as := autosave{
+		path:  path,
+		saved: ng.changes,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	ng.autosave = &as
+
+	go func() {
+		defer close(as.done)
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-as.stop:
+				return
+			case <-t.C:
+				ng.save(&as)
+			}
+		}
+	}()
+
+	return nil
+}
+
+type autosave struct {
+	path  string
+	saved uint64 // number of changes of the engine at the time of the last snapshot
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// save writes a snapshot if the engine changed since the last one.
+func (ng *Engine) save(as *autosave) error {
+	ng.mu.RLock()
+	changes := ng.changes
+	ng.mu.RUnlock()
+
+	if changes == as.saved {
+		return nil
+	}
+
+	err := ng.SaveFile(as.path)
+	if err != nil {
+		return err
+	}
+
+	as.saved = changes
+	return nil
+}
+
+// stopAutoSave stops the autosave goroutine, if any, and writes a last snapshot.
+func (ng *Engine) stopAutoSave() error {
+	ng.mu.Lock()
+	as := ng.autosave
+	ng.autosave = nil
+	ng.mu.Unlock()
+
+	if as == nil {
+		return nil
+	}
+
+	close(as.stop)
+	<-as.done
+
+	return ng.save(as)
+}
+
+var errCorruptedSnapshot = errors.New("snapshot corrupted")
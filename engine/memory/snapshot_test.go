@@ -0,0 +1,123 @@
+package memory_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/asdine/genji"
+	"github.com/asdine/genji/engine/memory"
+	"github.com/asdine/genji/record/recordutil"
+	"github.com/stretchr/testify/require"
+)
+
+func query(t *testing.T, db *genji.DB, q string) string {
+	res, err := db.Query(q)
+	require.NoError(t, err)
+	defer res.Close()
+
+	var buf bytes.Buffer
+	err = recordutil.IteratorToCSV(&buf, res)
+	require.NoError(t, err)
+	return buf.String()
+}
+
+func TestSnapshot(t *testing.T) {
+	ng := memory.NewEngine()
+	db, err := genji.New(ng)
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE test;
+		CREATE INDEX idx_test_a ON test (a);
+		INSERT INTO test (a, b) VALUES (1, 'foo'), (2, 'bar');
+	`)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = ng.SaveTo(&buf)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	snapshot := buf.Bytes()
+
+	ng = memory.NewEngine()
+	err = ng.LoadFrom(bytes.NewReader(snapshot))
+	require.NoError(t, err)
+
+	db, err = genji.New(ng)
+	require.NoError(t, err)
+	defer db.Close()
+	require.Equal(t, "2,bar\n", query(t, db, "SELECT a, b FROM test WHERE a > 1"))
+
+	t.Run("Replaces the content", func(t *testing.T) {
+		err := db.Exec("DROP TABLE test; CREATE TABLE other")
+		require.NoError(t, err)
+
+		err = ng.LoadFrom(bytes.NewReader(snapshot))
+		require.NoError(t, err)
+		require.Equal(t, "1,foo\n2,bar\n", query(t, db, "SELECT a, b FROM test WHERE a > 0"))
+
+		err = db.Exec("SELECT * FROM other")
+		require.Error(t, err)
+	})
+
+	t.Run("Corrupted", func(t *testing.T) {
+		for _, data := range [][]byte{
+			nil,
+			[]byte("not a snapshot"),
+			snapshot[:len(snapshot)-1],
+			snapshot[:len(snapshot)/2],
+			append(append([]byte{}, snapshot[:20]...), bytes.Repeat([]byte{0xFF}, 20)...),
+		} {
+			err := ng.LoadFrom(bytes.NewReader(data))
+			require.Error(t, err)
+		}
+
+		// the engine is left untouched
+		require.Equal(t, "1,foo\n2,bar\n", query(t, db, "SELECT a, b FROM test WHERE a > 0"))
+	})
+}
+
+func TestAutoSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	p := path.Join(dir, "snapshot")
+
+	ng := memory.NewEngine()
+	err = ng.LoadFile(p)
+	require.NoError(t, err)
+
+	err = ng.AutoSave(p, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.Error(t, ng.AutoSave(p, time.Second))
+
+	db, err := genji.New(ng)
+	require.NoError(t, err)
+
+	err = db.Exec("CREATE TABLE test; CREATE INDEX idx_test_a ON test (a); INSERT INTO test (a) VALUES (1)")
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(p)
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+
+	// the last changes are saved when the engine is closed
+	err = db.Exec("INSERT INTO test (a) VALUES (2)")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	ng = memory.NewEngine()
+	err = ng.LoadFile(p)
+	require.NoError(t, err)
+
+	db, err = genji.New(ng)
+	require.NoError(t, err)
+	defer db.Close()
+	require.Equal(t, "1\n2\n", query(t, db, "SELECT a FROM test WHERE a > 0"))
+}
@@ -8,9 +8,9 @@ import (
 	"github.com/google/btree"
 )
 
+// items are shared by the committed trees and their clones, they must never be modified.
 type item struct {
-	k, v    []byte
-	deleted bool
+	k, v []byte
 }
 
 func (i *item) Less(than btree.Item) bool {
@@ -31,44 +31,18 @@ func (s *storeTx) Put(k, v []byte) error {
 		return errors.New("empty keys are forbidden")
 	}
 
-	it := &item{k: k}
-	if i := s.tr.Get(it); i != nil {
-		cur := i.(*item)
-
-		oldv, oldDeleted := cur.v, cur.deleted
-		cur.v = v
-		cur.deleted = false
-
-		s.tx.onRollback = append(s.tx.onRollback, func() {
-			cur.v = oldv
-			cur.deleted = oldDeleted
-		})
-
-		return nil
-	}
-
-	it.v = v
-	s.tr.ReplaceOrInsert(it)
-
-	s.tx.onRollback = append(s.tx.onRollback, func() {
-		s.tr.Delete(it)
-	})
+	s.tr.ReplaceOrInsert(&item{k: k, v: v})
+	s.tx.modified = true
 
 	return nil
 }
 
 func (s *storeTx) Get(k []byte) ([]byte, error) {
 	it := s.tr.Get(&item{k: k})
-
 	if it == nil {
 		return nil, engine.ErrKeyNotFound
 	}
 
-	i := it.(*item)
-	if i.deleted {
-		return nil, engine.ErrKeyNotFound
-	}
-
 	return it.(*item).v, nil
 }
 
@@ -77,25 +51,12 @@ func (s *storeTx) Delete(k []byte) error {
 		return engine.ErrTransactionReadOnly
 	}
 
-	it := s.tr.Get(&item{k: k})
+	it := s.tr.Delete(&item{k: k})
 	if it == nil {
 		return engine.ErrKeyNotFound
 	}
 
-	i := it.(*item)
-	if i.deleted {
-		return engine.ErrKeyNotFound
-	}
-
-	i.deleted = true
-
-	s.tx.onRollback = append(s.tx.onRollback, func() {
-		i.deleted = false
-	})
-
-	s.tx.onCommit = append(s.tx.onCommit, func() {
-		s.tr.Delete(i)
-	})
+	s.tx.modified = true
 	return nil
 }
 
@@ -104,12 +65,9 @@ func (s *storeTx) Truncate() error {
 		return engine.ErrTransactionReadOnly
 	}
 
-	old := s.tr
-	s.tr = btree.New(3)
-
-	s.tx.onRollback = append(s.tx.onRollback, func() {
-		s.tr = old
-	})
+	// the nodes are not reused, they might be shared with the committed tree
+	s.tr.Clear(false)
+	s.tx.modified = true
 
 	return nil
 }
@@ -117,9 +75,6 @@ func (s *storeTx) Truncate() error {
 func (s *storeTx) AscendGreaterOrEqual(start []byte, fn func(k, v []byte) error) (err error) {
 	iterator := btree.ItemIterator(func(i btree.Item) bool {
 		it := i.(*item)
-		if it.deleted {
-			return true
-		}
 		err = fn(it.k, it.v)
 		return err == nil
 	})
@@ -134,26 +89,17 @@ func (s *storeTx) AscendGreaterOrEqual(start []byte, fn func(k, v []byte) error)
 }
 
 func (s *storeTx) DescendLessOrEqual(pivot []byte, fn func(k, v []byte) error) (err error) {
-	if pivot == nil {
-		s.tr.Descend(btree.ItemIterator(func(i btree.Item) bool {
-			it := i.(*item)
-			if it.deleted {
-				return true
-			}
-			err = fn(it.k, it.v)
-			return err == nil
-		}))
-		return
-	}
-
-	s.tr.DescendLessOrEqual(&item{k: pivot}, btree.ItemIterator(func(i btree.Item) bool {
+	iterator := btree.ItemIterator(func(i btree.Item) bool {
 		it := i.(*item)
-		if it.deleted {
-			return true
-		}
 		err = fn(it.k, it.v)
 		return err == nil
-	}))
+	})
+
+	if pivot == nil {
+		s.tr.Descend(iterator)
+	} else {
+		s.tr.DescendLessOrEqual(&item{k: pivot}, iterator)
+	}
 
 	return
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/asdine/genji/internal/scanner"
 )
@@ -23,6 +24,13 @@ var (
 	// same name as an existing one.
 	ErrIndexAlreadyExists = errors.New("index already exists")
 
+	// ErrSequenceNotFound is returned when the targeted sequence doesn't exist.
+	ErrSequenceNotFound = errors.New("sequence not found")
+
+	// ErrSequenceAlreadyExists is returned when attempting to create a sequence with the
+	// same name as an existing one.
+	ErrSequenceAlreadyExists = errors.New("sequence already exists")
+
 	// ErrRecordNotFound is returned when no record is associated with the provided key.
 	ErrRecordNotFound = errors.New("record not found")
 
@@ -54,3 +62,68 @@ func (e *ParseError) Error() string {
 	}
 	return fmt.Sprintf("found %s, expected %s at line %d, char %d", e.Found, strings.Join(e.Expected, ", "), e.Pos.Line+1, e.Pos.Char+1)
 }
+
+// ConstraintViolationError is returned when a record doesn't satisfy one of the constraints
+// of the table it is written to.
+type ConstraintViolationError struct {
+	// Name of the table.
+	Table string
+	// Field that violates the constraint. Empty for table constraints like CHECK.
+	Field string
+	// Violated constraint, e.g. NOT NULL, STRICT, CHECK (age > 0) or the declared type of the field, like INT64.
+	Constraint string
+	// Err is the cause of the violation, if any, e.g. the error returned
+	// when converting the field to its declared type.
+	Err error
+}
+
+// Error returns the string representation of the error.
+func (e *ConstraintViolationError) Error() string {
+	var msg string
+	if e.Field != "" {
+		msg = fmt.Sprintf("field %q of table %q violates constraint %s", e.Field, e.Table, e.Constraint)
+	} else {
+		msg = fmt.Sprintf("record of table %q violates constraint %s", e.Table, e.Constraint)
+	}
+
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+// Unwrap returns the cause of the violation.
+func (e *ConstraintViolationError) Unwrap() error {
+	return e.Err
+}
+
+// TimeoutError is returned when a statement runs longer than the Timeout of its limits.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+// Error returns the string representation of the error.
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("statement timeout of %s exceeded", e.Timeout)
+}
+
+// ScanLimitError is returned when a statement reads more records than the MaxScannedRecords of its limits.
+type ScanLimitError struct {
+	MaxScannedRecords int
+}
+
+// Error returns the string representation of the error.
+func (e *ScanLimitError) Error() string {
+	return fmt.Sprintf("statement scanned more than %d records", e.MaxScannedRecords)
+}
+
+// MemoryLimitError is returned when a statement keeps more data in memory than the MaxMemory of its limits.
+type MemoryLimitError struct {
+	MaxMemory int
+}
+
+// Error returns the string representation of the error.
+func (e *MemoryLimitError) Error() string {
+	return fmt.Sprintf("statement used more than %d bytes of memory", e.MaxMemory)
+}
@@ -3,7 +3,15 @@ package genji
 import (
 	"bytes"
 	"database/sql/driver"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/asdine/genji/internal/scanner"
 	"github.com/asdine/genji/record"
@@ -13,12 +21,25 @@ import (
 var (
 	trueLitteral  = newSingleEvalValue(value.NewBool(true))
 	falseLitteral = newSingleEvalValue(value.NewBool(false))
-	nilLitteral   = newSingleEvalValue(value.NewString("nil"))
+	nullLitteral  = newSingleEvalValue(value.NewNull())
 )
 
+// functions maps the name of every supported function, in lowercase,
+// to a function that creates it from its arguments.
+var functions = map[string]func(args ...expr) (expr, error){
+	"decimal": newConversionFunc(value.Decimal),
+	"length":  newLengthFunc,
+	"nextval": newNextValFunc,
+	"now":     newNowFunc,
+	"uuid":    newConversionFunc(value.UUID),
+}
+
 // An expr evaluates to a value.
+// Its String method returns a representation that can be parsed back
+// into an equivalent expression.
 type expr interface {
 	Eval(evalStack) (evalValue, error)
+	String() string
 }
 
 // evalStack contains information about the context in which
@@ -48,6 +69,11 @@ func (v evalValue) Truthy() bool {
 	return v.Value.Truthy()
 }
 
+// IsNull returns true if v is a single value of type Null.
+func (v evalValue) IsNull() bool {
+	return !v.IsList && v.Value.Type == value.Null
+}
+
 func newSingleEvalValue(v value.Value) evalValue {
 	return evalValue{
 		Value: litteralValue{
@@ -136,6 +162,39 @@ func float64Value(v float64) litteralValue {
 	return litteralValue{value.NewFloat64(v)}
 }
 
+// timestampValue creates a litteral value of type Timestamp.
+func timestampValue(v time.Time) litteralValue {
+	return litteralValue{value.NewTimestamp(v)}
+}
+
+// durationValue creates a litteral value of type Duration.
+func durationValue(v time.Duration) litteralValue {
+	return litteralValue{value.NewDuration(v)}
+}
+
+// decimalValue creates a litteral value of type Decimal from its string representation.
+func decimalValue(v string) (litteralValue, error) {
+	d, err := value.ParseDecimal(v)
+	if err != nil {
+		return litteralValue{}, err
+	}
+	return litteralValue{d}, nil
+}
+
+// uuidValue creates a litteral value of type UUID from its string representation.
+func uuidValue(v string) (litteralValue, error) {
+	u, err := value.ParseUUID(v)
+	if err != nil {
+		return litteralValue{}, err
+	}
+	return litteralValue{u}, nil
+}
+
+// nullValue creates a litteral value of type Null.
+func nullValue() litteralValue {
+	return litteralValue{value.NewNull()}
+}
+
 // Truthy returns true if the Data is different than the zero value of
 // the type of s.
 // It implements the Value interface.
@@ -148,6 +207,161 @@ func (l litteralValue) Eval(evalStack) (evalValue, error) {
 	return evalValue{Value: l}, nil
 }
 
+// String returns l as an SQL litteral. The litteral is parsed back into a value
+// of the same type: values whose type can't be expressed by a litteral alone,
+// like timestamps or integers other than int64, are cast to their type.
+func (l litteralValue) String() string {
+	switch l.Type {
+	case value.String:
+		if !utf8.Valid(l.Data) {
+			return castString(bytesString(l.Data), l.Type)
+		}
+		return quoteString(string(l.Data), '\'')
+	case value.Bytes:
+		return bytesString(l.Data)
+	case value.Float32, value.Float64:
+		f, err := l.DecodeToFloat64()
+		if err != nil {
+			return l.Value.String()
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return castString(quoteString(strconv.FormatFloat(f, 'f', -1, 64), '\''), l.Type)
+		}
+		// very large and very small numbers use an exponent
+		s := strconv.FormatFloat(f, 'g', -1, 64)
+		// make sure the litteral is parsed back as a float
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		if l.Type == value.Float32 {
+			return castString(s, l.Type)
+		}
+		return s
+	case value.Int64:
+		return l.Value.String()
+	case value.Uint, value.Uint8, value.Uint16, value.Uint32, value.Uint64, value.Int, value.Int8, value.Int16, value.Int32:
+		return castString(l.Value.String(), l.Type)
+	case value.Document:
+		return documentString(l.Value)
+	case value.Array:
+		values, err := l.DecodeToArray()
+		if err != nil {
+			return l.Value.String()
+		}
+
+		elems := make([]string, len(values))
+		for i := range values {
+			elems[i] = litteralValue{values[i]}.String()
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	case value.Timestamp:
+		return castString(quoteString(l.Value.String(), '\''), l.Type)
+	case value.Duration:
+		d, err := l.DecodeToDuration()
+		if err != nil {
+			return l.Value.String()
+		}
+		return durationString(d)
+	case value.Decimal:
+		return "DECIMAL('" + l.Value.String() + "')"
+	case value.UUID:
+		return "UUID('" + l.Value.String() + "')"
+	}
+
+	return l.Value.String()
+}
+
+// castString returns the SQL representation of the cast of the litteral s to t.
+func castString(s string, t value.Type) string {
+	return "CAST(" + s + " AS " + strings.ToUpper(t.String()) + ")"
+}
+
+// bytesString returns data as a bytes litteral, e.g. x'0a1b'.
+func bytesString(data []byte) string {
+	return "x'" + hex.EncodeToString(data) + "'"
+}
+
+// durationString returns a duration as a duration litteral, using the largest unit
+// that represents it exactly.
+func durationString(d time.Duration) string {
+	if d < 0 && d != math.MinInt64 {
+		return "-" + durationString(-d)
+	}
+
+	switch {
+	case d == 0:
+		return "0s"
+	case d%(7*24*time.Hour) == 0:
+		return strconv.FormatInt(int64(d/(7*24*time.Hour)), 10) + "w"
+	case d%(24*time.Hour) == 0:
+		return strconv.FormatInt(int64(d/(24*time.Hour)), 10) + "d"
+	case d%time.Hour == 0:
+		return strconv.FormatInt(int64(d/time.Hour), 10) + "h"
+	case d%time.Minute == 0:
+		return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+	case d%time.Second == 0:
+		return strconv.FormatInt(int64(d/time.Second), 10) + "s"
+	case d%time.Millisecond == 0:
+		return strconv.FormatInt(int64(d/time.Millisecond), 10) + "ms"
+	case d%time.Microsecond == 0:
+		return strconv.FormatInt(int64(d/time.Microsecond), 10) + "u"
+	}
+
+	return strconv.FormatInt(int64(d), 10) + "ns"
+}
+
+// documentString returns a document as an SQL litteral.
+func documentString(v value.Value) string {
+	d, err := record.DecodeDocument(v)
+	if err != nil {
+		return v.String()
+	}
+
+	var fields []string
+	err = d.Iterate(func(f record.Field) error {
+		fields = append(fields, fieldNameString(f.Name)+": "+litteralValue{f.Value}.String())
+		return nil
+	})
+	if err != nil {
+		return v.String()
+	}
+
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+// fieldNameString returns the name of a field, or of a table, quoted if necessary.
+func fieldNameString(name string) string {
+	if isIdent(name) {
+		return name
+	}
+
+	return quoteString(name, '"')
+}
+
+// quoteString surrounds s with the quote character and escapes it
+// so it can be read by the scanner.
+func quoteString(s string, quote byte) string {
+	var buf strings.Builder
+
+	buf.WriteByte(quote)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case quote, '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(s[i])
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	buf.WriteByte(quote)
+
+	return buf.String()
+}
+
 // A litteralValueList represents a litteral value of any type defined by the value package.
 type litteralValueList []evalValue
 
@@ -164,7 +378,7 @@ type litteralExprList []expr
 // Eval evaluates all the expressions and returns a litteralValueList. It implements the Expr interface.
 func (l litteralExprList) Eval(stack evalStack) (evalValue, error) {
 	if len(l) == 0 {
-		return nilLitteral, nil
+		return nullLitteral, nil
 	}
 
 	var err error
@@ -173,28 +387,314 @@ func (l litteralExprList) Eval(stack evalStack) (evalValue, error) {
 	for i, e := range l {
 		values[i], err = e.Eval(stack)
 		if err != nil {
-			return nilLitteral, err
+			return nullLitteral, err
 		}
 	}
 	return evalValue{List: values, IsList: true}, nil
 }
 
+// String returns the list as a comma separated list of expressions surrounded by parentheses.
+func (l litteralExprList) String() string {
+	s := make([]string, len(l))
+	for i, e := range l {
+		s[i] = e.String()
+	}
+
+	return "(" + strings.Join(s, ", ") + ")"
+}
+
+// documentExpr is a list of fields whose values are expressions.
+type documentExpr []kvPair
+
+// Eval evaluates the value of every field and returns a document.
+// It implements the Expr interface.
+func (d documentExpr) Eval(stack evalStack) (evalValue, error) {
+	var fb record.FieldBuffer
+
+	for _, kv := range d {
+		v, err := kv.V.Eval(stack)
+		if err != nil {
+			return nullLitteral, err
+		}
+
+		if v.IsList {
+			return nullLitteral, fmt.Errorf("field %q of document can't be a list", kv.K)
+		}
+
+		fb.Add(record.Field{Name: kv.K, Value: v.Value.Value})
+	}
+
+	v, err := record.NewDocumentValue(fb)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return newSingleEvalValue(v), nil
+}
+
+// String returns the fields of the document surrounded by braces.
+func (d documentExpr) String() string {
+	fields := make([]string, len(d))
+	for i, kv := range d {
+		fields[i] = fieldNameString(kv.K) + ": " + kv.V.String()
+	}
+
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+// arrayExpr is a list of expressions that evaluates to an array.
+type arrayExpr []expr
+
+// Eval evaluates all the expressions and returns an array.
+// It implements the Expr interface.
+func (a arrayExpr) Eval(stack evalStack) (evalValue, error) {
+	values := make([]value.Value, len(a))
+
+	for i, e := range a {
+		v, err := e.Eval(stack)
+		if err != nil {
+			return nullLitteral, err
+		}
+
+		if v.IsList {
+			return nullLitteral, fmt.Errorf("element %d of array can't be a list", i)
+		}
+
+		values[i] = v.Value.Value
+	}
+
+	return newSingleEvalValue(value.NewArray(values...)), nil
+}
+
+// String returns the elements of the array surrounded by brackets.
+func (a arrayExpr) String() string {
+	elems := make([]string, len(a))
+	for i, e := range a {
+		elems[i] = e.String()
+	}
+
+	return "[" + strings.Join(elems, ", ") + "]"
+}
+
+// elements returns the values v is made of: the elements of a list or of an array,
+// or v itself.
+func (v evalValue) elements() ([]evalValue, error) {
+	if v.IsList {
+		return v.List, nil
+	}
+
+	if v.Value.Type != value.Array {
+		return []evalValue{v}, nil
+	}
+
+	values, err := v.Value.DecodeToArray()
+	if err != nil {
+		return nil, err
+	}
+
+	elems := make([]evalValue, len(values))
+	for i := range values {
+		elems[i] = newSingleEvalValue(values[i])
+	}
+
+	return elems, nil
+}
+
+// lengthFunc is the LENGTH function. It returns the number of elements of an array,
+// the number of fields of a document or the size of a string in bytes.
+type lengthFunc struct {
+	e expr
+}
+
+func newLengthFunc(args ...expr) (expr, error) {
+	if len(args) != 1 {
+		return nil, errors.New("LENGTH takes exactly one argument")
+	}
+
+	return lengthFunc{e: args[0]}, nil
+}
+
+// Eval implements the Expr interface.
+func (f lengthFunc) Eval(stack evalStack) (evalValue, error) {
+	v, err := f.e.Eval(stack)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if v.IsList {
+		return evalValue{Value: int64Value(int64(len(v.List)))}, nil
+	}
+
+	var n int
+	switch v.Value.Type {
+	case value.Null:
+		return nullLitteral, nil
+	case value.String, value.Bytes:
+		n = len(v.Value.Data)
+	case value.Array:
+		values, err := v.Value.DecodeToArray()
+		if err != nil {
+			return nullLitteral, err
+		}
+		n = len(values)
+	case value.Document:
+		d, err := record.DecodeDocument(v.Value.Value)
+		if err != nil {
+			return nullLitteral, err
+		}
+		err = d.Iterate(func(record.Field) error {
+			n++
+			return nil
+		})
+		if err != nil {
+			return nullLitteral, err
+		}
+	default:
+		return nullLitteral, fmt.Errorf("LENGTH expects an array, a document or a string, got %s", v.Value.Type)
+	}
+
+	return evalValue{Value: int64Value(int64(n))}, nil
+}
+
+func (f lengthFunc) String() string {
+	return "LENGTH(" + f.e.String() + ")"
+}
+
+// castExpr converts the result of an expression to a given type,
+// following the rules of value.Value.CastAs, e.g. CAST(a AS INT8) or a::INT8.
+type castExpr struct {
+	e expr
+	t value.Type
+}
+
+// Eval implements the Expr interface.
+func (c castExpr) Eval(stack evalStack) (evalValue, error) {
+	v, err := c.e.Eval(stack)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if v.IsList {
+		return nullLitteral, fmt.Errorf("cannot cast a list of values to %s", c.t)
+	}
+
+	x, err := v.Value.CastAs(c.t)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return newSingleEvalValue(x), nil
+}
+
+func (c castExpr) String() string {
+	return "CAST(" + c.e.String() + " AS " + strings.ToUpper(c.t.String()) + ")"
+}
+
+// conversionFunc converts its argument to a given type. It is used to implement the DECIMAL and UUID
+// functions, which convert strings or numbers to decimals and strings or bytes to UUIDs.
+type conversionFunc struct {
+	e expr
+	t value.Type
+}
+
+// newConversionFunc returns the constructor of a function converting its argument to t.
+// If the argument is a litteral, the conversion is done immediately and the constructor returns
+// a litteral, which allows writing decimal and UUID litterals, e.g. DECIMAL('12.50').
+func newConversionFunc(t value.Type) func(args ...expr) (expr, error) {
+	return func(args ...expr) (expr, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s takes exactly one argument", strings.ToUpper(t.String()))
+		}
+
+		if l, ok := args[0].(litteralValue); ok {
+			v, err := l.ConvertTo(t)
+			if err != nil {
+				return nil, err
+			}
+			return litteralValue{v}, nil
+		}
+
+		return conversionFunc{e: args[0], t: t}, nil
+	}
+}
+
+// Eval implements the Expr interface.
+func (f conversionFunc) Eval(stack evalStack) (evalValue, error) {
+	v, err := f.e.Eval(stack)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if v.IsList {
+		return nullLitteral, fmt.Errorf("%s expects a single value", strings.ToUpper(f.t.String()))
+	}
+
+	c, err := v.Value.ConvertTo(f.t)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return newSingleEvalValue(c), nil
+}
+
+func (f conversionFunc) String() string {
+	return strings.ToUpper(f.t.String()) + "(" + f.e.String() + ")"
+}
+
+// nowFunc is the NOW function. It returns the current time, in UTC.
+type nowFunc struct{}
+
+func newNowFunc(args ...expr) (expr, error) {
+	if len(args) != 0 {
+		return nil, errors.New("NOW takes no arguments")
+	}
+
+	return nowFunc{}, nil
+}
+
+// Eval implements the Expr interface.
+func (nowFunc) Eval(evalStack) (evalValue, error) {
+	return newSingleEvalValue(value.NewTimestamp(time.Now().UTC())), nil
+}
+
+func (nowFunc) String() string {
+	return "NOW()"
+}
+
+// parentheses is an expression surrounded by parentheses.
+type parentheses struct {
+	e expr
+}
+
+// Eval evaluates the inner expression. It implements the Expr interface.
+func (p parentheses) Eval(stack evalStack) (evalValue, error) {
+	return p.e.Eval(stack)
+}
+
+func (p parentheses) String() string {
+	return "(" + p.e.String() + ")"
+}
+
 type namedParam string
 
 func (p namedParam) Eval(stack evalStack) (evalValue, error) {
 	v, err := p.Extract(stack.Params)
 	if err != nil {
-		return nilLitteral, err
+		return nullLitteral, err
 	}
 
-	vl, err := value.New(v)
+	vl, err := record.NewValue(v)
 	if err != nil {
-		return nilLitteral, err
+		return nullLitteral, err
 	}
 
 	return newSingleEvalValue(vl), nil
 }
 
+func (p namedParam) String() string {
+	return "$" + string(p)
+}
+
 func (p namedParam) Extract(params []driver.NamedValue) (interface{}, error) {
 	for _, nv := range params {
 		if nv.Name == string(p) {
@@ -210,17 +710,21 @@ type positionalParam int
 func (p positionalParam) Eval(stack evalStack) (evalValue, error) {
 	v, err := p.Extract(stack.Params)
 	if err != nil {
-		return nilLitteral, err
+		return nullLitteral, err
 	}
 
-	vl, err := value.New(v)
+	vl, err := record.NewValue(v)
 	if err != nil {
-		return nilLitteral, err
+		return nullLitteral, err
 	}
 
 	return newSingleEvalValue(vl), nil
 }
 
+func (p positionalParam) String() string {
+	return "?"
+}
+
 func (p positionalParam) Extract(params []driver.NamedValue) (interface{}, error) {
 	idx := int(p - 1)
 	if idx >= len(params) {
@@ -238,15 +742,19 @@ type identOrStringLitteral string
 // It implements the Expr interface.
 func (i identOrStringLitteral) Eval(stack evalStack) (evalValue, error) {
 	if stack.Record != nil {
-		v, err := fieldSelector(i).Eval(stack)
+		f, err := fieldSelector(i).SelectField(stack.Record)
 		if err == nil {
-			return v, nil
+			return newSingleEvalValue(f.Value), nil
 		}
 	}
 
 	return evalValue{Value: stringValue(string(i))}, nil
 }
 
+func (i identOrStringLitteral) String() string {
+	return quoteString(string(i), '"')
+}
+
 type simpleOperator struct {
 	a, b  expr
 	Token scanner.Token
@@ -264,12 +772,23 @@ func (op simpleOperator) RightHand() expr {
 	return op.b
 }
 
-func (op *simpleOperator) SetLeftHandExpr(a expr) {
-	op.a = a
+func (op simpleOperator) Operator() scanner.Token {
+	return op.Token
 }
 
-func (op *simpleOperator) SetRightHandExpr(b expr) {
-	op.b = b
+// String returns both operands separated by the operator. Operands with a lower
+// precedence are surrounded by parentheses to preserve the shape of the tree.
+func (op simpleOperator) String() string {
+	a, b := op.a.String(), op.b.String()
+
+	if o, ok := op.a.(operator); ok && o.Precedence() < op.Precedence() {
+		a = "(" + a + ")"
+	}
+	if o, ok := op.b.(operator); ok && o.Precedence() <= op.Precedence() {
+		b = "(" + b + ")"
+	}
+
+	return a + " " + op.Token.String() + " " + b
 }
 
 type cmpOp struct {
@@ -301,7 +820,13 @@ func lte(a, b expr) expr {
 	return cmpOp{simpleOperator{a, b, scanner.LTE}}
 }
 
+// Eval compares both operands. Comparing a value with null evaluates to null.
+// It implements the Expr interface.
 func (op cmpOp) Eval(ctx evalStack) (evalValue, error) {
+	if q, ok := op.b.(quantifier); ok {
+		return op.evalQuantified(ctx, q)
+	}
+
 	v1, err := op.a.Eval(ctx)
 	if err != nil {
 		return falseLitteral, err
@@ -312,6 +837,10 @@ func (op cmpOp) Eval(ctx evalStack) (evalValue, error) {
 		return falseLitteral, err
 	}
 
+	if v1.IsNull() || v2.IsNull() {
+		return nullLitteral, nil
+	}
+
 	ok, err := op.compare(v1, v2)
 	if ok {
 		return trueLitteral, err
@@ -357,96 +886,203 @@ func (op cmpOp) compare(l, r evalValue) (bool, error) {
 }
 
 func (op cmpOp) compareLitterals(l, r litteralValue) (bool, error) {
-	var err error
+	// null is neither equal, greater or lesser than any value
+	if l.Type == value.Null || r.Type == value.Null {
+		return false, nil
+	}
+
+	// numbers and decimals are compared by value, whatever their type
+	if isNumeric(l.Type) && isNumeric(r.Type) {
+		c, ok, err := compareNumbers(l.Value, r.Value)
+		if err != nil || !ok {
+			return false, err
+		}
+
+		return op.result(c), nil
+	}
 
 	// if same type, no conversion needed
 	if l.Type == r.Type || (l.Type == value.String && r.Type == value.Bytes) || (r.Type == value.String && l.Type == value.Bytes) {
-		var ok bool
-		switch op.Token {
-		case scanner.EQ:
-			ok = bytes.Equal(l.Data, r.Data)
-		case scanner.GT:
-			ok = bytes.Compare(l.Data, r.Data) > 0
-		case scanner.GTE:
-			ok = bytes.Compare(l.Data, r.Data) >= 0
-		case scanner.LT:
-			ok = bytes.Compare(l.Data, r.Data) < 0
-		case scanner.LTE:
-			ok = bytes.Compare(l.Data, r.Data) <= 0
-		}
+		return op.result(bytes.Compare(l.Data, r.Data)), nil
+	}
 
-		return ok, nil
+	// values of different types are never equal, greater or lesser than each other
+	return false, nil
+}
+
+// result turns the result of a comparison, -1, 0 or +1, into a boolean
+// depending on the operator.
+func (op cmpOp) result(c int) bool {
+	switch op.Token {
+	case scanner.EQ:
+		return c == 0
+	case scanner.GT:
+		return c > 0
+	case scanner.GTE:
+		return c >= 0
+	case scanner.LT:
+		return c < 0
+	case scanner.LTE:
+		return c <= 0
 	}
 
-	lv, err := l.Decode()
+	return false
+}
+
+// evalQuantified compares the left operand with every element of the array returned by q.
+// ANY returns true if one of the comparisons is true, ALL returns true if all of them are true.
+// If the result can't be determined because of null elements, it returns null.
+func (op cmpOp) evalQuantified(ctx evalStack, q quantifier) (evalValue, error) {
+	v1, err := op.a.Eval(ctx)
 	if err != nil {
-		return false, err
+		return falseLitteral, err
 	}
 
-	rv, err := r.Decode()
+	v2, err := q.e.Eval(ctx)
 	if err != nil {
-		return false, err
+		return falseLitteral, err
 	}
 
-	// number OP number
-	if value.IsNumber(l.Type) && value.IsNumber(r.Type) {
-		af, bf := numberToFloat(lv), numberToFloat(rv)
+	if v1.IsNull() || v2.IsNull() {
+		return nullLitteral, nil
+	}
 
-		var ok bool
+	if !v2.IsList && v2.Value.Type != value.Array {
+		return falseLitteral, fmt.Errorf("%s expects an array", q.name())
+	}
 
-		switch op.Token {
-		case scanner.EQ:
-			ok = af == bf
-		case scanner.GT:
-			ok = af > bf
-		case scanner.GTE:
-			ok = af >= bf
-		case scanner.LT:
-			ok = af < bf
-		case scanner.LTE:
-			ok = af <= bf
+	elems, err := v2.elements()
+	if err != nil {
+		return falseLitteral, err
+	}
+
+	var hasNull bool
+	for _, e := range elems {
+		if e.IsNull() {
+			hasNull = true
+			continue
 		}
 
-		if ok {
-			return true, nil
+		ok, err := op.compare(v1, e)
+		if err != nil {
+			return falseLitteral, err
 		}
 
-		return false, nil
+		if ok != q.all {
+			return newSingleEvalValue(value.NewBool(ok)), nil
+		}
 	}
-	return false, nil
+
+	if hasNull {
+		return nullLitteral, nil
+	}
+
+	return newSingleEvalValue(value.NewBool(q.all)), nil
+}
+
+// isDecimalOperation returns true if one of the values is a decimal
+// and the other one is either a decimal or a number.
+func isDecimalOperation(a, b value.Value) bool {
+	return (a.Type == value.Decimal && isNumeric(b.Type)) || (b.Type == value.Decimal && isNumeric(a.Type))
+}
+
+// isNumeric returns true if t is a number or a decimal.
+func isNumeric(t value.Type) bool {
+	return value.IsNumber(t) || t == value.Decimal
+}
+
+// compareNumbers compares two numbers or decimals and returns -1, 0 or +1, without losing precision.
+// Like the index encoding, floats are compared with other types using their shortest decimal representation.
+// It returns false if one of the numbers is NaN, which is not ordered.
+func compareNumbers(a, b value.Value) (int, bool, error) {
+	// integers and decimals of the same type are encoded in an order-preserving way
+	if a.Type == b.Type && !value.IsFloat(a.Type) {
+		return bytes.Compare(a.Data, b.Data), true, nil
+	}
+
+	if value.IsInteger(a.Type) && value.IsInteger(b.Type) && !isUnsigned64(a.Type) && !isUnsigned64(b.Type) {
+		x, err := a.DecodeToInt64()
+		if err != nil {
+			return 0, false, err
+		}
+		y, err := b.DecodeToInt64()
+		if err != nil {
+			return 0, false, err
+		}
+
+		return compareInt64(x, y), true, nil
+	}
+
+	if value.IsFloat(a.Type) || value.IsFloat(b.Type) {
+		x, err := numberToFloat(a)
+		if err != nil {
+			return 0, false, err
+		}
+		y, err := numberToFloat(b)
+		if err != nil {
+			return 0, false, err
+		}
+
+		switch {
+		case math.IsNaN(x) || math.IsNaN(y):
+			return 0, false, nil
+		case math.IsInf(x, 0) || math.IsInf(y, 0) || a.Type == b.Type:
+			// infinities can't be represented by decimals
+			return compareFloat64(x, y), true, nil
+		}
+	}
+
+	x, err := a.DecodeToDecimal()
+	if err != nil {
+		return 0, false, err
+	}
+	y, err := b.DecodeToDecimal()
+	if err != nil {
+		return 0, false, err
+	}
+
+	return x.Cmp(y), true, nil
+}
+
+// isUnsigned64 returns true if t can hold values that overflow an int64.
+func isUnsigned64(t value.Type) bool {
+	return t == value.Uint64 || t == value.Uint
+}
+
+func numberToFloat(v value.Value) (float64, error) {
+	if v.Type == value.Decimal {
+		x, err := v.DecodeToDecimal()
+		if err != nil {
+			return 0, err
+		}
+
+		f, _ := x.Float64()
+		return f, nil
+	}
+
+	return v.DecodeToFloat64()
+}
+
+func compareInt64(x, y int64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+
+	return 0
 }
 
-func numberToFloat(v interface{}) float64 {
-	var f float64
-
-	switch t := v.(type) {
-	case uint:
-		f = float64(t)
-	case uint8:
-		f = float64(t)
-	case uint16:
-		f = float64(t)
-	case uint32:
-		f = float64(t)
-	case uint64:
-		f = float64(t)
-	case int:
-		f = float64(t)
-	case int8:
-		f = float64(t)
-	case int16:
-		f = float64(t)
-	case int32:
-		f = float64(t)
-	case int64:
-		f = float64(t)
-	case float32:
-		f = float64(t)
-	case float64:
-		f = t
-	}
-
-	return f
+func compareFloat64(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+
+	return 0
 }
 
 type andOp struct {
@@ -458,18 +1094,23 @@ func and(a, b expr) expr {
 	return &andOp{simpleOperator{a, b, scanner.AND}}
 }
 
-// Eval implements the Expr interface.
+// Eval implements the Expr interface. If one of the operands is falsy, it returns false,
+// otherwise if one of them is null, it returns null.
 func (op *andOp) Eval(ctx evalStack) (evalValue, error) {
 	s, err := op.a.Eval(ctx)
-	if err != nil || !s.Truthy() {
+	if err != nil || (!s.IsNull() && !s.Truthy()) {
 		return falseLitteral, err
 	}
 
-	s, err = op.b.Eval(ctx)
-	if err != nil || !s.Truthy() {
+	t, err := op.b.Eval(ctx)
+	if err != nil || (!t.IsNull() && !t.Truthy()) {
 		return falseLitteral, err
 	}
 
+	if s.IsNull() || t.IsNull() {
+		return nullLitteral, nil
+	}
+
 	return trueLitteral, nil
 }
 
@@ -482,7 +1123,8 @@ func or(a, b expr) expr {
 	return &orOp{simpleOperator{a, b, scanner.OR}}
 }
 
-// Eval implements the Expr interface.
+// Eval implements the Expr interface. If none of the operands is truthy
+// and one of them is null, it returns null.
 func (op *orOp) Eval(ctx evalStack) (evalValue, error) {
 	s, err := op.a.Eval(ctx)
 	if err != nil {
@@ -492,13 +1134,408 @@ func (op *orOp) Eval(ctx evalStack) (evalValue, error) {
 		return trueLitteral, nil
 	}
 
-	s, err = op.b.Eval(ctx)
+	t, err := op.b.Eval(ctx)
 	if err != nil {
 		return falseLitteral, err
 	}
-	if s.Truthy() {
+	if t.Truthy() {
 		return trueLitteral, nil
 	}
 
+	if s.IsNull() || t.IsNull() {
+		return nullLitteral, nil
+	}
+
 	return falseLitteral, nil
 }
+
+type isOp struct {
+	simpleOperator
+}
+
+// is creates an expression that returns true if a and b are both null or equal.
+func is(a, b expr) expr {
+	return isOp{simpleOperator{a, b, scanner.IS}}
+}
+
+// isNot creates an expression that returns true if a and b are not both null and not equal.
+func isNot(a, b expr) expr {
+	return isOp{simpleOperator{a, b, scanner.ISN}}
+}
+
+// Eval implements the Expr interface. Unlike the comparison operators, it never returns null.
+func (op isOp) Eval(ctx evalStack) (evalValue, error) {
+	v1, err := op.a.Eval(ctx)
+	if err != nil {
+		return falseLitteral, err
+	}
+
+	v2, err := op.b.Eval(ctx)
+	if err != nil {
+		return falseLitteral, err
+	}
+
+	var ok bool
+	if v1.IsNull() || v2.IsNull() {
+		ok = v1.IsNull() && v2.IsNull()
+	} else {
+		ok, err = cmpOp{simpleOperator{Token: scanner.EQ}}.compare(v1, v2)
+		if err != nil {
+			return falseLitteral, err
+		}
+	}
+
+	if ok == (op.Token == scanner.IS) {
+		return trueLitteral, nil
+	}
+
+	return falseLitteral, nil
+}
+
+type inOp struct {
+	simpleOperator
+}
+
+// in creates an expression that returns true if a is equal to one of the elements of b.
+// b can be a list of expressions or an array. If b is a single value, it behaves like eq.
+func in(a, b expr) expr {
+	return inOp{simpleOperator{a, b, scanner.IN}}
+}
+
+// Eval implements the Expr interface. If a is not found and a or one of the elements of b
+// is null, it returns null.
+func (op inOp) Eval(ctx evalStack) (evalValue, error) {
+	v1, err := op.a.Eval(ctx)
+	if err != nil {
+		return falseLitteral, err
+	}
+
+	v2, err := op.b.Eval(ctx)
+	if err != nil {
+		return falseLitteral, err
+	}
+
+	if v1.IsNull() || v2.IsNull() {
+		return nullLitteral, nil
+	}
+
+	elems, err := v2.elements()
+	if err != nil {
+		return falseLitteral, err
+	}
+
+	var hasNull bool
+	for _, e := range elems {
+		if e.IsNull() {
+			hasNull = true
+			continue
+		}
+
+		ok, err := cmpOp{simpleOperator{Token: scanner.EQ}}.compare(v1, e)
+		if err != nil {
+			return falseLitteral, err
+		}
+		if ok {
+			return trueLitteral, nil
+		}
+	}
+
+	if hasNull {
+		return nullLitteral, nil
+	}
+
+	return falseLitteral, nil
+}
+
+type arithOp struct {
+	simpleOperator
+}
+
+// add creates an expression that returns the sum of a and b.
+func add(a, b expr) expr {
+	return arithOp{simpleOperator{a, b, scanner.ADD}}
+}
+
+// sub creates an expression that returns the difference of a and b.
+func sub(a, b expr) expr {
+	return arithOp{simpleOperator{a, b, scanner.SUB}}
+}
+
+// mul creates an expression that returns the product of a and b.
+func mul(a, b expr) expr {
+	return arithOp{simpleOperator{a, b, scanner.MUL}}
+}
+
+// div creates an expression that returns the quotient of a and b.
+func div(a, b expr) expr {
+	return arithOp{simpleOperator{a, b, scanner.DIV}}
+}
+
+// mod creates an expression that returns the remainder of the division of a by b.
+func mod(a, b expr) expr {
+	return arithOp{simpleOperator{a, b, scanner.MOD}}
+}
+
+// Eval evaluates both operands and computes the result of the operation.
+// Integers operations return an Int64, other numbers a Float64.
+// Durations can be added to or subtracted from timestamps and other durations,
+// and multiplied or divided by integers. Subtracting two timestamps returns a duration.
+// If one of the operands is null, it returns null.
+// It implements the Expr interface.
+func (op arithOp) Eval(ctx evalStack) (evalValue, error) {
+	v1, err := op.a.Eval(ctx)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	v2, err := op.b.Eval(ctx)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if v1.IsNull() || v2.IsNull() {
+		return nullLitteral, nil
+	}
+
+	if v1.IsList || v2.IsList {
+		return nullLitteral, fmt.Errorf("can't use %s with lists", op.Token)
+	}
+
+	v, err := op.compute(v1.Value.Value, v2.Value.Value)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return newSingleEvalValue(v), nil
+}
+
+func (op arithOp) compute(a, b value.Value) (value.Value, error) {
+	switch {
+	case isDecimalOperation(a, b):
+		return op.computeDecimal(a, b)
+	case value.IsInteger(a.Type) && value.IsInteger(b.Type):
+		z, err := op.computeIntegers(a, b)
+		if err != nil {
+			return value.Value{}, err
+		}
+
+		// the result is an int64, unless only an uint64 can hold it
+		if z.IsInt64() {
+			return value.NewInt64(z.Int64()), nil
+		}
+		if z.IsUint64() {
+			return value.NewUint64(z.Uint64()), nil
+		}
+
+		return value.Value{}, fmt.Errorf("integer overflow: %s %s %s", a, op.Token, b)
+	case value.IsNumber(a.Type) && value.IsNumber(b.Type):
+		x, err := a.DecodeToFloat64()
+		if err != nil {
+			return value.Value{}, err
+		}
+		y, err := b.DecodeToFloat64()
+		if err != nil {
+			return value.Value{}, err
+		}
+
+		switch op.Token {
+		case scanner.ADD:
+			return value.NewFloat64(x + y), nil
+		case scanner.SUB:
+			return value.NewFloat64(x - y), nil
+		case scanner.MUL:
+			return value.NewFloat64(x * y), nil
+		case scanner.DIV:
+			return value.NewFloat64(x / y), nil
+		case scanner.MOD:
+			return value.NewFloat64(math.Mod(x, y)), nil
+		}
+	case a.Type == value.Timestamp && b.Type == value.Timestamp && op.Token == scanner.SUB:
+		x, err := a.DecodeToTimestamp()
+		if err != nil {
+			return value.Value{}, err
+		}
+		y, err := b.DecodeToTimestamp()
+		if err != nil {
+			return value.Value{}, err
+		}
+
+		return value.NewDuration(x.Sub(y)), nil
+	case a.Type == value.Timestamp && b.Type == value.Duration,
+		a.Type == value.Duration && b.Type == value.Timestamp && op.Token == scanner.ADD:
+		if a.Type == value.Duration {
+			a, b = b, a
+		}
+
+		t, err := a.DecodeToTimestamp()
+		if err != nil {
+			return value.Value{}, err
+		}
+		d, err := b.DecodeToDuration()
+		if err != nil {
+			return value.Value{}, err
+		}
+
+		switch op.Token {
+		case scanner.ADD:
+			return value.NewTimestamp(t.Add(d)), nil
+		case scanner.SUB:
+			return value.NewTimestamp(t.Add(-d)), nil
+		}
+	case a.Type == value.Duration && b.Type == value.Duration:
+		x, err := a.DecodeToDuration()
+		if err != nil {
+			return value.Value{}, err
+		}
+		y, err := b.DecodeToDuration()
+		if err != nil {
+			return value.Value{}, err
+		}
+
+		var z time.Duration
+		switch op.Token {
+		case scanner.ADD:
+			z = x + y
+			if (z > x) != (y > 0) {
+				return value.Value{}, fmt.Errorf("duration overflow: %s %s %s", a, op.Token, b)
+			}
+		case scanner.SUB:
+			z = x - y
+			if (z < x) != (y > 0) {
+				return value.Value{}, fmt.Errorf("duration overflow: %s %s %s", a, op.Token, b)
+			}
+		default:
+			return value.Value{}, fmt.Errorf("can't compute %s %s %s", a.Type, op.Token, b.Type)
+		}
+
+		return value.NewDuration(z), nil
+	case a.Type == value.Duration && value.IsInteger(b.Type),
+		value.IsInteger(a.Type) && b.Type == value.Duration && op.Token == scanner.MUL:
+		if a.Type != value.Duration {
+			a, b = b, a
+		}
+
+		d, err := a.DecodeToDuration()
+		if err != nil {
+			return value.Value{}, err
+		}
+
+		if op.Token == scanner.MUL || op.Token == scanner.DIV {
+			z, err := op.computeIntegers(value.NewInt64(int64(d)), b)
+			if err != nil {
+				return value.Value{}, err
+			}
+
+			if !z.IsInt64() {
+				return value.Value{}, fmt.Errorf("duration overflow: %s %s %s", a, op.Token, b)
+			}
+
+			return value.NewDuration(time.Duration(z.Int64())), nil
+		}
+	}
+
+	return value.Value{}, fmt.Errorf("can't compute %s %s %s", a.Type, op.Token, b.Type)
+}
+
+// computeIntegers computes the operation on two integers without overflowing.
+// Divisions and modulos are truncated, like in Go.
+func (op arithOp) computeIntegers(a, b value.Value) (*big.Int, error) {
+	x, err := decodeToBigInt(a)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeToBigInt(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if (op.Token == scanner.DIV || op.Token == scanner.MOD) && y.Sign() == 0 {
+		return nil, errors.New("division by zero")
+	}
+
+	z := new(big.Int)
+	switch op.Token {
+	case scanner.ADD:
+		z.Add(x, y)
+	case scanner.SUB:
+		z.Sub(x, y)
+	case scanner.MUL:
+		z.Mul(x, y)
+	case scanner.DIV:
+		z.Quo(x, y)
+	case scanner.MOD:
+		z.Rem(x, y)
+	}
+
+	return z, nil
+}
+
+// decodeToBigInt decodes any integer, including uint64 values larger than math.MaxInt64.
+func decodeToBigInt(v value.Value) (*big.Int, error) {
+	x, err := v.DecodeToDecimal()
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).Set(x.Num()), nil
+}
+
+// computeDecimal computes the operation exactly, the result is a decimal.
+func (op arithOp) computeDecimal(a, b value.Value) (value.Value, error) {
+	x, err := a.DecodeToDecimal()
+	if err != nil {
+		return value.Value{}, err
+	}
+	y, err := b.DecodeToDecimal()
+	if err != nil {
+		return value.Value{}, err
+	}
+
+	if (op.Token == scanner.DIV || op.Token == scanner.MOD) && y.Sign() == 0 {
+		return value.Value{}, errors.New("division by zero")
+	}
+
+	z := new(big.Rat)
+	switch op.Token {
+	case scanner.ADD:
+		z.Add(x, y)
+	case scanner.SUB:
+		z.Sub(x, y)
+	case scanner.MUL:
+		z.Mul(x, y)
+	case scanner.DIV:
+		z.Quo(x, y)
+	case scanner.MOD:
+		// x - y * trunc(x / y)
+		q := new(big.Rat).Quo(x, y)
+		t := new(big.Int).Quo(q.Num(), q.Denom())
+		z.Sub(x, q.Mul(y, new(big.Rat).SetInt(t)))
+	}
+
+	return value.NewDecimal(z), nil
+}
+
+// A quantifier is ANY or ALL followed by an expression that evaluates to an array.
+// It can only be used as the right operand of a comparison operator.
+type quantifier struct {
+	e   expr
+	all bool
+}
+
+func (q quantifier) name() string {
+	if q.all {
+		return "ALL"
+	}
+
+	return "ANY"
+}
+
+// Eval returns an error, quantifiers are evaluated by comparison operators.
+// It implements the Expr interface.
+func (q quantifier) Eval(evalStack) (evalValue, error) {
+	return nullLitteral, fmt.Errorf("%s must be used on the right of a comparison operator", q.name())
+}
+
+func (q quantifier) String() string {
+	return q.name() + "(" + q.e.String() + ")"
+}
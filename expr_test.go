@@ -1,12 +1,35 @@
 package genji
 
 import (
+	"math"
 	"testing"
+	"time"
 
+	"github.com/asdine/genji/value"
 	"github.com/stretchr/testify/require"
 )
 
+// mustDecimalValue calls decimalValue and panics if v is not a valid decimal.
+func mustDecimalValue(v string) litteralValue {
+	l, err := decimalValue(v)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+// mustUUIDValue calls uuidValue and panics if v is not a valid UUID.
+func mustUUIDValue(v string) litteralValue {
+	l, err := uuidValue(v)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
 func TestOperator(t *testing.T) {
+	ts := time.Date(2019, 11, 3, 10, 0, 0, 0, time.UTC)
+
 	tests := []struct {
 		name  string
 		fn    func(a, b expr) expr
@@ -52,6 +75,65 @@ func TestOperator(t *testing.T) {
 		{"LTE / Numbers / Greater", lte, int32Value(11), uint64Value(10), falseLitteral, false},
 		{"LTE / String Bytes", lte, stringValue("foo1"), bytesValue([]byte("foo2")), trueLitteral, false},
 		{"LTE / Bytes String", lte, bytesValue([]byte("foo1")), stringValue("foo2"), trueLitteral, false},
+		{"EQ / Null", eq, nullValue(), nullValue(), nullLitteral, false},
+		{"GT / Null", gt, int32Value(10), nullValue(), nullLitteral, false},
+		{"EQ / Lists / Null", eq, litteralExprList{nullValue()}, litteralExprList{int32Value(10)}, falseLitteral, false},
+		{"IS / Null", is, nullValue(), nullValue(), trueLitteral, false},
+		{"IS / Null / Value", is, int32Value(10), nullValue(), falseLitteral, false},
+		{"IS / Numbers", is, int32Value(10), float64Value(10), trueLitteral, false},
+		{"IS NOT / Null", isNot, nullValue(), nullValue(), falseLitteral, false},
+		{"IS NOT / Null / Value", isNot, int32Value(10), nullValue(), trueLitteral, false},
+		{"AND / Null / True", and, nullValue(), boolValue(true), nullLitteral, false},
+		{"AND / Null / False", and, nullValue(), boolValue(false), falseLitteral, false},
+		{"OR / Null / True", or, nullValue(), boolValue(true), trueLitteral, false},
+		{"OR / Null / False", or, boolValue(false), nullValue(), nullLitteral, false},
+		{"GT / Timestamps", gt, timestampValue(ts.Add(time.Nanosecond)), timestampValue(ts), trueLitteral, false},
+		{"LT / Durations", lt, durationValue(-time.Hour), durationValue(time.Second), trueLitteral, false},
+		{"ADD / Integers", add, int8Value(10), int64Value(5), newSingleEvalValue(value.NewInt64(15)), false},
+		{"ADD / Numbers", add, int8Value(10), float64Value(0.5), newSingleEvalValue(value.NewFloat64(10.5)), false},
+		{"ADD / Null", add, int8Value(10), nullValue(), nullLitteral, false},
+		{"ADD / Strings", add, stringValue("a"), stringValue("b"), nullLitteral, true},
+		{"DIV / Integers", div, int64Value(7), int64Value(2), newSingleEvalValue(value.NewInt64(3)), false},
+		{"DIV / Zero", div, int64Value(7), int64Value(0), nullLitteral, true},
+		{"MOD / Integers", mod, int64Value(7), int64Value(2), newSingleEvalValue(value.NewInt64(1)), false},
+		{"SUB / Timestamp Duration", sub, timestampValue(ts), durationValue(time.Hour),
+			newSingleEvalValue(value.NewTimestamp(ts.Add(-time.Hour))), false},
+		{"ADD / Duration Timestamp", add, durationValue(time.Hour), timestampValue(ts),
+			newSingleEvalValue(value.NewTimestamp(ts.Add(time.Hour))), false},
+		{"SUB / Timestamps", sub, timestampValue(ts), timestampValue(ts.Add(time.Minute)),
+			newSingleEvalValue(value.NewDuration(-time.Minute)), false},
+		{"SUB / Duration Timestamp", sub, durationValue(time.Hour), timestampValue(ts), nullLitteral, true},
+		{"MUL / Duration Integer", mul, durationValue(time.Hour), int64Value(2), newSingleEvalValue(value.NewDuration(2 * time.Hour)), false},
+		{"MUL / Integer Duration", mul, int64Value(2), durationValue(time.Hour), newSingleEvalValue(value.NewDuration(2 * time.Hour)), false},
+		{"EQ / Decimal Integer", eq, mustDecimalValue("10"), int64Value(10), trueLitteral, false},
+		{"GT / Decimal Uint64", gt, mustDecimalValue("18446744073709551615.5"), uint64Value(math.MaxUint64), trueLitteral, false},
+		{"LT / Int64 Decimal", lt, int64Value(math.MaxInt64 - 1), mustDecimalValue("9223372036854775807"), trueLitteral, false},
+		{"EQ / Decimal Float", eq, mustDecimalValue("0.1"), float64Value(0.1), trueLitteral, false},
+		{"EQ / Int8 Int64", eq, int8Value(10), int64Value(10), trueLitteral, false},
+		{"GT / Uint64 Int64", gt, uint64Value(math.MaxUint64), int64Value(math.MaxInt64), trueLitteral, false},
+		{"LT / Float64 Int64", lt, float64Value(1 << 53), int64Value(1<<53 + 1), trueLitteral, false},
+		{"EQ / Float32 Float64", eq, float32Value(0.1), float64Value(0.1), trueLitteral, false},
+		{"GT / NaN", gt, float64Value(math.NaN()), int64Value(1), falseLitteral, false},
+		{"LT / Int64 Infinity", lt, int64Value(math.MaxInt64), float64Value(math.Inf(1)), trueLitteral, false},
+		{"EQ / String Integer", eq, stringValue("10"), int64Value(10), falseLitteral, false},
+		{"GT / String Integer", gt, stringValue("10"), int64Value(1), falseLitteral, false},
+		{"LT / Bool Timestamp", lt, boolValue(true), timestampValue(ts), falseLitteral, false},
+		{"ADD / Decimals", add, mustDecimalValue("0.1"), mustDecimalValue("0.2"), newSingleEvalValue(mustDecimalValue("0.3").Value), false},
+		{"MUL / Decimal Integer", mul, mustDecimalValue("19.99"), int64Value(3), newSingleEvalValue(mustDecimalValue("59.97").Value), false},
+		{"DIV / Decimals", div, mustDecimalValue("1"), mustDecimalValue("3"), newSingleEvalValue(mustDecimalValue("1/3").Value), false},
+		{"DIV / Decimal Zero", div, mustDecimalValue("1"), int64Value(0), nullLitteral, true},
+		{"MOD / Decimals", mod, mustDecimalValue("-7.5"), mustDecimalValue("2"), newSingleEvalValue(mustDecimalValue("-1.5").Value), false},
+		{"DIV / Duration Integer", div, durationValue(time.Hour), int64Value(2), newSingleEvalValue(value.NewDuration(30 * time.Minute)), false},
+		{"ADD / Int64 Promoted", add, int64Value(math.MaxInt64), int64Value(1), newSingleEvalValue(value.NewUint64(math.MaxInt64 + 1)), false},
+		{"SUB / Uint64 Int64", sub, uint64Value(math.MaxUint64), int64Value(1), newSingleEvalValue(value.NewUint64(math.MaxUint64 - 1)), false},
+		{"SUB / Uint64 Negative", sub, uint64Value(1), uint64Value(2), newSingleEvalValue(value.NewInt64(-1)), false},
+		{"ADD / Uint64 Overflow", add, uint64Value(math.MaxUint64), int64Value(1), nullLitteral, true},
+		{"SUB / Int64 Overflow", sub, int64Value(math.MinInt64), int64Value(1), nullLitteral, true},
+		{"MUL / Int64 Overflow", mul, int64Value(math.MaxInt64), int64Value(3), nullLitteral, true},
+		{"ADD / Uint64 Float64", add, uint64Value(math.MaxUint64), float64Value(0), newSingleEvalValue(value.NewFloat64(math.MaxUint64)), false},
+		{"ADD / Duration Overflow", add, durationValue(math.MaxInt64), durationValue(1), nullLitteral, true},
+		{"SUB / Duration Overflow", sub, durationValue(math.MinInt64), durationValue(1), nullLitteral, true},
+		{"MUL / Duration Overflow", mul, durationValue(time.Hour), int64Value(math.MaxInt64), nullLitteral, true},
 	}
 
 	for _, test := range tests {
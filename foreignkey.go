@@ -0,0 +1,353 @@
+package genji
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/asdine/genji/index"
+	"github.com/asdine/genji/record"
+	"github.com/asdine/genji/value"
+)
+
+// A ForeignKey makes a field reference a record of another table.
+// Every value of the field must match a record of the referenced table.
+type ForeignKey struct {
+	// Name of the referenced table.
+	Table string
+	// Name of the referenced field. It must be covered by a unique index.
+	// If empty, values of the field are compared with the primary keys of the referenced table.
+	Field string
+	// Action to perform on referencing records when a referenced record is deleted.
+	OnDelete ForeignKeyAction
+}
+
+// A ForeignKeyAction determines what happens to the records that reference a deleted record.
+type ForeignKeyAction uint8
+
+const (
+	// ForeignKeyRestrict forbids deleting a record while it is referenced. It is the default action.
+	ForeignKeyRestrict ForeignKeyAction = iota
+	// ForeignKeyCascade deletes the referencing records as well.
+	ForeignKeyCascade
+	// ForeignKeySetNull sets the referencing field of the referencing records to null.
+	ForeignKeySetNull
+)
+
+// String returns the SQL representation of the action.
+func (a ForeignKeyAction) String() string {
+	switch a {
+	case ForeignKeyCascade:
+		return "CASCADE"
+	case ForeignKeySetNull:
+		return "SET NULL"
+	}
+
+	return "RESTRICT"
+}
+
+// String returns the SQL representation of the foreign key.
+func (fk *ForeignKey) String() string {
+	var buf strings.Builder
+
+	buf.WriteString("REFERENCES ")
+	buf.WriteString(fieldNameString(fk.Table))
+	if fk.Field != "" {
+		buf.WriteString("(" + fieldNameString(fk.Field) + ")")
+	}
+	buf.WriteString(" ON DELETE ")
+	buf.WriteString(fk.OnDelete.String())
+
+	return buf.String()
+}
+
+// validateForeignKeys ensures the tables and fields referenced by cfg exist and can be used
+// to lookup records.
+func (tx Tx) validateForeignKeys(tableName string, cfg *TableConfig) error {
+	for _, fc := range cfg.FieldConstraints {
+		fk := fc.ForeignKey
+		if fk == nil || fk.Field == "" {
+			continue
+		}
+
+		if fk.Table == tableName {
+			if rfc := cfg.GetFieldConstraint(fk.Field); rfc == nil || !rfc.Unique {
+				return fmt.Errorf("field %q references field %q which is not unique", fc.Name, fk.Field)
+			}
+			continue
+		}
+
+		t, err := tx.GetTable(fk.Table)
+		if err != nil {
+			return fmt.Errorf("field %q references table %q: %v", fc.Name, fk.Table, err)
+		}
+
+		if idx, ok := t.indexes[fk.Field]; !ok || !idx.Unique {
+			return fmt.Errorf("field %q references field %q of table %q which doesn't have a unique index", fc.Name, fk.Field, fk.Table)
+		}
+	}
+
+	return nil
+}
+
+// checkForeignKeys ensures every reference made by r points to an existing record.
+func (t Table) checkForeignKeys(r record.Record) error {
+	if t.schema == nil {
+		return nil
+	}
+
+	for _, fc := range t.schema.cfg.FieldConstraints {
+		if fc.ForeignKey == nil {
+			continue
+		}
+
+		// null values don't reference anything
+		f, err := r.GetField(fc.Name)
+		if err != nil || f.Type == value.Null {
+			continue
+		}
+
+		ok, err := t.tx.hasReferencedRecord(fc.ForeignKey, f.Value)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return &ConstraintViolationError{Table: t.name, Field: fc.Name, Constraint: fc.ForeignKey.String()}
+		}
+	}
+
+	return nil
+}
+
+// hasReferencedRecord returns true if the table referenced by fk contains a record matching the value.
+func (tx *Tx) hasReferencedRecord(fk *ForeignKey, v value.Value) (bool, error) {
+	t, err := tx.GetTable(fk.Table)
+	if err != nil {
+		return false, err
+	}
+
+	if fk.Field == "" {
+		_, err = t.GetRecord(v.Data)
+		if err == ErrRecordNotFound {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	idx, ok := t.indexes[fk.Field]
+	if !ok {
+		return false, fmt.Errorf("missing unique index on field %q of table %q", fk.Field, fk.Table)
+	}
+
+	pivot, err := index.EncodeValue(v)
+	if err != nil {
+		return false, err
+	}
+
+	var found bool
+	err = idx.AscendGreaterOrEqual(pivot, func(value, key []byte) error {
+		found = bytes.Equal(value, pivot)
+		return errStop
+	})
+	if err != nil && err != errStop {
+		return false, err
+	}
+
+	return found, nil
+}
+
+// A reference is a field of a table that references another table.
+type reference struct {
+	tableName string
+	fieldName string
+	fieldType value.Type
+	fk        *ForeignKey
+}
+
+// references returns the list of fields referencing t, from any table.
+func (t Table) references() ([]reference, error) {
+	if strings.HasPrefix(t.name, systemTablePrefix) {
+		return nil, nil
+	}
+
+	ct, err := t.tx.GetTable(tableConfigTable)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []reference
+	err = ct.Iterate(func(r record.Record) error {
+		var ti tableInfo
+		err := ti.ScanRecord(r)
+		if err != nil {
+			return err
+		}
+
+		for _, fc := range ti.Config.FieldConstraints {
+			if fc.ForeignKey != nil && fc.ForeignKey.Table == t.name {
+				refs = append(refs, reference{tableName: ti.TableName, fieldName: fc.Name, fieldType: fc.Type, fk: fc.ForeignKey})
+			}
+		}
+		return nil
+	})
+
+	return refs, err
+}
+
+// referencedValue returns the value of r referenced by ref.
+// Keys are not typed: if ref references the primary key, the key is returned with
+// the declared type of the referencing field, which is zero if the field doesn't declare any.
+// It returns false if r doesn't contain the referenced field or if it is null.
+func (ref reference) referencedValue(key []byte, r record.Record) (value.Value, bool) {
+	if ref.fk.Field == "" {
+		return value.Value{Type: ref.fieldType, Data: key}, true
+	}
+
+	f, err := r.GetField(ref.fk.Field)
+	if err != nil || f.Type == value.Null {
+		return value.Value{}, false
+	}
+
+	return f.Value, true
+}
+
+// referencingKeys returns the keys of the records whose referencing field matches v.
+// The index of the referencing field is only used if the type of v is known,
+// otherwise fields are compared byte by byte.
+func (ref reference) referencingKeys(tx *Tx, v value.Value) ([][]byte, error) {
+	t, err := tx.GetTable(ref.tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys [][]byte
+
+	if idx, ok := t.indexes[ref.fieldName]; ok && v.Type != 0 {
+		pivot, err := index.EncodeValue(v)
+		if err != nil {
+			return nil, err
+		}
+
+		err = idx.AscendGreaterOrEqual(pivot, func(value, key []byte) error {
+			if !bytes.Equal(value, pivot) {
+				return errStop
+			}
+
+			keys = append(keys, append([]byte{}, key...))
+			return nil
+		})
+		if err == errStop {
+			err = nil
+		}
+		return keys, err
+	}
+
+	err = t.Iterate(func(r record.Record) error {
+		f, err := r.GetField(ref.fieldName)
+		if err != nil || f.Type == value.Null || !bytes.Equal(f.Data, v.Data) {
+			return nil
+		}
+
+		keys = append(keys, append([]byte{}, r.(record.Keyer).Key()...))
+		return nil
+	})
+
+	return keys, err
+}
+
+// restrictReferences returns an error if any of the given references prevents
+// the record from being deleted or from having its referenced fields modified.
+// If newRecord is nil, the record is being deleted.
+func (t Table) restrictReferences(refs []reference, key []byte, old, newRecord record.Record) error {
+	for _, ref := range refs {
+		v, ok := ref.referencedValue(key, old)
+		if !ok {
+			continue
+		}
+
+		if newRecord != nil {
+			// replacing a record doesn't change its key
+			if nv, ok := ref.referencedValue(key, newRecord); ok && bytes.Equal(nv.Data, v.Data) {
+				continue
+			}
+		} else if ref.fk.OnDelete != ForeignKeyRestrict {
+			continue
+		}
+
+		keys, err := ref.referencingKeys(t.tx, v)
+		if err != nil {
+			return err
+		}
+
+		// a record referencing itself doesn't prevent its own deletion
+		if newRecord == nil && ref.tableName == t.name && len(keys) == 1 && bytes.Equal(keys[0], key) {
+			continue
+		}
+
+		if len(keys) > 0 {
+			return &ConstraintViolationError{Table: ref.tableName, Field: ref.fieldName, Constraint: ref.fk.String()}
+		}
+	}
+
+	return nil
+}
+
+// applyDeleteActions cascades the deletion of a record to the records referencing it.
+func (t Table) applyDeleteActions(refs []reference, key []byte, old record.Record) error {
+	for _, ref := range refs {
+		if ref.fk.OnDelete == ForeignKeyRestrict {
+			continue
+		}
+
+		v, ok := ref.referencedValue(key, old)
+		if !ok {
+			continue
+		}
+
+		keys, err := ref.referencingKeys(t.tx, v)
+		if err != nil {
+			return err
+		}
+
+		rt, err := t.tx.GetTable(ref.tableName)
+		if err != nil {
+			return err
+		}
+
+		for _, k := range keys {
+			switch ref.fk.OnDelete {
+			case ForeignKeyCascade:
+				err = rt.Delete(k)
+				// the record might have already been deleted by another cascade
+				if err == ErrRecordNotFound {
+					err = nil
+				}
+			case ForeignKeySetNull:
+				err = rt.setNull(k, ref.fieldName)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// setNull sets a field of the record associated with the key to null.
+func (t Table) setNull(key []byte, fieldName string) error {
+	r, err := t.GetRecord(key)
+	if err != nil {
+		return err
+	}
+
+	var fb record.FieldBuffer
+	err = fb.ScanRecord(r)
+	if err != nil {
+		return err
+	}
+
+	fb.Set(record.NewNullField(fieldName))
+
+	return t.Replace(key, &fb)
+}
@@ -0,0 +1,153 @@
+package genji
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/asdine/genji/engine/memory"
+	"github.com/asdine/genji/record"
+	"github.com/asdine/genji/record/recordutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForeignKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		create   string
+		query    string
+		fails    bool
+		expected string
+	}{
+		{"Insert / Unknown key", "CREATE TABLE parent (id INT64 UNIQUE); CREATE TABLE test (a REFERENCES parent(id))", "INSERT INTO test (a) VALUES (1)", true, ""},
+		{"Insert / Ok", "CREATE TABLE parent (id INT64 UNIQUE); CREATE TABLE test (a REFERENCES parent(id))", "INSERT INTO parent (id) VALUES (1); INSERT INTO test (a, b) VALUES (1, 1)", false, "a(Int64): 1\nb(Int64): 1\n"},
+		{"Insert / Missing field", "CREATE TABLE parent (id INT64 UNIQUE); CREATE TABLE test (a REFERENCES parent(id))", "INSERT INTO test (b) VALUES (1)", false, "b(Int64): 1\n"},
+		{"Insert / Primary key", "CREATE TABLE parent AUTOINCREMENT; CREATE TABLE test (a REFERENCES parent)", "INSERT INTO parent (id) VALUES (1); INSERT INTO test (a) VALUES (1)", false, "a(Int64): 1\n"},
+		{"Update / Unknown key", "CREATE TABLE parent (id INT64 UNIQUE); CREATE TABLE test (a REFERENCES parent(id))", "INSERT INTO parent (id) VALUES (1); INSERT INTO test (a) VALUES (1); UPDATE test SET a = 2", true, ""},
+		{"Update / Referenced field", "CREATE TABLE parent (id INT64 UNIQUE); CREATE TABLE test (a REFERENCES parent(id))", "INSERT INTO parent (id) VALUES (1); INSERT INTO test (a) VALUES (1); UPDATE parent SET id = 2", true, ""},
+		{"Delete / Restrict", "CREATE TABLE parent (id INT64 UNIQUE); CREATE TABLE test (a REFERENCES parent(id))", "INSERT INTO parent (id) VALUES (1); INSERT INTO test (a) VALUES (1); DELETE FROM parent", true, ""},
+		{"Delete / Not referenced", "CREATE TABLE parent (id INT64 UNIQUE); CREATE TABLE test (a REFERENCES parent(id))", "INSERT INTO parent (id) VALUES (1), (2); INSERT INTO test (a) VALUES (1); DELETE FROM parent WHERE id = 2", false, "a(Int64): 1\n"},
+		{"Delete / Cascade", "CREATE TABLE parent (id INT64 UNIQUE); CREATE TABLE test (a REFERENCES parent(id) ON DELETE CASCADE)", "INSERT INTO parent (id) VALUES (1), (2); INSERT INTO test (a) VALUES (1), (2), (1); DELETE FROM parent WHERE id = 1", false, "a(Int64): 2\n"},
+		{"Delete / Set null", "CREATE TABLE parent (id INT64 UNIQUE); CREATE TABLE test (a REFERENCES parent(id) ON DELETE SET NULL, b)", "INSERT INTO parent (id) VALUES (1); INSERT INTO test (a, b) VALUES (1, 1); DELETE FROM parent", false, "a(Null): <nil>\nb(Int64): 1\n"},
+		{"Insert / Null reference", "CREATE TABLE parent; CREATE TABLE test (a REFERENCES parent)", "INSERT INTO test (a) VALUES (NULL)", false, "a(Null): <nil>\n"},
+		{"Self reference / Cascade", "CREATE TABLE test (id INT64 UNIQUE, parent REFERENCES test(id) ON DELETE CASCADE)", "INSERT INTO test (id) VALUES (1); INSERT INTO test (id, parent) VALUES (2, 1), (3, 2); DELETE FROM test WHERE id = 1", false, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := New(memory.NewEngine())
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(test.create)
+			require.NoError(t, err)
+
+			err = db.Exec(test.query)
+			if test.fails {
+				require.Error(t, err)
+				require.IsType(t, &ConstraintViolationError{}, err)
+				return
+			}
+			require.NoError(t, err)
+
+			res, err := db.Query("SELECT * FROM test")
+			require.NoError(t, err)
+			defer res.Close()
+
+			var buf bytes.Buffer
+			err = res.Iterate(func(r record.Record) error {
+				return recordutil.DumpRecord(&buf, r)
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestForeignKeysValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"Unknown table", "CREATE TABLE test (a REFERENCES parent(id))"},
+		{"Field not unique", "CREATE TABLE parent (id INT64); CREATE TABLE test (a REFERENCES parent(id))"},
+		{"Self reference not unique", "CREATE TABLE test (id, a REFERENCES test(id))"},
+		{"Set null on not null field", "CREATE TABLE parent; CREATE TABLE test (a NOT NULL REFERENCES parent ON DELETE SET NULL)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := New(memory.NewEngine())
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(test.query)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestForeignKeysTruncate(t *testing.T) {
+	tests := []struct {
+		name     string
+		create   string
+		fails    bool
+		expected string
+	}{
+		{"Restrict", "CREATE TABLE parent (id INT64 UNIQUE); CREATE TABLE test (a REFERENCES parent(id))", true, "a(Int64): 1\n"},
+		{"Cascade", "CREATE TABLE parent (id INT64 UNIQUE); CREATE TABLE test (a REFERENCES parent(id) ON DELETE CASCADE)", false, ""},
+		{"Set null", "CREATE TABLE parent (id INT64 UNIQUE); CREATE TABLE test (a REFERENCES parent(id) ON DELETE SET NULL)", false, "a(Null): <nil>\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := New(memory.NewEngine())
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(test.create)
+			require.NoError(t, err)
+			err = db.Exec("INSERT INTO parent (id) VALUES (1), (2); INSERT INTO test (a) VALUES (1)")
+			require.NoError(t, err)
+
+			err = db.UpdateTable("parent", func(_ *Tx, tb *Table) error {
+				return tb.Truncate()
+			})
+			if test.fails {
+				require.IsType(t, &ConstraintViolationError{}, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			res, err := db.Query("SELECT * FROM test")
+			require.NoError(t, err)
+			defer res.Close()
+
+			var buf bytes.Buffer
+			err = res.Iterate(func(r record.Record) error {
+				return recordutil.DumpRecord(&buf, r)
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.expected, buf.String())
+		})
+	}
+
+	t.Run("Self reference", func(t *testing.T) {
+		db, err := New(memory.NewEngine())
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test (id INT64 UNIQUE, parent REFERENCES test(id) ON DELETE CASCADE); CREATE INDEX idx_test_parent ON test (parent)")
+		require.NoError(t, err)
+		err = db.Exec("INSERT INTO test (id) VALUES (1); INSERT INTO test (id, parent) VALUES (2, 1), (3, 2)")
+		require.NoError(t, err)
+
+		err = db.UpdateTable("test", func(_ *Tx, tb *Table) error {
+			return tb.Truncate()
+		})
+		require.NoError(t, err)
+
+		// the indexes were updated as well
+		err = db.Exec("INSERT INTO test (id) VALUES (1)")
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,89 @@
+package index
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/asdine/genji/value"
+)
+
+// type tags prefixing every encoded value, so that values of the same
+// family are stored next to each other in the index.
+const (
+	boolTag byte = iota + 1
+	numberTag
+	stringTag
+	timestampTag
+	durationTag
+	documentTag
+	arrayTag
+	uuidTag
+)
+
+// encoding of the numbers that can't be represented by a decimal,
+// placed around the sign byte of value.EncodeDecimal.
+const (
+	negativeInfinity byte = 0x00
+	positiveInfinity byte = 0xF0
+	notANumber       byte = 0xF1
+)
+
+// EncodeValue returns the canonical representation of v used as index value.
+// The encoded value is prefixed by a tag that depends on the family of the type,
+// rather than on the type itself: all numbers share the same tag and are encoded
+// as decimals, so that an int8, an uint64 and a float64 can be compared
+// with one another by comparing their encoded form. Strings and bytes also share the same tag.
+// Null values can't be indexed.
+func EncodeValue(v value.Value) ([]byte, error) {
+	switch {
+	case v.Type == value.Bool:
+		return tag(boolTag, v.Data), nil
+	case value.IsNumber(v.Type) || v.Type == value.Decimal:
+		return encodeNumber(v)
+	case v.Type == value.String || v.Type == value.Bytes:
+		return tag(stringTag, v.Data), nil
+	case v.Type == value.Timestamp:
+		return tag(timestampTag, v.Data), nil
+	case v.Type == value.Duration:
+		return tag(durationTag, v.Data), nil
+	case v.Type == value.Document:
+		return tag(documentTag, v.Data), nil
+	case v.Type == value.Array:
+		return tag(arrayTag, v.Data), nil
+	case v.Type == value.UUID:
+		return tag(uuidTag, v.Data), nil
+	}
+
+	return nil, fmt.Errorf("cannot index value of type %s", v.Type)
+}
+
+func encodeNumber(v value.Value) ([]byte, error) {
+	if value.IsFloat(v.Type) {
+		f, err := v.DecodeToFloat64()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case math.IsNaN(f):
+			return []byte{numberTag, notANumber}, nil
+		case math.IsInf(f, 1):
+			return []byte{numberTag, positiveInfinity}, nil
+		case math.IsInf(f, -1):
+			return []byte{numberTag, negativeInfinity}, nil
+		}
+	}
+
+	x, err := v.DecodeToDecimal()
+	if err != nil {
+		return nil, err
+	}
+
+	return tag(numberTag, value.EncodeDecimal(x)), nil
+}
+
+func tag(t byte, data []byte) []byte {
+	buf := make([]byte, 0, len(data)+1)
+	buf = append(buf, t)
+	return append(buf, data...)
+}
@@ -1,7 +1,7 @@
 package index
 
 import (
-	"bytes"
+	"encoding/binary"
 	"errors"
 
 	"github.com/asdine/genji/engine"
@@ -11,9 +11,25 @@ const (
 	separator byte = 0x1E
 )
 
+// FormatVersion is the version of the format of the indexes.
+// Unlike records, indexes can't be read if they were written using an older version
+// of the format: they must be rebuilt.
+//
+// Versions:
+//
+//	0: values are encoded according to their type and the entries of list indexes
+//	   are made of the value followed by the separator and the key
+//	1: values use the encoding of EncodeValue and the entries of list indexes
+//	   are suffixed with the length of the key
+const FormatVersion uint8 = 1
+
 var (
 	// ErrDuplicate is returned when a value is already associated with a key
 	ErrDuplicate = errors.New("duplicate")
+
+	// ErrInvalidEntry is returned when an entry of an index can't be decoded,
+	// e.g. if the index was written using an older version of the format.
+	ErrInvalidEntry = errors.New("invalid index entry")
 )
 
 // An Index associates encoded values with keys.
@@ -67,27 +83,20 @@ func (i *listIndex) Set(value, key []byte) error {
 		return errors.New("value cannot be nil")
 	}
 
-	buf := make([]byte, 0, len(value)+len(key)+1)
-	buf = append(buf, value...)
-	buf = append(buf, separator)
-	buf = append(buf, key...)
-
-	return i.store.Put(buf, nil)
+	return i.store.Put(encodeListIndexKey(value, key), nil)
 }
 
 func (i *listIndex) Delete(value, key []byte) error {
-	buf := make([]byte, 0, len(value)+len(key)+1)
-	buf = append(buf, value...)
-	buf = append(buf, separator)
-	buf = append(buf, key...)
-
-	return i.store.Delete(buf)
+	return i.store.Delete(encodeListIndexKey(value, key))
 }
 
 func (i *listIndex) AscendGreaterOrEqual(pivot []byte, fn func(value []byte, key []byte) error) error {
 	return i.store.AscendGreaterOrEqual(pivot, func(k, v []byte) error {
-		idx := bytes.LastIndexByte(k, separator)
-		return fn(k[:idx], k[idx+1:])
+		value, key, err := decodeListIndexKey(k)
+		if err != nil {
+			return err
+		}
+		return fn(value, key)
 	})
 }
 
@@ -97,11 +106,44 @@ func (i *listIndex) DescendLessOrEqual(pivot []byte, fn func(k, v []byte) error)
 		pivot = append(pivot, separator, 0xFF)
 	}
 	return i.store.DescendLessOrEqual(pivot, func(k, v []byte) error {
-		idx := bytes.LastIndexByte(k, separator)
-		return fn(k[:idx], k[idx+1:])
+		value, key, err := decodeListIndexKey(k)
+		if err != nil {
+			return err
+		}
+		return fn(value, key)
 	})
 }
 
+// encodeListIndexKey builds the key stored in the list index for the value and key pair.
+// The key is suffixed with its length so that it can be extracted even if it contains the separator.
+func encodeListIndexKey(value, key []byte) []byte {
+	buf := make([]byte, 0, len(value)+len(key)+5)
+	buf = append(buf, value...)
+	buf = append(buf, separator)
+	buf = append(buf, key...)
+
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(key)))
+	return append(buf, size[:]...)
+}
+
+// decodeListIndexKey extracts the value and the key from an entry of a list index.
+// It returns ErrInvalidEntry if k wasn't built by encodeListIndexKey.
+func decodeListIndexKey(k []byte) (value, key []byte, err error) {
+	n := len(k) - 4
+	if n < 2 {
+		return nil, nil, ErrInvalidEntry
+	}
+
+	// the value can't be empty and must be followed by the separator
+	size := binary.BigEndian.Uint32(k[n:])
+	if uint64(size) > uint64(n-2) || k[n-int(size)-1] != separator {
+		return nil, nil, ErrInvalidEntry
+	}
+
+	return k[:n-int(size)-1], k[n-int(size) : n], nil
+}
+
 // uniqueIndex is an implementation that associates a value with a exactly one key.
 type uniqueIndex struct {
 	store engine.Store
@@ -2,12 +2,15 @@
 package index_test
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/asdine/genji/engine/memory"
 	"github.com/asdine/genji/index"
+	"github.com/asdine/genji/value"
 	"github.com/stretchr/testify/require"
 )
 
@@ -191,10 +194,55 @@ func TestIndexAscendGreaterThan(t *testing.T) {
 			require.NoError(t, err)
 			require.Equal(t, 4, count)
 		})
+
+		t.Run(text+"Binary keys should be returned untouched", func(t *testing.T) {
+			idx, cleanup := getIndex(t, index.Options{Unique: unique})
+			defer cleanup()
+
+			key := []byte{0x80, 0x1E, 0x1F, 0x1E}
+			require.NoError(t, idx.Set([]byte("value"), key))
+
+			var count int
+			err := idx.AscendGreaterOrEqual(nil, func(v, rid []byte) error {
+				require.Equal(t, []byte("value"), v)
+				require.Equal(t, key, rid)
+				count++
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, 1, count)
+		})
 	}
 
 }
 
+func TestIndexInvalidEntry(t *testing.T) {
+	ng := memory.NewEngine()
+	tx, err := ng.Begin(true)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	err = tx.CreateStore("test")
+	require.NoError(t, err)
+	st, err := tx.Store("test")
+	require.NoError(t, err)
+
+	// entries written by the version 0 of the format aren't suffixed with the length of the key
+	err = st.Put([]byte("value\x1ekey"), nil)
+	require.NoError(t, err)
+
+	idx := index.New(st, index.Options{})
+	err = idx.AscendGreaterOrEqual(nil, func(value []byte, key []byte) error {
+		return errors.New("should not iterate")
+	})
+	require.Equal(t, index.ErrInvalidEntry, err)
+
+	err = idx.DescendLessOrEqual(nil, func(value []byte, key []byte) error {
+		return errors.New("should not iterate")
+	})
+	require.Equal(t, index.ErrInvalidEntry, err)
+}
+
 func TestIndexDescendLessOrEqual(t *testing.T) {
 	for _, unique := range []bool{true, false} {
 		text := fmt.Sprintf("Unique: %v, ", unique)
@@ -258,6 +306,70 @@ func TestIndexDescendLessOrEqual(t *testing.T) {
 	}
 }
 
+func TestEncodeValue(t *testing.T) {
+	mustDecimal := func(s string) value.Value {
+		v, err := value.ParseDecimal(s)
+		require.NoError(t, err)
+		return v
+	}
+
+	t.Run("Numbers are sorted together", func(t *testing.T) {
+		// sorted in increasing order
+		values := []value.Value{
+			value.NewFloat64(math.Inf(-1)),
+			value.NewInt64(math.MinInt64),
+			value.NewFloat32(-10.5),
+			value.NewInt8(-10),
+			mustDecimal("-0.001"),
+			value.NewUint8(0),
+			value.NewFloat64(0.5),
+			value.NewInt16(1),
+			mustDecimal("1.25"),
+			value.NewInt64(math.MaxInt64),
+			value.NewUint64(math.MaxUint64),
+			value.NewFloat64(1e300),
+			value.NewFloat64(math.Inf(1)),
+		}
+
+		var prev []byte
+		for i, v := range values {
+			enc, err := index.EncodeValue(v)
+			require.NoError(t, err)
+			if prev != nil {
+				require.Equal(t, -1, bytes.Compare(prev, enc), "values[%d] should be greater than values[%d]", i, i-1)
+			}
+			prev = enc
+		}
+	})
+
+	t.Run("Equal numbers have the same encoding", func(t *testing.T) {
+		for _, v := range []value.Value{value.NewInt8(10), value.NewUint64(10), value.NewFloat32(10), mustDecimal("10.00")} {
+			enc, err := index.EncodeValue(v)
+			require.NoError(t, err)
+			expected, err := index.EncodeValue(value.NewInt64(10))
+			require.NoError(t, err)
+			require.Equal(t, expected, enc)
+		}
+	})
+
+	t.Run("Strings and bytes", func(t *testing.T) {
+		a, err := index.EncodeValue(value.NewString("foo"))
+		require.NoError(t, err)
+		b, err := index.EncodeValue(value.NewBytes([]byte("foo")))
+		require.NoError(t, err)
+		require.Equal(t, a, b)
+
+		n, err := index.EncodeValue(value.NewInt64(math.MaxInt64))
+		require.NoError(t, err)
+		require.Equal(t, 1, bytes.Compare(a, n))
+	})
+
+	t.Run("Null", func(t *testing.T) {
+		_, err := index.EncodeValue(value.NewNull())
+		require.Error(t, err)
+	})
+}
+
 // BenchmarkIndexSet benchmarks the Set method with 1, 10, 1000 and 10000 successive insertions.
 func BenchmarkIndexSet(b *testing.B) {
 	for size := 10; size <= 10000; size *= 10 {
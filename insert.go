@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/asdine/genji/internal/scanner"
 	"github.com/asdine/genji/record"
@@ -50,7 +51,7 @@ func (p *parser) parseInsertStatement() (insertStmt, error) {
 	}
 
 	// If values was not found, parse RECORDS (r1, r2, r3)
-	records, found, err := p.parseRecords()
+	records, keys, found, err := p.parseRecords()
 	if err != nil {
 		return stmt, err
 	}
@@ -61,6 +62,7 @@ func (p *parser) parseInsertStatement() (insertStmt, error) {
 	}
 
 	stmt.records = records
+	stmt.keys = keys
 
 	return stmt, nil
 }
@@ -123,41 +125,60 @@ func (p *parser) parseValues() ([]litteralExprList, bool, error) {
 	return valuesList, true, nil
 }
 
-// parseValues parses the "RECORDS" clause of the query, if it exists.
-func (p *parser) parseRecords() ([]interface{}, bool, error) {
+// parseRecords parses the "RECORDS" clause of the query, if it exists.
+// Each record can be followed by a KEY clause, in which case the returned list of keys
+// has one element per record, nil for the records without a key.
+func (p *parser) parseRecords() ([]interface{}, []expr, bool, error) {
 	// Check if the RECORDS token exists.
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.RECORDS {
 		p.Unscan()
-		return nil, false, nil
+		return nil, nil, false, nil
 	}
 
 	var records []interface{}
+	var keys []expr
+	hasKeys := false
 
-	// Parse first (required) record.
-	// It can either be a param or kv list
-	rec, err := p.parseRecord()
-	if err != nil {
-		return nil, false, err
-	}
+	for {
+		// Parse a record, it can either be a param or kv list
+		rec, err := p.parseRecord()
+		if err != nil {
+			return nil, nil, false, err
+		}
 
-	records = append(records, rec)
+		records = append(records, rec)
 
-	// Parse remaining (optional) records.
-	for {
+		key, err := p.parseRecordKey()
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		keys = append(keys, key)
+		hasKeys = hasKeys || key != nil
+
+		// Parse remaining (optional) records.
 		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
 			p.Unscan()
 			break
 		}
+	}
 
-		rec, err := p.parseRecord()
-		if err != nil {
-			return nil, false, err
-		}
+	if !hasKeys {
+		keys = nil
+	}
 
-		records = append(records, rec)
+	return records, keys, true, nil
+}
+
+// parseRecordKey parses the key of a record in the form: KEY expr, if it exists.
+func (p *parser) parseRecordKey() (expr, error) {
+	// KEY isn't a keyword so that it can still be used as a field name
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "key") {
+		p.Unscan()
+		return nil, nil
 	}
 
-	return records, true, nil
+	return p.ParseExpr()
 }
 
 func (p *parser) parseRecord() (interface{}, error) {
@@ -287,6 +308,8 @@ type insertStmt struct {
 	fieldNames []string
 	values     litteralExprList
 	records    []interface{}
+	// keys of the records, nil if no key was provided.
+	keys []expr
 }
 
 // IsReadOnly always returns false. It implements the Statement interface.
@@ -333,13 +356,12 @@ type paramExtractor interface {
 
 func (stmt insertStmt) insertRecords(t *Table, stack evalStack) (Result, error) {
 	var res Result
-	var err error
 
 	if len(stmt.fieldNames) > 0 {
 		return res, errors.New("can't provide a field list with RECORDS clause")
 	}
 
-	for _, rec := range stmt.records {
+	for i, rec := range stmt.records {
 		var r record.Record
 
 		switch tp := rec.(type) {
@@ -373,17 +395,82 @@ func (stmt insertStmt) insertRecords(t *Table, stack evalStack) (Result, error)
 			r = &fb
 		}
 
-		res.lastInsertKey, err = t.Insert(r)
+		if stmt.keys != nil && stmt.keys[i] != nil {
+			k, isInteger, err := evalRecordKey(stmt.keys[i], stack)
+			if err != nil {
+				return res, err
+			}
+
+			r = keyedRecord{Record: r, key: k, integer: isInteger}
+		}
+
+		key, err := t.Insert(r)
 		if err != nil {
 			return res, err
 		}
 
+		res.setLastInsertKey(t, r, key)
+
 		res.rowsAffected++
 	}
 
 	return res, nil
 }
 
+// evalRecordKey evaluates the key of a record. Integers are encoded like the keys generated by
+// the SequenceKeyGenerator, UUIDs like the ones generated by the UUIDKeyGenerator, while strings
+// and bytes are used as is. It also reports whether the key is an integer.
+func evalRecordKey(e expr, stack evalStack) ([]byte, bool, error) {
+	v, err := e.Eval(stack)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if v.IsList {
+		return nil, false, errors.New("record key must be a single value")
+	}
+
+	switch tp := v.Value.Type; {
+	case tp == value.String, tp == value.Bytes, tp == value.UUID:
+		return v.Value.Data, false, nil
+	case value.IsInteger(tp):
+		n, err := v.Value.ConvertTo(value.Int64)
+		if err != nil {
+			return nil, false, err
+		}
+		return n.Data, true, nil
+	}
+
+	return nil, false, fmt.Errorf("record key must be an integer, a string, bytes or a UUID, got %s", v.Value.Type)
+}
+
+// keyedRecord is a record inserted with an explicit key.
+type keyedRecord struct {
+	record.Record
+
+	key     []byte
+	integer bool // the key is an encoded int64
+}
+
+// PrimaryKey returns the key of the record. It implements the PrimaryKeyer interface.
+func (k keyedRecord) PrimaryKey() ([]byte, error) {
+	return k.key, nil
+}
+
+// hasIntegerKey reports whether r is inserted in t with an integer key, either because
+// it was given an integer KEY or because its key is generated by the sequence of the table.
+// Keys returned by other PrimaryKeyer implementations can be of any type.
+func hasIntegerKey(t *Table, r record.Record) bool {
+	switch tr := r.(type) {
+	case keyedRecord:
+		return tr.integer
+	case PrimaryKeyer:
+		return false
+	}
+
+	return t.schema != nil && t.schema.cfg.KeyGenerator == SequenceKeyGenerator
+}
+
 func (stmt insertStmt) insertValues(t *Table, stack evalStack) (Result, error) {
 	var res Result
 
@@ -436,11 +523,13 @@ func (stmt insertStmt) insertValues(t *Table, stack evalStack) (Result, error) {
 			})
 		}
 
-		res.lastInsertKey, err = t.Insert(&fb)
+		key, err := t.Insert(&fb)
 		if err != nil {
 			return res, err
 		}
 
+		res.setLastInsertKey(t, &fb, key)
+
 		res.rowsAffected++
 	}
 
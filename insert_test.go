@@ -71,6 +71,21 @@ func TestParserInsert(t *testing.T) {
 				records:   []interface{}{namedParam("foo"), namedParam("bar")},
 			},
 			false},
+		{"Records / Key", "INSERT INTO test RECORDS (a: 1) KEY 'foo', ? key 10",
+			insertStmt{
+				tableName: "test",
+				records:   []interface{}{[]kvPair{kvPair{K: "a", V: int64Value(1)}}, positionalParam(1)},
+				keys:      []expr{stringValue("foo"), int64Value(10)},
+			},
+			false},
+		{"Records / Some keys", "INSERT INTO test RECORDS (a: 1), (a: 2) KEY 'foo'",
+			insertStmt{
+				tableName: "test",
+				records:   []interface{}{[]kvPair{kvPair{K: "a", V: int64Value(1)}}, []kvPair{kvPair{K: "a", V: int64Value(2)}}},
+				keys:      []expr{nil, stringValue("foo")},
+			},
+			false},
+		{"Records / Key without value", "INSERT INTO test RECORDS (a: 1) KEY", nil, true},
 	}
 
 	for _, test := range tests {
@@ -105,6 +120,9 @@ func TestInsertStmt(t *testing.T) {
 		{"Records / Positional Params", "INSERT INTO test RECORDS (a: ?, b: 2.3, c: ?)", false, "a,2.3,true\n", []interface{}{"a", true}},
 		{"Records / Named Params", "INSERT INTO test RECORDS (a: $a, b: 2.3, c: $c)", false, "1,2.3,true\n", []interface{}{sql.Named("c", true), sql.Named("a", 1)}},
 		{"Records / List ", "INSERT INTO test RECORDS (a: (1, 2, 3))", true, "", nil},
+		{"Records / Key", "INSERT INTO test RECORDS (a: 1) KEY 'foo', (a: 2) KEY 'bar'", false, "2\n1\n", nil},
+		{"Records / Duplicate key", "INSERT INTO test RECORDS (a: 1) KEY 'foo', (a: 2) KEY 'foo'", true, "", nil},
+		{"Records / Invalid key", "INSERT INTO test RECORDS (a: 1) KEY 1.5", true, "", nil},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,168 @@
+// Package archive implements the framing shared by the memory engine snapshots and the database backups:
+// a stream of raw bytes, uvarints and length-prefixed byte slices, followed by the CRC-32 checksum
+// of everything written before it.
+package archive
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+)
+
+// Writer writes to an underlying writer and updates the checksum of the archive.
+// The first error is kept and subsequent writes are ignored.
+type Writer struct {
+	w   *bufio.Writer
+	h   hash.Hash32
+	err error
+	buf [binary.MaxVarintLen64]byte
+}
+
+// NewWriter creates a Writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w), h: crc32.NewIEEE()}
+}
+
+// Write writes data as is.
+func (w *Writer) Write(data []byte) {
+	if w.err != nil {
+		return
+	}
+
+	w.h.Write(data)
+	_, w.err = w.w.Write(data)
+}
+
+// WriteUvarint writes x as a uvarint.
+func (w *Writer) WriteUvarint(x uint64) {
+	n := binary.PutUvarint(w.buf[:], x)
+	w.Write(w.buf[:n])
+}
+
+// WriteBytes writes data prefixed by its length.
+func (w *Writer) WriteBytes(data []byte) {
+	w.WriteUvarint(uint64(len(data)))
+	w.Write(data)
+}
+
+// Err returns the first error that occurred while writing.
+func (w *Writer) Err() error {
+	return w.err
+}
+
+// Close writes the checksum of the archive and flushes the underlying writer.
+// It returns the first error that occurred while writing.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], w.h.Sum32())
+	_, err := w.w.Write(sum[:])
+	if err != nil {
+		return err
+	}
+
+	return w.w.Flush()
+}
+
+// Reader reads from an underlying reader and updates the checksum of the archive.
+// The first error is kept and subsequent reads return nothing.
+type Reader struct {
+	r            *bufio.Reader
+	h            hash.Hash32
+	err          error
+	errCorrupted error
+}
+
+// NewReader creates a Reader that reads from r. Truncated or corrupted archives are reported using errCorrupted.
+func NewReader(r io.Reader, errCorrupted error) *Reader {
+	return &Reader{r: bufio.NewReader(r), h: crc32.NewIEEE(), errCorrupted: errCorrupted}
+}
+
+// Read reads exactly n bytes.
+func (r *Reader) Read(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+
+	data := make([]byte, n)
+	_, err := io.ReadFull(r.r, data)
+	if err != nil {
+		r.err = r.errCorrupted
+		return nil
+	}
+
+	r.h.Write(data)
+	return data
+}
+
+// ReadUvarint reads a uvarint.
+func (r *Reader) ReadUvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	var buf [binary.MaxVarintLen64]byte
+	for i := range buf {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			r.err = r.errCorrupted
+			return 0
+		}
+
+		buf[i] = b
+		if b < 0x80 {
+			r.h.Write(buf[:i+1])
+			x, _ := binary.Uvarint(buf[:i+1])
+			return x
+		}
+	}
+
+	r.err = r.errCorrupted
+	return 0
+}
+
+// ReadBytes reads a byte slice prefixed by its length.
+func (r *Reader) ReadBytes() []byte {
+	n := r.ReadUvarint()
+	if r.err != nil {
+		return nil
+	}
+
+	// the data is read progressively so that a corrupted size doesn't cause a huge allocation
+	data, err := ioutil.ReadAll(io.LimitReader(r.r, int64(n)))
+	if err != nil || uint64(len(data)) != n {
+		r.err = r.errCorrupted
+		return nil
+	}
+
+	r.h.Write(data)
+	return data
+}
+
+// Err returns the first error that occurred while reading.
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Close reads the checksum of the archive and verifies it matches the bytes read so far.
+func (r *Reader) Close() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	sum := r.h.Sum32()
+
+	var buf [4]byte
+	_, err := io.ReadFull(r.r, buf[:])
+	if err != nil || binary.BigEndian.Uint32(buf[:]) != sum {
+		return r.errCorrupted
+	}
+
+	return nil
+}
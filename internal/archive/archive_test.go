@@ -0,0 +1,61 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errTest = errors.New("corrupted")
+
+func TestArchive(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := NewWriter(&buf)
+	w.Write([]byte("head"))
+	w.WriteUvarint(300)
+	w.WriteBytes([]byte("data"))
+	w.WriteBytes(nil)
+	require.NoError(t, w.Close())
+
+	archive := buf.Bytes()
+
+	t.Run("OK", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(archive), errTest)
+		require.Equal(t, []byte("head"), r.Read(4))
+		require.EqualValues(t, 300, r.ReadUvarint())
+		require.Equal(t, []byte("data"), r.ReadBytes())
+		require.Empty(t, r.ReadBytes())
+		require.NoError(t, r.Close())
+	})
+
+	t.Run("Corrupted", func(t *testing.T) {
+		corrupted := append([]byte{}, archive...)
+		corrupted[1]++
+
+		r := NewReader(bytes.NewReader(corrupted), errTest)
+		r.Read(4)
+		r.ReadUvarint()
+		r.ReadBytes()
+		r.ReadBytes()
+		require.NoError(t, r.Err())
+		require.Equal(t, errTest, r.Close())
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(archive[:7]), errTest)
+		r.Read(4)
+		r.ReadUvarint()
+		require.Nil(t, r.ReadBytes())
+		require.Equal(t, errTest, r.Err())
+		require.Equal(t, errTest, r.Close())
+	})
+
+	t.Run("Huge length", func(t *testing.T) {
+		r := NewReader(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 0x0f, 'a'}), errTest)
+		require.Nil(t, r.ReadBytes())
+		require.Equal(t, errTest, r.Err())
+	})
+}
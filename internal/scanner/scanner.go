@@ -3,6 +3,7 @@ package scanner
 import (
 	"bufio"
 	"bytes"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -33,6 +34,14 @@ func (s *Scanner) Scan() (tok Token, pos Pos, lit string) {
 	// as an ident or reserved word.
 	if isWhitespace(ch0) {
 		return s.scanWhitespace()
+	} else if ch0 == 'x' || ch0 == 'X' {
+		// x'0a1b' is a bytes litteral, otherwise x is the start of an identifier
+		if ch1, _ := s.r.read(); ch1 == '\'' {
+			return s.scanBytes(pos)
+		}
+		s.r.unread()
+		s.r.unread()
+		return s.scanIdent(true, false)
 	} else if isLetter(ch0) || ch0 == '_' {
 		s.r.unread()
 		return s.scanIdent(true, false)
@@ -125,6 +134,14 @@ func (s *Scanner) Scan() (tok Token, pos Pos, lit string) {
 		return LPAREN, pos, ""
 	case ')':
 		return RPAREN, pos, ""
+	case '{':
+		return LBRACE, pos, ""
+	case '}':
+		return RBRACE, pos, ""
+	case '[':
+		return LBRACKET, pos, ""
+	case ']':
+		return RBRACKET, pos, ""
 	case ',':
 		return COMMA, pos, ""
 	case ';':
@@ -248,6 +265,30 @@ func (s *Scanner) scanString() (tok Token, pos Pos, lit string) {
 	return STRING, pos, lit
 }
 
+// scanBytes consumes the hexadecimal digits of a bytes litteral until the closing quote
+// and returns the decoded bytes.
+// This function assumes the x' characters have already been consumed.
+func (s *Scanner) scanBytes(pos Pos) (tok Token, _ Pos, lit string) {
+	var buf bytes.Buffer
+	for {
+		ch, _ := s.r.read()
+		if ch == '\'' {
+			break
+		}
+		if ch == eof || ch == '\n' {
+			return BADSTRING, pos, buf.String()
+		}
+		_, _ = buf.WriteRune(ch)
+	}
+
+	data, err := hex.DecodeString(buf.String())
+	if err != nil {
+		return BADSTRING, pos, buf.String()
+	}
+
+	return BYTES, pos, string(data)
+}
+
 // ScanRegex consumes a token to find escapes
 func (s *Scanner) ScanRegex() (tok Token, pos Pos, lit string) {
 	_, pos = s.r.curr()
@@ -306,6 +347,12 @@ func (s *Scanner) scanNumber() (tok Token, pos Pos, lit string) {
 		s.r.unread()
 	}
 
+	// Numbers with an exponent are always floating point numbers.
+	if exp := s.scanExponent(); exp != "" {
+		_, _ = buf.WriteString(exp)
+		return NUMBER, pos, buf.String()
+	}
+
 	// Read as a duration or integer if it doesn't have a fractional part.
 	if !isDecimal {
 		// If the next rune is a letter then this is a duration token.
@@ -337,6 +384,37 @@ func (s *Scanner) scanNumber() (tok Token, pos Pos, lit string) {
 	return NUMBER, pos, buf.String()
 }
 
+// scanExponent consumes the exponent of a number, like e10, E+10 or e-10.
+// It returns an empty string if the next runes are not an exponent.
+func (s *Scanner) scanExponent() string {
+	ch0, _ := s.r.read()
+	if ch0 != 'e' && ch0 != 'E' {
+		s.r.unread()
+		return ""
+	}
+
+	exp := string(ch0)
+	ch1, _ := s.r.read()
+	if ch1 == '+' || ch1 == '-' {
+		ch2, _ := s.r.read()
+		if !isDigit(ch2) {
+			s.r.unread()
+			s.r.unread()
+			s.r.unread()
+			return ""
+		}
+		exp += string(ch1) + string(ch2)
+	} else if isDigit(ch1) {
+		exp += string(ch1)
+	} else {
+		s.r.unread()
+		s.r.unread()
+		return ""
+	}
+
+	return exp + s.scanDigits()
+}
+
 // scanDigits consumes a contiguous series of digits.
 func (s *Scanner) scanDigits() string {
 	var buf bytes.Buffer
@@ -580,6 +658,10 @@ func ScanString(r io.RuneScanner) (string, error) {
 			ch1, _, _ := r.ReadRune()
 			if ch1 == 'n' {
 				_, _ = buf.WriteRune('\n')
+			} else if ch1 == 'r' {
+				_, _ = buf.WriteRune('\r')
+			} else if ch1 == 't' {
+				_, _ = buf.WriteRune('\t')
 			} else if ch1 == '\\' {
 				_, _ = buf.WriteRune('\\')
 			} else if ch1 == '"' {
@@ -36,11 +36,23 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `/`, tok: scanner.DIV},
 		{s: `%`, tok: scanner.MOD},
 
+		// Documents
+		{s: `{`, tok: scanner.LBRACE},
+		{s: `}`, tok: scanner.RBRACE},
+
+		// Arrays
+		{s: `[`, tok: scanner.LBRACKET},
+		{s: `]`, tok: scanner.RBRACKET},
+
 		// Logical operators
 		{s: `AND`, tok: scanner.AND},
 		{s: `and`, tok: scanner.AND},
 		{s: `OR`, tok: scanner.OR},
 		{s: `or`, tok: scanner.OR},
+		{s: `IS`, tok: scanner.IS},
+		{s: `is`, tok: scanner.IS},
+		{s: `IN`, tok: scanner.IN},
+		{s: `in`, tok: scanner.IN},
 
 		{s: `=`, tok: scanner.EQ},
 		{s: `<>`, tok: scanner.NEQ},
@@ -85,11 +97,24 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `'test`, tok: scanner.BADSTRING, lit: `test`},
 		{s: "'test\nfoo", tok: scanner.BADSTRING, lit: `test`},
 		{s: `'test\g'`, tok: scanner.BADESCAPE, lit: `\g`, pos: scanner.Pos{Line: 0, Char: 6}},
+		{s: `'a\rb\tc'`, tok: scanner.STRING, lit: "a\rb\tc"},
+
+		// Bytes
+		{s: `x'0a1B'`, tok: scanner.BYTES, lit: "\x0a\x1b"},
+		{s: `X''`, tok: scanner.BYTES, lit: ""},
+		{s: `x'0a1'`, tok: scanner.BADSTRING, lit: `0a1`},
+		{s: `x'zz'`, tok: scanner.BADSTRING, lit: `zz`},
+		{s: `x`, tok: scanner.IDENT, lit: `x`},
+		{s: `xyz`, tok: scanner.IDENT, lit: `xyz`},
 
 		// Numbers
 		{s: `100`, tok: scanner.INTEGER, lit: `100`},
 		{s: `100.23`, tok: scanner.NUMBER, lit: `100.23`},
 		{s: `.23`, tok: scanner.NUMBER, lit: `.23`},
+		{s: `1e300`, tok: scanner.NUMBER, lit: `1e300`},
+		{s: `1E+300`, tok: scanner.NUMBER, lit: `1E+300`},
+		{s: `1.5e-300`, tok: scanner.NUMBER, lit: `1.5e-300`},
+		{s: `1e-x`, tok: scanner.DURATIONVAL, lit: `1e`},
 		//{s: `.`, tok: scanner.ILLEGAL, lit: `.`},
 		{s: `10.3s`, tok: scanner.NUMBER, lit: `10.3`},
 
@@ -25,6 +25,7 @@ const (
 	INTEGER         // 12345
 	DURATIONVAL     // 13h
 	STRING          // "abc"
+	BYTES           // x'0a1b'
 	BADSTRING       // "abc
 	BADESCAPE       // \q
 	TRUE            // true
@@ -55,10 +56,17 @@ const (
 	LTE      // <=
 	GT       // >
 	GTE      // >=
+	IS       // IS
+	ISN      // IS NOT
+	IN       // IN
 	operatorEnd
 
 	LPAREN      // (
 	RPAREN      // )
+	LBRACE      // {
+	RBRACE      // }
+	LBRACKET    // [
+	RBRACKET    // ]
 	COMMA       // ,
 	COLON       // :
 	DOUBLECOLON // ::
@@ -71,8 +79,12 @@ const (
 	ALTER
 	AS
 	ASC
+	AUTOINCREMENT
 	BY
+	CASCADE
+	CHECK
 	CREATE
+	DEFAULT
 	DELETE
 	DESC
 	DROP
@@ -80,19 +92,23 @@ const (
 	EXISTS
 	FROM
 	IF
-	IN
 	INDEX
 	INF
 	INSERT
 	INTO
 	LIMIT
 	NOT
+	NULL
 	OFFSET
 	ON
 	ORDER
 	SELECT
+	SEQUENCE
 	SET
+	STRICT
 	RECORDS
+	REFERENCES
+	RESTRICT
 	TABLE
 	TO
 	UNIQUE
@@ -113,6 +129,7 @@ var tokens = [...]string{
 	NUMBER:          "NUMBER",
 	DURATIONVAL:     "DURATIONVAL",
 	STRING:          "STRING",
+	BYTES:           "BYTES",
 	BADSTRING:       "BADSTRING",
 	BADESCAPE:       "BADESCAPE",
 	TRUE:            "TRUE",
@@ -139,46 +156,61 @@ var tokens = [...]string{
 	LTE:      "<=",
 	GT:       ">",
 	GTE:      ">=",
+	IS:       "IS",
+	ISN:      "IS NOT",
+	IN:       "IN",
 
 	LPAREN:      "(",
 	RPAREN:      ")",
+	LBRACE:      "{",
+	RBRACE:      "}",
+	LBRACKET:    "[",
+	RBRACKET:    "]",
 	COMMA:       ",",
 	COLON:       ":",
 	DOUBLECOLON: "::",
 	SEMICOLON:   ";",
 	DOT:         ".",
 
-	ALL:      "ALL",
-	ALTER:    "ALTER",
-	AS:       "AS",
-	ASC:      "ASC",
-	BY:       "BY",
-	CREATE:   "CREATE",
-	DELETE:   "DELETE",
-	DESC:     "DESC",
-	DROP:     "DROP",
-	DURATION: "DURATION",
-	EXISTS:   "EXISTS",
-	FROM:     "FROM",
-	IF:       "IF",
-	IN:       "IN",
-	INDEX:    "INDEX",
-	INSERT:   "INSERT",
-	INTO:     "INTO",
-	LIMIT:    "LIMIT",
-	NOT:      "NOT",
-	OFFSET:   "OFFSET",
-	ON:       "ON",
-	ORDER:    "ORDER",
-	SELECT:   "SELECT",
-	SET:      "SET",
-	RECORDS:  "RECORDS",
-	TABLE:    "TABLE",
-	TO:       "TO",
-	UNIQUE:   "UNIQUE",
-	UPDATE:   "UPDATE",
-	VALUES:   "VALUES",
-	WHERE:    "WHERE",
+	ALL:           "ALL",
+	ALTER:         "ALTER",
+	AS:            "AS",
+	ASC:           "ASC",
+	AUTOINCREMENT: "AUTOINCREMENT",
+	BY:            "BY",
+	CASCADE:       "CASCADE",
+	CHECK:         "CHECK",
+	CREATE:        "CREATE",
+	DEFAULT:       "DEFAULT",
+	DELETE:        "DELETE",
+	DESC:          "DESC",
+	DROP:          "DROP",
+	DURATION:      "DURATION",
+	EXISTS:        "EXISTS",
+	FROM:          "FROM",
+	IF:            "IF",
+	INDEX:         "INDEX",
+	INSERT:        "INSERT",
+	INTO:          "INTO",
+	LIMIT:         "LIMIT",
+	NOT:           "NOT",
+	NULL:          "NULL",
+	OFFSET:        "OFFSET",
+	ON:            "ON",
+	ORDER:         "ORDER",
+	SELECT:        "SELECT",
+	SEQUENCE:      "SEQUENCE",
+	SET:           "SET",
+	STRICT:        "STRICT",
+	RECORDS:       "RECORDS",
+	REFERENCES:    "REFERENCES",
+	RESTRICT:      "RESTRICT",
+	TABLE:         "TABLE",
+	TO:            "TO",
+	UNIQUE:        "UNIQUE",
+	UPDATE:        "UPDATE",
+	VALUES:        "VALUES",
+	WHERE:         "WHERE",
 }
 
 var keywords map[string]Token
@@ -188,7 +220,7 @@ func init() {
 	for tok := keywordBeg + 1; tok < keywordEnd; tok++ {
 		keywords[strings.ToLower(tokens[tok])] = tok
 	}
-	for _, tok := range []Token{AND, OR} {
+	for _, tok := range []Token{AND, OR, IS, IN} {
 		keywords[strings.ToLower(tokens[tok])] = tok
 	}
 	keywords["true"] = TRUE
@@ -210,7 +242,7 @@ func (tok Token) Precedence() int {
 		return 1
 	case AND:
 		return 2
-	case EQ, NEQ, EQREGEX, NEQREGEX, LT, LTE, GT, GTE:
+	case EQ, NEQ, EQREGEX, NEQREGEX, LT, LTE, GT, GTE, IS, ISN, IN:
 		return 3
 	case ADD, SUB, BITWISEOR, BITWISEXOR:
 		return 4
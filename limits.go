@@ -0,0 +1,125 @@
+package genji
+
+import (
+	"context"
+	"time"
+)
+
+// Limits restrict the resources used by every statement.
+// Zero values mean no limit.
+type Limits struct {
+	// Timeout is the maximum duration of a statement, including the time spent
+	// reading its result.
+	Timeout time.Duration
+
+	// MaxScannedRecords is the maximum number of records a statement can read
+	// from tables and indexes.
+	MaxScannedRecords int
+
+	// MaxMemory is the maximum number of bytes a statement can keep in memory
+	// to process records it can't stream. Only the keys collected by UPDATE before
+	// modifying the records are counted for now, since no stage sorts, groups
+	// or removes duplicates yet.
+	MaxMemory int
+}
+
+// merge returns l, overridden by the non zero limits of other.
+func (l Limits) merge(other Limits) Limits {
+	if other.Timeout != 0 {
+		l.Timeout = other.Timeout
+	}
+	if other.MaxScannedRecords != 0 {
+		l.MaxScannedRecords = other.MaxScannedRecords
+	}
+	if other.MaxMemory != 0 {
+		l.MaxMemory = other.MaxMemory
+	}
+
+	return l
+}
+
+type limitsKey struct{}
+
+// WithLimits returns a copy of ctx carrying limits. Statements run with the returned context,
+// using for example DB.QueryContext or the database/sql package, are subject to these limits.
+// Non zero limits override the ones set on the database using SetLimits.
+func WithLimits(ctx context.Context, l Limits) context.Context {
+	return context.WithValue(ctx, limitsKey{}, l)
+}
+
+// SetLimits sets the limits of every statement executed against the database.
+// It must be called before using the database.
+func (db *DB) SetLimits(l Limits) {
+	db.limits = l
+}
+
+// statementLimiter keeps track of the resources used by a statement.
+type statementLimiter struct {
+	limits   Limits
+	deadline time.Time
+	scanned  int
+	memory   int
+}
+
+// newStatementLimiter returns a limiter enforcing the limits of the database and those of ctx,
+// or nil if there are none.
+func newStatementLimiter(ctx context.Context, db *DB) *statementLimiter {
+	l := db.limits
+	if cl, ok := ctx.Value(limitsKey{}).(Limits); ok {
+		l = l.merge(cl)
+	}
+
+	if l == (Limits{}) {
+		return nil
+	}
+
+	sl := statementLimiter{
+		limits: l,
+	}
+	if l.Timeout != 0 {
+		sl.deadline = time.Now().Add(l.Timeout)
+	}
+
+	return &sl
+}
+
+// checkTimeout returns an error if the statement exceeded its timeout.
+func (l *statementLimiter) checkTimeout() error {
+	if !l.deadline.IsZero() && time.Now().After(l.deadline) {
+		return &TimeoutError{Timeout: l.limits.Timeout}
+	}
+
+	return nil
+}
+
+// recordScanned must be called before reading each record.
+// It returns an error if the statement exceeded one of its limits.
+func (l *statementLimiter) recordScanned() error {
+	err := l.checkTimeout()
+	if err != nil {
+		return err
+	}
+
+	l.scanned++
+	if l.limits.MaxScannedRecords != 0 && l.scanned > l.limits.MaxScannedRecords {
+		return &ScanLimitError{MaxScannedRecords: l.limits.MaxScannedRecords}
+	}
+
+	return nil
+}
+
+// memoryUsed must be called every time the statement keeps n more bytes in memory.
+// It returns an error if the statement exceeded one of its limits.
+func (l *statementLimiter) memoryUsed(n int) error {
+	err := l.checkTimeout()
+	if err != nil {
+		return err
+	}
+
+	l.memory += n
+	if l.limits.MaxMemory != 0 && l.memory > l.limits.MaxMemory {
+		return &MemoryLimitError{MaxMemory: l.limits.MaxMemory}
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package genji_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asdine/genji"
+	"github.com/asdine/genji/engine/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimits(t *testing.T) {
+	db, err := genji.New(memory.NewEngine())
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test; CREATE INDEX idx_test_a ON test (a)")
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		err = db.Exec("INSERT INTO test (a) VALUES (?)", i)
+		require.NoError(t, err)
+	}
+
+	count := func(ctx context.Context, q string) (int, error) {
+		res, err := db.QueryContext(ctx, q)
+		if err != nil {
+			return 0, err
+		}
+		defer res.Close()
+
+		return res.Count()
+	}
+
+	db.SetLimits(genji.Limits{MaxScannedRecords: 5})
+	defer db.SetLimits(genji.Limits{})
+
+	tests := []struct {
+		name  string
+		query string
+		n     int
+		fails bool
+	}{
+		{"Full scan", "SELECT * FROM test", 0, true},
+		{"Filtered scan", "SELECT * FROM test WHERE a + 0 > 8", 0, true},
+		{"Limit", "SELECT * FROM test LIMIT 4", 4, false},
+		{"Index", "SELECT * FROM test WHERE a < 3", 3, false},
+		{"Large index range", "SELECT * FROM test WHERE a >= 2", 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			n, err := count(context.Background(), test.query)
+			if test.fails {
+				require.Equal(t, &genji.ScanLimitError{MaxScannedRecords: 5}, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.n, n)
+		})
+	}
+
+	t.Run("Per query", func(t *testing.T) {
+		ctx := genji.WithLimits(context.Background(), genji.Limits{MaxScannedRecords: 10})
+		n, err := count(ctx, "SELECT * FROM test")
+		require.NoError(t, err)
+		require.Equal(t, 10, n)
+
+		// every statement has its own limits
+		n, err = count(ctx, "SELECT * FROM test; SELECT * FROM test")
+		require.NoError(t, err)
+		require.Equal(t, 10, n)
+	})
+
+	t.Run("Writes", func(t *testing.T) {
+		err := db.Exec("UPDATE test SET b = 1")
+		require.Equal(t, &genji.ScanLimitError{MaxScannedRecords: 5}, err)
+
+		// nothing was updated
+		n, err := count(genji.WithLimits(context.Background(), genji.Limits{MaxScannedRecords: 100}), "SELECT * FROM test WHERE b = 1")
+		require.NoError(t, err)
+		require.Zero(t, n)
+	})
+
+	t.Run("Memory", func(t *testing.T) {
+		// the keys of the 10 records are collected before updating them
+		ctx := genji.WithLimits(context.Background(), genji.Limits{MaxScannedRecords: 100, MaxMemory: 100})
+		err := db.ExecContext(ctx, "UPDATE test SET c = 1")
+		require.Equal(t, &genji.MemoryLimitError{MaxMemory: 100}, err)
+		require.EqualError(t, err, "statement used more than 100 bytes of memory")
+
+		ctx = genji.WithLimits(context.Background(), genji.Limits{MaxScannedRecords: 100, MaxMemory: 1000})
+		err = db.ExecContext(ctx, "UPDATE test SET c = 1")
+		require.NoError(t, err)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		ctx := genji.WithLimits(context.Background(), genji.Limits{Timeout: time.Millisecond})
+		res, err := db.QueryContext(ctx, "SELECT * FROM test LIMIT 2")
+		require.NoError(t, err)
+		defer res.Close()
+
+		time.Sleep(2 * time.Millisecond)
+		_, err = res.Count()
+		require.Equal(t, &genji.TimeoutError{Timeout: time.Millisecond}, err)
+		require.EqualError(t, err, "statement timeout of 1ms exceeded")
+	})
+
+	t.Run("Driver", func(t *testing.T) {
+		dbx, err := genji.OpenDB(db)
+		require.NoError(t, err)
+
+		ctx := genji.WithLimits(context.Background(), genji.Limits{MaxScannedRecords: 2})
+		rows, err := dbx.QueryContext(ctx, "SELECT * FROM test")
+		require.NoError(t, err)
+		defer rows.Close()
+		for rows.Next() {
+		}
+		require.Equal(t, &genji.ScanLimitError{MaxScannedRecords: 2}, rows.Err())
+	})
+}
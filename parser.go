@@ -1,9 +1,11 @@
 package genji
 
 import (
+	"fmt"
 	"io"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/asdine/genji/internal/scanner"
 	"github.com/asdine/genji/value"
@@ -27,23 +29,47 @@ func parseQuery(s string) (query, error) { return newParser(strings.NewReader(s)
 // ParseQuery parses a Genji SQL string and returns a Query.
 func (p *parser) ParseQuery() (query, error) {
 	var statements []statement
+
+	err := p.parseStatements(func(s statement) error {
+		statements = append(statements, s)
+		return nil
+	})
+	if err != nil {
+		return query{}, err
+	}
+
+	q := newQuery(statements...)
+	q.numInput = p.orderedParams
+	if p.namedParams > 0 {
+		q.numInput = -1
+	}
+
+	return q, nil
+}
+
+// parseStatements parses a list of statements separated by semicolons and calls fn
+// with each of them as soon as it is parsed. If fn returns an error, parsing stops.
+func (p *parser) parseStatements(fn func(statement) error) error {
 	semi := true
 
 	for {
 		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok == scanner.EOF {
-			return newQuery(statements...), nil
+			return nil
 		} else if tok == scanner.SEMICOLON {
 			semi = true
 		} else {
 			if !semi {
-				return query{}, newParseError(scanner.Tokstr(tok, lit), []string{";"}, pos)
+				return newParseError(scanner.Tokstr(tok, lit), []string{";"}, pos)
 			}
 			p.Unscan()
 			s, err := p.ParseStatement()
 			if err != nil {
-				return query{}, err
+				return err
+			}
+			err = fn(s)
+			if err != nil {
+				return err
 			}
-			statements = append(statements, s)
 			semi = false
 		}
 	}
@@ -89,27 +115,23 @@ func (p *parser) parseCondition() (expr, error) {
 	return expr, nil
 }
 
+// An operator is an expression made of two operands and an operator token.
 type operator interface {
+	expr
 	Precedence() int
 	LeftHand() expr
 	RightHand() expr
-	SetLeftHandExpr(expr)
-	SetRightHandExpr(expr)
+	Operator() scanner.Token
 }
 
 // ParseExpr parses an expression.
 func (p *parser) ParseExpr() (expr, error) {
-	var err error
-	// Dummy root node.
-	var root operator = &cmpOp{}
-
 	// Parse a non-binary expression type to start.
 	// This variable will always be the root of the expression tree.
 	e, err := p.parseUnaryExpr()
 	if err != nil {
 		return nil, err
 	}
-	root.SetRightHandExpr(e)
 
 	// Loop over operations and unary exprs and build a tree based on precendence.
 	for {
@@ -117,31 +139,38 @@ func (p *parser) ParseExpr() (expr, error) {
 		op, _, _ := p.ScanIgnoreWhitespace()
 		if !op.IsOperator() {
 			p.Unscan()
-			return root.RightHand(), nil
+			return e, nil
 		}
 
-		var rhs expr
+		if op == scanner.IS {
+			if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.NOT {
+				op = scanner.ISN
+			} else {
+				p.Unscan()
+			}
+		}
 
-		if rhs, err = p.parseUnaryExpr(); err != nil {
+		rhs, err := p.parseUnaryExpr()
+		if err != nil {
 			return nil, err
 		}
 
-		// Find the right spot in the tree to add the new expression by
-		// descending the RHS of the expression tree until we reach the last
-		// BinaryExpr or a BinaryExpr whose RHS has an operator with
-		// precedence >= the operator being added.
-		for node := root.(operator); ; {
-			p, ok := node.RightHand().(operator)
-			if !ok || p.Precedence() >= op.Precedence() {
-				// Add the new expression here and break.
-				node.SetRightHandExpr(opToExpr(op, node.RightHand(), rhs))
-				break
-			}
-			node = p
-		}
+		e = insertOperation(e, op, rhs)
 	}
 }
 
+// insertOperation adds a new operation to the expression tree. It descends the right hand side
+// of operators whose precedence is lower than the one of op, so that operations with a higher
+// precedence end up deeper in the tree and are evaluated first.
+// Operators are rebuilt rather than modified in place because most of them are values.
+func insertOperation(e expr, op scanner.Token, rhs expr) expr {
+	if o, ok := e.(operator); ok && o.Precedence() < op.Precedence() {
+		return opToExpr(o.Operator(), o.LeftHand(), insertOperation(o.RightHand(), op, rhs))
+	}
+
+	return opToExpr(op, e, rhs)
+}
+
 func opToExpr(op scanner.Token, lhs, rhs expr) expr {
 	switch op {
 	case scanner.EQ:
@@ -158,6 +187,22 @@ func opToExpr(op scanner.Token, lhs, rhs expr) expr {
 		return and(lhs, rhs)
 	case scanner.OR:
 		return or(lhs, rhs)
+	case scanner.IS:
+		return is(lhs, rhs)
+	case scanner.ISN:
+		return isNot(lhs, rhs)
+	case scanner.IN:
+		return in(lhs, rhs)
+	case scanner.ADD:
+		return add(lhs, rhs)
+	case scanner.SUB:
+		return sub(lhs, rhs)
+	case scanner.MUL:
+		return mul(lhs, rhs)
+	case scanner.DIV:
+		return div(lhs, rhs)
+	case scanner.MOD:
+		return mod(lhs, rhs)
 	}
 
 	return nil
@@ -165,10 +210,49 @@ func opToExpr(op scanner.Token, lhs, rhs expr) expr {
 
 // parseUnaryExpr parses an non-binary expression.
 func (p *parser) parseUnaryExpr() (expr, error) {
+	e, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse optional casts: expr::type
+	for {
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.DOUBLECOLON {
+			p.Unscan()
+			return e, nil
+		}
+
+		t, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+
+		e = castExpr{e: e, t: t}
+	}
+}
+
+// parseOperand parses a litteral, a field, a param, a function call or an expression between parentheses.
+func (p *parser) parseOperand() (expr, error) {
 	tok, pos, lit := p.ScanIgnoreWhitespace()
 	switch tok {
 	case scanner.IDENT:
-		return fieldSelector(lit), nil
+		// an identifier immediately followed by a parenthesis is a function call
+		if tok, _, _ := p.Scan(); tok == scanner.LPAREN {
+			// ANY and CAST are not keywords so that they can still be used as field names
+			switch {
+			case strings.EqualFold(lit, "any"):
+				return p.parseQuantifier(false)
+			case strings.EqualFold(lit, "cast"):
+				return p.parseCast()
+			}
+			return p.parseFunctionCall(lit, pos)
+		}
+		p.Unscan()
+		path, err := p.parsePath(lit)
+		if err != nil {
+			return nil, err
+		}
+		return fieldSelector(path), nil
 	case scanner.IDENTORSTRING:
 		return identOrStringLitteral(lit), nil
 	case scanner.NAMEDPARAM:
@@ -188,6 +272,8 @@ func (p *parser) parseUnaryExpr() (expr, error) {
 		return positionalParam(p.orderedParams), nil
 	case scanner.STRING:
 		return litteralValue{value.NewString(lit)}, nil
+	case scanner.BYTES:
+		return litteralValue{value.NewBytes([]byte(lit))}, nil
 	case scanner.NUMBER:
 		v, err := strconv.ParseFloat(lit, 64)
 		if err != nil {
@@ -205,11 +291,227 @@ func (p *parser) parseUnaryExpr() (expr, error) {
 			return nil, &ParseError{Message: "unable to parse integer", Pos: pos}
 		}
 		return litteralValue{value.NewInt64(v)}, nil
+	case scanner.DURATIONVAL:
+		d, err := parseDuration(lit)
+		if err != nil {
+			return nil, &ParseError{Message: err.Error(), Pos: pos}
+		}
+		return durationValue(d), nil
 	case scanner.TRUE, scanner.FALSE:
 		return litteralValue{value.NewBool(tok == scanner.TRUE)}, nil
+	case scanner.NULL:
+		return nullValue(), nil
+	case scanner.SUB:
+		return p.parseNegativeNumber()
+	case scanner.LBRACE:
+		return p.parseDocument()
+	case scanner.LBRACKET:
+		return p.parseArray()
+	case scanner.ALL:
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+		}
+
+		return p.parseQuantifier(true)
+	case scanner.LPAREN:
+		e, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		// a comma means the parentheses contain a list of expressions
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.COMMA {
+			list := litteralExprList{e}
+			for {
+				e, err := p.ParseExpr()
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, e)
+
+				if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+					p.Unscan()
+					break
+				}
+			}
+
+			if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+				return nil, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+			}
+
+			return list, nil
+		}
+		p.Unscan()
+
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+		}
+
+		return parentheses{e}, nil
 	default:
-		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"identifier", "string", "number", "bool"}, pos)
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"identifier", "string", "number", "bool", "null"}, pos)
+	}
+}
+
+// parseCast parses a CAST expression: CAST(expr AS type).
+// This function assumes the CAST and ( tokens have already been consumed.
+func (p *parser) parseCast() (expr, error) {
+	e, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.AS {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"AS"}, pos)
+	}
+
+	t, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	return castExpr{e: e, t: t}, nil
+}
+
+// parseFunctionCall parses the arguments of a function call and returns the function.
+// This function assumes the function name and the ( token have already been consumed.
+func (p *parser) parseFunctionCall(name string, pos scanner.Pos) (expr, error) {
+	newFunc, ok := functions[strings.ToLower(name)]
+	if !ok {
+		return nil, &ParseError{Message: fmt.Sprintf("unknown function %q", name), Pos: pos}
+	}
+
+	var args []expr
+
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		p.Unscan()
+
+		for {
+			e, err := p.ParseExpr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, e)
+
+			tok, pos, lit := p.ScanIgnoreWhitespace()
+			if tok == scanner.RPAREN {
+				break
+			}
+			if tok != scanner.COMMA {
+				return nil, newParseError(scanner.Tokstr(tok, lit), []string{",", ")"}, pos)
+			}
+		}
+	}
+
+	e, err := newFunc(args...)
+	if err != nil {
+		return nil, &ParseError{Message: err.Error(), Pos: pos}
+	}
+
+	return e, nil
+}
+
+// parseNegativeNumber parses a number litteral preceded by a minus sign.
+// This function assumes the SUB token has already been consumed.
+func (p *parser) parseNegativeNumber() (expr, error) {
+	tok, pos, lit := p.Scan()
+	switch tok {
+	case scanner.NUMBER:
+		v, err := strconv.ParseFloat("-"+lit, 64)
+		if err != nil {
+			return nil, &ParseError{Message: "unable to parse number", Pos: pos}
+		}
+		return litteralValue{value.NewFloat64(v)}, nil
+	case scanner.INTEGER:
+		v, err := strconv.ParseInt("-"+lit, 10, 64)
+		if err != nil {
+			return nil, &ParseError{Message: "unable to parse integer", Pos: pos}
+		}
+		return litteralValue{value.NewInt64(v)}, nil
+	case scanner.DURATIONVAL:
+		d, err := parseDuration(lit)
+		if err != nil {
+			return nil, &ParseError{Message: err.Error(), Pos: pos}
+		}
+		return durationValue(-d), nil
+	}
+
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"number", "duration"}, pos)
+}
+
+// durationUnits maps the units of duration litterals to their duration.
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"u":  time.Microsecond,
+	"µ":  time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+}
+
+// parseDuration parses a duration litteral, made of one or more integers
+// each followed by a unit, e.g. 10s or 1h30m.
+func parseDuration(s string) (time.Duration, error) {
+	var d time.Duration
+
+	orig := s
+	for len(s) > 0 {
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == 0 {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+
+		n, err := strconv.ParseInt(s[:i], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		s = s[i:]
+
+		i = 0
+		for i < len(s) && (s[i] < '0' || s[i] > '9') {
+			i++
+		}
+
+		unit, ok := durationUnits[s[:i]]
+		if !ok {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		s = s[i:]
+
+		d += time.Duration(n) * unit
 	}
+
+	return d, nil
+}
+
+// parseConstraintExpr parses an expression that is meant to be stored, like a default value
+// or a check constraint. Params are rejected since they can't be evaluated later on.
+func (p *parser) parseConstraintExpr() (expr, error) {
+	_, pos, _ := p.ScanIgnoreWhitespace()
+	p.Unscan()
+
+	orderedParams, namedParams := p.orderedParams, p.namedParams
+
+	e, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.orderedParams != orderedParams || p.namedParams != namedParams {
+		return nil, &ParseError{Message: "params are not allowed in constraints", Pos: pos}
+	}
+
+	return e, nil
 }
 
 // ParseIdent parses an identifier.
@@ -221,6 +523,138 @@ func (p *parser) ParseIdent() (string, error) {
 	return lit, nil
 }
 
+// parseDocument parses a list of fields in the form {k: expr, k: expr, ...}.
+// This function assumes the { token has already been consumed.
+func (p *parser) parseDocument() (expr, error) {
+	var d documentExpr
+
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.RBRACE {
+		return d, nil
+	}
+	p.Unscan()
+
+	for {
+		k, e, err := p.parseKV()
+		if err != nil {
+			return nil, err
+		}
+		d = append(d, kvPair{K: k, V: e})
+
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok == scanner.RBRACE {
+			return d, nil
+		}
+		if tok != scanner.COMMA {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{",", "}"}, pos)
+		}
+	}
+}
+
+// parseQuantifier parses the expression of an ANY or ALL quantifier.
+// This function assumes the quantifier and the ( token have already been consumed.
+func (p *parser) parseQuantifier(all bool) (expr, error) {
+	e, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	return quantifier{e: e, all: all}, nil
+}
+
+// parseArray parses a list of expressions in the form [expr, expr, ...].
+// This function assumes the [ token has already been consumed.
+func (p *parser) parseArray() (expr, error) {
+	var a arrayExpr
+
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.RBRACKET {
+		return a, nil
+	}
+	p.Unscan()
+
+	for {
+		e, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, e)
+
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok == scanner.RBRACKET {
+			return a, nil
+		}
+		if tok != scanner.COMMA {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{",", "]"}, pos)
+		}
+	}
+}
+
+// parseFieldPath parses a field name, optionally followed by the names of nested fields
+// separated by dots, like address.city.
+func (p *parser) parseFieldPath() (string, error) {
+	ident, err := p.ParseIdent()
+	if err != nil {
+		return "", err
+	}
+
+	return p.parsePath(ident)
+}
+
+// parsePath parses the remaining parts of a path, if any. A path is made of
+// field names separated by dots and array indexes between brackets, like a.b[0].
+// The parts must not be separated by whitespace.
+// This function assumes the first identifier has already been consumed.
+func (p *parser) parsePath(ident string) (string, error) {
+	path := ident
+
+	for {
+		switch tok, _, _ := p.Scan(); tok {
+		case scanner.DOT:
+			tok, pos, lit := p.Scan()
+			if tok != scanner.IDENT && tok != scanner.IDENTORSTRING {
+				return "", newParseError(scanner.Tokstr(tok, lit), []string{"identifier"}, pos)
+			}
+
+			path += "." + lit
+		case scanner.LBRACKET:
+			tok, pos, lit := p.Scan()
+			if tok != scanner.INTEGER {
+				return "", newParseError(scanner.Tokstr(tok, lit), []string{"integer"}, pos)
+			}
+
+			if tok, pos, lit := p.Scan(); tok != scanner.RBRACKET {
+				return "", newParseError(scanner.Tokstr(tok, lit), []string{"]"}, pos)
+			}
+
+			path += "[" + lit + "]"
+		default:
+			p.Unscan()
+			return path, nil
+		}
+	}
+}
+
+// parseFieldPathList parses a comma delimited list of field paths.
+func (p *parser) parseFieldPathList() ([]string, error) {
+	var paths []string
+
+	for {
+		path, err := p.parseFieldPath()
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			return paths, nil
+		}
+	}
+}
+
 // ParseIdentList parses a comma delimited list of identifiers.
 func (p *parser) ParseIdentList() ([]string, error) {
 	// Parse first (required) identifier.
@@ -3,7 +3,9 @@ package genji
 import (
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/asdine/genji/value"
 	"github.com/stretchr/testify/require"
 )
 
@@ -33,6 +35,61 @@ func TestParserExpr(t *testing.T) {
 				),
 				lt(fieldSelector("age"), float64Value(10.4)),
 			)},
+		{"Parentheses", "age >= 10 AND (age > 5 OR age < 1)",
+			and(
+				gte(fieldSelector("age"), int64Value(10)),
+				parentheses{or(
+					gt(fieldSelector("age"), int64Value(5)),
+					lt(fieldSelector("age"), int64Value(1)),
+				)},
+			)},
+		{"Negative numbers", "age > -10 AND age < -1.5", and(
+			gt(fieldSelector("age"), int64Value(-10)),
+			lt(fieldSelector("age"), float64Value(-1.5)),
+		)},
+		{"IS NULL", "age IS NULL", is(fieldSelector("age"), nullValue())},
+		{"Path", "address.city = 'Lyon'", eq(fieldSelector("address.city"), stringValue("Lyon"))},
+		{"Document", "a = {b: 1, c: {d: 'foo'}}",
+			eq(fieldSelector("a"), documentExpr{
+				{K: "b", V: int64Value(1)},
+				{K: "c", V: documentExpr{{K: "d", V: stringValue("foo")}}},
+			})},
+		{"Array", "tags = ['a', 1, []]",
+			eq(fieldSelector("tags"), arrayExpr{stringValue("a"), int64Value(1), arrayExpr(nil)})},
+		{"Array element", "tags[0] = 'a'", eq(fieldSelector("tags[0]"), stringValue("a"))},
+		{"IN array", "'a' IN tags", in(stringValue("a"), fieldSelector("tags"))},
+		{"IN list", "age IN (1, 2)", in(fieldSelector("age"), litteralExprList{int64Value(1), int64Value(2)})},
+		{"ANY", "'a' = ANY(tags)", eq(stringValue("a"), quantifier{e: fieldSelector("tags")})},
+		{"ALL", "10 > ALL (ages)", gt(int64Value(10), quantifier{e: fieldSelector("ages"), all: true})},
+		{"LENGTH", "LENGTH(tags) > 1", gt(lengthFunc{e: fieldSelector("tags")}, int64Value(1))},
+		{"Duration", "d > 1h30m AND d < -10ms",
+			and(
+				gt(fieldSelector("d"), durationValue(90*time.Minute)),
+				lt(fieldSelector("d"), durationValue(-10*time.Millisecond)),
+			)},
+		{"Arithmetic", "ts > NOW() - 1d * 2", gt(fieldSelector("ts"), sub(nowFunc{}, mul(durationValue(24*time.Hour), int64Value(2))))},
+		{"Arithmetic / Left associative", "a - b + c = 10", eq(add(sub(fieldSelector("a"), fieldSelector("b")), fieldSelector("c")), int64Value(10))},
+		{"Arithmetic / Parentheses", "(a + 1) % 2", mod(parentheses{add(fieldSelector("a"), int64Value(1))}, int64Value(2))},
+		{"Decimal", "price = DECIMAL('12.50') OR price = DECIMAL(?)",
+			or(
+				eq(fieldSelector("price"), mustDecimalValue("12.5")),
+				eq(fieldSelector("price"), conversionFunc{e: positionalParam(1), t: value.Decimal}),
+			)},
+		{"UUID", "id = UUID('F47AC10B-58CC-4372-A567-0E02B2C3D479') OR id = UUID(?)",
+			or(
+				eq(fieldSelector("id"), mustUUIDValue("f47ac10b-58cc-4372-a567-0e02b2c3d479")),
+				eq(fieldSelector("id"), conversionFunc{e: positionalParam(1), t: value.UUID}),
+			)},
+		{"CAST", "CAST(a AS INT8) = CAST('10' AS int)",
+			eq(castExpr{e: fieldSelector("a"), t: value.Int8}, castExpr{e: stringValue("10"), t: value.Int})},
+		{"::", "a::STRING::bytes = b :: DURATION",
+			eq(castExpr{e: castExpr{e: fieldSelector("a"), t: value.String}, t: value.Bytes}, castExpr{e: fieldSelector("b"), t: value.Duration})},
+		{"CAST field", "cast = 1", eq(fieldSelector("cast"), int64Value(1))},
+		{"IS NOT NULL", "age IS NOT NULL AND age > 10",
+			and(
+				isNot(fieldSelector("age"), nullValue()),
+				gt(fieldSelector("age"), int64Value(10)),
+			)},
 	}
 
 	for _, test := range tests {
@@ -44,6 +101,62 @@ func TestParserExpr(t *testing.T) {
 	}
 }
 
+func TestExprString(t *testing.T) {
+	tests := []struct {
+		name     string
+		e        expr
+		expected string
+	}{
+		{"Field", fieldSelector("age"), "age"},
+		{"Quoted field", fieldSelector("first name"), `"first name"`},
+		{"Keyword field", fieldSelector("table"), `"table"`},
+		{"String", stringValue("it's"), `'it\'s'`},
+		{"String with carriage return", stringValue("a\r\nb"), `'a\r\nb'`},
+		{"Invalid UTF-8 string", stringValue("a\xff"), "CAST(x'61ff' AS STRING)"},
+		{"Bytes", bytesValue([]byte{0, 'a', 0xff}), "x'0061ff'"},
+		{"Integer", int64Value(-10), "-10"},
+		{"Int8", int8Value(-10), "CAST(-10 AS INT8)"},
+		{"Float32", float32Value(1.5), "CAST(1.5 AS FLOAT32)"},
+		{"Timestamp", timestampValue(time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)), "CAST('2020-01-02T03:04:05.000000006Z' AS TIMESTAMP)"},
+		{"Float", float64Value(10), "10.0"},
+		{"Bool", boolValue(true), "true"},
+		{"Null", nullValue(), "NULL"},
+		{"Path", fieldSelector("address.city"), "address.city"},
+		{"Quoted path", fieldSelector("address.first name"), `"address.first name"`},
+		{"Document", documentExpr{{K: "a", V: int64Value(1)}, {K: "first name", V: documentExpr{}}}, `{a: 1, "first name": {}}`},
+		{"Array", arrayExpr{stringValue("a"), arrayExpr{int64Value(1)}}, "['a', [1]]"},
+		{"Array element", fieldSelector("tags[0].name"), "tags[0].name"},
+		{"IN", in(fieldSelector("a"), litteralExprList{int64Value(1), int64Value(2)}), "a IN (1, 2)"},
+		{"ANY", eq(stringValue("a"), quantifier{e: fieldSelector("tags")}), "'a' = ANY(tags)"},
+		{"ALL", lt(fieldSelector("a"), quantifier{e: fieldSelector("b"), all: true}), "a < ALL(b)"},
+		{"LENGTH", lengthFunc{e: fieldSelector("tags")}, "LENGTH(tags)"},
+		{"Decimal", mustDecimalValue("-0.50"), "DECIMAL('-0.5')"},
+		{"Decimal function", conversionFunc{e: fieldSelector("a"), t: value.Decimal}, "DECIMAL(a)"},
+		{"UUID", mustUUIDValue("f47ac10b58cc4372a5670e02b2c3d479"), "UUID('f47ac10b-58cc-4372-a567-0e02b2c3d479')"},
+		{"CAST", castExpr{e: add(fieldSelector("a"), int64Value(1)), t: value.Int8}, "CAST(a + 1 AS INT8)"},
+		{"Duration", durationValue(90 * time.Minute), "90m"},
+		{"Negative duration", durationValue(-1500 * time.Microsecond), "-1500u"},
+		{"Arithmetic", mul(add(fieldSelector("a"), int64Value(1)), sub(fieldSelector("b"), int64Value(2))), "(a + 1) * (b - 2)"},
+		{"NOW", gt(fieldSelector("ts"), sub(nowFunc{}, durationValue(time.Hour))), "ts > NOW() - 1h"},
+		{"IS NOT", isNot(fieldSelector("a"), nullValue()), "a IS NOT NULL"},
+		{"AND", and(gt(fieldSelector("a"), int64Value(1)), lt(fieldSelector("a"), float64Value(1.5))), "a > 1 AND a < 1.5"},
+		{"Precedence", and(or(fieldSelector("a"), fieldSelector("b")), fieldSelector("c")), "(a OR b) AND c"},
+		{"Parentheses", parentheses{eq(fieldSelector("a"), positionalParam(1))}, "(a = ?)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := test.e.String()
+			require.Equal(t, test.expected, s)
+
+			// the output must be parsed back into an equivalent expression
+			ex, err := newParser(strings.NewReader(s)).ParseExpr()
+			require.NoError(t, err)
+			require.Equal(t, s, ex.String())
+		})
+	}
+}
+
 func TestParserParams(t *testing.T) {
 	tests := []struct {
 		name     string
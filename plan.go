@@ -8,6 +8,7 @@ import (
 	"github.com/asdine/genji/index"
 	"github.com/asdine/genji/internal/scanner"
 	"github.com/asdine/genji/record"
+	"github.com/asdine/genji/value"
 )
 
 type queryPlan struct {
@@ -69,6 +70,8 @@ func buildQueryPlan(indexes map[string]Index, e expr) queryPlan {
 
 func analyseExpr(indexes map[string]Index, e expr) *queryPlanNode {
 	switch t := e.(type) {
+	case parentheses:
+		return analyseExpr(indexes, t.e)
 	case cmpOp:
 		ok, fs, e := cmpOpCanUseIndex(&t)
 		if !ok || !evaluatesToScalarOrParam(e) {
@@ -86,6 +89,25 @@ func analyseExpr(indexes map[string]Index, e expr) *queryPlanNode {
 			e:            e,
 			uniqueIndex:  idx.Unique,
 		}
+	case inOp:
+		// expr IN field can be looked up in the index of the field,
+		// which contains an entry for each element of arrays
+		fs, ok := t.RightHand().(fieldSelector)
+		if !ok || !evaluatesToScalarOrParam(t.LeftHand()) {
+			return nil
+		}
+
+		idx, ok := indexes[fs.Name()]
+		if !ok {
+			return nil
+		}
+
+		return &queryPlanNode{
+			indexedField: fs,
+			op:           scanner.EQ,
+			e:            t.LeftHand(),
+			uniqueIndex:  idx.Unique,
+		}
 	case *andOp:
 		nodeL := analyseExpr(indexes, t.LeftHand())
 		nodeR := analyseExpr(indexes, t.LeftHand())
@@ -114,12 +136,22 @@ func cmpOpCanUseIndex(cmp *cmpOp) (bool, fieldSelector, expr) {
 
 	// field OP expr
 	if leftIsField && !rightIsField {
-		cmp.RightHand()
 		return true, lf, cmp.RightHand()
 	}
 
-	// expr OP field
+	// expr OP field is turned into field OP' expr
+	// so that the index is read in the right direction
 	if rightIsField && !leftIsField {
+		switch cmp.Token {
+		case scanner.GT:
+			cmp.Token = scanner.LT
+		case scanner.GTE:
+			cmp.Token = scanner.LTE
+		case scanner.LT:
+			cmp.Token = scanner.GT
+		case scanner.LTE:
+			cmp.Token = scanner.GTE
+		}
 		return true, rf, cmp.LeftHand()
 	}
 
@@ -127,11 +159,21 @@ func cmpOpCanUseIndex(cmp *cmpOp) (bool, fieldSelector, expr) {
 }
 
 func evaluatesToScalarOrParam(e expr) bool {
-	switch e.(type) {
+	switch t := e.(type) {
 	case litteralValue:
 		return true
 	case namedParam, positionalParam:
 		return true
+	case nowFunc:
+		return true
+	case conversionFunc:
+		return evaluatesToScalarOrParam(t.e)
+	case castExpr:
+		return evaluatesToScalarOrParam(t.e)
+	case arithOp:
+		return evaluatesToScalarOrParam(t.a) && evaluatesToScalarOrParam(t.b)
+	case parentheses:
+		return evaluatesToScalarOrParam(t.e)
 	}
 
 	return false
@@ -161,10 +203,40 @@ func (it indexIterator) Iterate(fn func(r record.Record) error) error {
 		return errors.New("expression doesn't evaluate to scalar")
 	}
 
+	// comparing with null never matches and null values are not indexed
+	if v.Value.Type == value.Null {
+		return nil
+	}
+
+	// arrays are indexed element by element, the index can't be used
+	// to look up a whole array
+	if v.Value.Type == value.Array {
+		return it.tb.Iterate(fn)
+	}
+
+	// the value is converted to the encoding used by the index so that
+	// it can be compared with values of any other numeric type
+	pivot, err := index.EncodeValue(v.Value.Value)
+	if err != nil {
+		return err
+	}
+
+	// like Table.Iterate, stop as soon as the context of the transaction is done
+	// or the statement limits are exceeded
+	iterFn := fn
+	fn = func(r record.Record) error {
+		err := it.tb.checkScan()
+		if err != nil {
+			return err
+		}
+
+		return iterFn(r)
+	}
+
 	switch it.op {
 	case scanner.EQ:
-		err = it.index.AscendGreaterOrEqual(v.Value.Data, func(value []byte, key []byte) error {
-			if bytes.Equal(v.Value.Data, value) {
+		err = it.index.AscendGreaterOrEqual(pivot, func(value []byte, key []byte) error {
+			if bytes.Equal(pivot, value) {
 				r, err := it.tb.GetRecord(key)
 				if err != nil {
 					return err
@@ -176,8 +248,11 @@ func (it indexIterator) Iterate(fn func(r record.Record) error) error {
 			return errStop
 		})
 	case scanner.GT:
-		err = it.index.AscendGreaterOrEqual(v.Value.Data, func(value []byte, key []byte) error {
-			if bytes.Equal(v.Value.Data, value) {
+		err = it.index.AscendGreaterOrEqual(pivot, func(value []byte, key []byte) error {
+			if !sameFamily(pivot, value) {
+				return errStop
+			}
+			if bytes.Equal(pivot, value) {
 				return nil
 			}
 
@@ -189,7 +264,11 @@ func (it indexIterator) Iterate(fn func(r record.Record) error) error {
 			return fn(r)
 		})
 	case scanner.GTE:
-		err = it.index.AscendGreaterOrEqual(v.Value.Data, func(value []byte, key []byte) error {
+		err = it.index.AscendGreaterOrEqual(pivot, func(value []byte, key []byte) error {
+			if !sameFamily(pivot, value) {
+				return errStop
+			}
+
 			r, err := it.tb.GetRecord(key)
 			if err != nil {
 				return err
@@ -198,8 +277,11 @@ func (it indexIterator) Iterate(fn func(r record.Record) error) error {
 			return fn(r)
 		})
 	case scanner.LT:
-		err = it.index.DescendLessOrEqual(v.Value.Data, func(value []byte, key []byte) error {
-			if bytes.Equal(v.Value.Data, value) {
+		err = it.index.DescendLessOrEqual(pivot, func(value []byte, key []byte) error {
+			if !sameFamily(pivot, value) {
+				return errStop
+			}
+			if bytes.Equal(pivot, value) {
 				return nil
 			}
 
@@ -211,7 +293,11 @@ func (it indexIterator) Iterate(fn func(r record.Record) error) error {
 			return fn(r)
 		})
 	case scanner.LTE:
-		err = it.index.DescendLessOrEqual(v.Value.Data, func(value []byte, key []byte) error {
+		err = it.index.DescendLessOrEqual(pivot, func(value []byte, key []byte) error {
+			if !sameFamily(pivot, value) {
+				return errStop
+			}
+
 			r, err := it.tb.GetRecord(key)
 			if err != nil {
 				return err
@@ -227,3 +313,10 @@ func (it indexIterator) Iterate(fn func(r record.Record) error) error {
 
 	return nil
 }
+
+// sameFamily reports whether two encoded index values share the same type tag.
+// Values of different types never match a comparison, so range scans stop
+// as soon as they reach values of another type.
+func sameFamily(a, b []byte) bool {
+	return len(a) > 0 && len(b) > 0 && a[0] == b[0]
+}
@@ -1,11 +1,16 @@
 package genji
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
+	"strconv"
+	"strings"
 
+	"github.com/asdine/genji/internal/scanner"
 	"github.com/asdine/genji/record"
+	"github.com/asdine/genji/value"
 )
 
 // A query can execute statements against the database. It can read or write data
@@ -13,10 +18,14 @@ import (
 // Results are returned as streams.
 type query struct {
 	Statements []statement
+
+	// number of positional params of the query, -1 if it uses named params
+	numInput int
 }
 
-// Run executes all the statements in their own transaction and returns the last result.
-func (q query) Run(db *DB, args []driver.NamedValue) (*Result, error) {
+// Run executes all the statements in their own transaction, bound to ctx, and returns the last result.
+// Each statement is subject to the limits of the database and of ctx.
+func (q query) Run(ctx context.Context, db *DB, args []driver.NamedValue) (*Result, error) {
 	var res Result
 	var tx *Tx
 	var err error
@@ -39,12 +48,12 @@ func (q query) Run(db *DB, args []driver.NamedValue) (*Result, error) {
 		}
 
 		// start a new transaction for every statement
-		tx, err = db.Begin(!stmt.IsReadOnly())
+		tx, err = db.BeginContext(ctx, !stmt.IsReadOnly())
 		if err != nil {
 			return nil, err
 		}
 
-		res, err = stmt.Run(tx, args)
+		res, err = stmt.Run(tx.statementTx(ctx), args)
 		if err != nil {
 			tx.Rollback()
 			return nil, err
@@ -60,7 +69,9 @@ func (q query) Run(db *DB, args []driver.NamedValue) (*Result, error) {
 
 // Exec the query within the given transaction. If the one of the statements requires a read-write
 // transaction and tx is not, tx will get promoted.
-func (q query) Exec(tx *Tx, args []driver.NamedValue, forceReadOnly bool) (*Result, error) {
+// The statements are run using ctx instead of the context of tx, and each of them
+// is subject to the limits of the database and of ctx.
+func (q query) Exec(ctx context.Context, tx *Tx, args []driver.NamedValue, forceReadOnly bool) (*Result, error) {
 	var res Result
 	var err error
 
@@ -74,7 +85,7 @@ func (q query) Exec(tx *Tx, args []driver.NamedValue, forceReadOnly bool) (*Resu
 			}
 		}
 
-		res, err = stmt.Run(tx, args)
+		res, err = stmt.Run(tx.statementTx(ctx), args)
 		if err != nil {
 			return nil, err
 		}
@@ -83,6 +94,51 @@ func (q query) Exec(tx *Tx, args []driver.NamedValue, forceReadOnly bool) (*Resu
 	return &res, nil
 }
 
+// Statement is a query parsed once by DB.Prepare, which can then be run any number of times,
+// possibly concurrently. It is safe to reuse a statement once the transaction it was run in is closed.
+type Statement struct {
+	db *DB
+	tx *Tx
+	q  query
+}
+
+// NumInput returns the number of positional params of the statement,
+// or -1 if it uses named params, which can be passed in any order and number.
+func (s *Statement) NumInput() int {
+	return s.q.numInput
+}
+
+// Query runs the statement like DB.Query, or like Tx.Query if the statement
+// was returned by Tx.Stmt, and returns the result.
+// The returned result must always be closed after usage.
+func (s *Statement) Query(args ...interface{}) (*Result, error) {
+	return s.QueryContext(context.Background(), args...)
+}
+
+// QueryContext runs the statement like Query, aborting it if ctx is done.
+func (s *Statement) QueryContext(ctx context.Context, args ...interface{}) (*Result, error) {
+	if s.tx != nil {
+		return s.q.Exec(ctx, s.tx, argsToNamedValues(args), false)
+	}
+
+	return s.q.Run(ctx, s.db, argsToNamedValues(args))
+}
+
+// Exec runs the statement without returning the result.
+func (s *Statement) Exec(args ...interface{}) error {
+	return s.ExecContext(context.Background(), args...)
+}
+
+// ExecContext runs the statement like Exec, aborting it if ctx is done.
+func (s *Statement) ExecContext(ctx context.Context, args ...interface{}) error {
+	res, err := s.QueryContext(ctx, args...)
+	if err != nil {
+		return err
+	}
+
+	return res.Close()
+}
+
 // newQuery creates a new query with the given statements.
 func newQuery(statements ...statement) query {
 	return query{Statements: statements}
@@ -99,16 +155,39 @@ type Result struct {
 	record.Stream
 	rowsAffected  driver.RowsAffected
 	lastInsertKey []byte
+	lastInsertID  int64
+	hasInsertID   bool
 	tx            *Tx
 	closed        bool
 }
 
-// LastInsertId is not supported and returns an error.
-// Use LastInsertKey instead.
+// LastInsertId returns the key generated for the last inserted record
+// if its table uses a SequenceKeyGenerator. Otherwise it returns an error,
+// use LastInsertKey instead.
 func (r Result) LastInsertId() (int64, error) {
+	if r.hasInsertID {
+		return r.lastInsertID, nil
+	}
+
 	return r.rowsAffected.LastInsertId()
 }
 
+// setLastInsertKey stores the key of the last inserted record rec. If the table uses a sequence
+// and the key is an integer, it is also decoded so it can be returned by LastInsertId.
+func (r *Result) setLastInsertKey(t *Table, rec record.Record, key []byte) {
+	r.lastInsertKey = key
+	r.hasInsertID = false
+
+	if t.schema == nil || t.schema.cfg.KeyGenerator != SequenceKeyGenerator || !hasIntegerKey(t, rec) {
+		return
+	}
+
+	id, err := value.DecodeInt64(key)
+	if err == nil {
+		r.lastInsertID, r.hasInsertID = id, true
+	}
+}
+
 // LastInsertKey returns the database's auto-generated key
 // after, for example, an INSERT into a table with primary
 // key.
@@ -203,12 +282,75 @@ func (f fieldSelector) Name() string {
 // SelectField takes a field from a record.
 // If the field selector was created using the As method
 // it must replace the name of f by the alias.
+// If f is a path, like "address.city" or "tags[0]", and r doesn't contain a field with that exact name,
+// the path is followed through nested documents and arrays. The selected field is named after the whole path.
 func (f fieldSelector) SelectField(r record.Record) (record.Field, error) {
 	if r == nil {
 		return record.Field{}, fmt.Errorf("field not found")
 	}
 
-	return r.GetField(string(f))
+	fd, err := r.GetField(string(f))
+	if err == nil || !strings.ContainsAny(string(f), ".[") {
+		return fd, err
+	}
+
+	notFound := fmt.Errorf("field %q not found", string(f))
+
+	parts := splitPath(string(f))
+	fd, err = r.GetField(parts[0])
+	if err != nil {
+		return record.Field{}, notFound
+	}
+
+	for _, part := range parts[1:] {
+		if part[0] == '[' {
+			i, err := strconv.Atoi(part[1 : len(part)-1])
+			if err != nil {
+				return record.Field{}, notFound
+			}
+
+			values, err := fd.DecodeToArray()
+			if err != nil || i < 0 || i >= len(values) {
+				return record.Field{}, notFound
+			}
+
+			fd.Value = values[i]
+			continue
+		}
+
+		d, err := record.DecodeDocument(fd.Value)
+		if err != nil {
+			return record.Field{}, notFound
+		}
+
+		fd, err = d.GetField(part)
+		if err != nil {
+			return record.Field{}, notFound
+		}
+	}
+
+	fd.Name = string(f)
+	return fd, nil
+}
+
+// splitPath splits a path like "a.b[0]" into its parts: "a", "b" and "[0]".
+func splitPath(path string) []string {
+	var parts []string
+
+	for _, name := range strings.Split(path, ".") {
+		for name != "" {
+			i := strings.IndexByte(name[1:], '[')
+			if i < 0 {
+				parts = append(parts, name)
+				break
+			}
+
+			parts = append(parts, name[:i+1])
+			name = name[i+1:]
+		}
+	}
+
+	return parts
 }
 
 // Eval extracts the record from the context and selects the right field.
@@ -216,8 +358,41 @@ func (f fieldSelector) SelectField(r record.Record) (record.Field, error) {
 func (f fieldSelector) Eval(stack evalStack) (evalValue, error) {
 	fd, err := f.SelectField(stack.Record)
 	if err != nil {
-		return nilLitteral, nil
+		return nullLitteral, nil
 	}
 
 	return newSingleEvalValue(fd.Value), nil
 }
+
+// String returns f as an identifier, quoted if necessary.
+// Paths are returned unquoted if all their parts are identifiers.
+func (f fieldSelector) String() string {
+	if isIdent(string(f)) {
+		return string(f)
+	}
+
+	for _, part := range splitPath(string(f)) {
+		if part[0] != '[' && !isIdent(part) {
+			return quoteString(string(f), '"')
+		}
+	}
+
+	return string(f)
+}
+
+// isIdent returns true if s can be parsed as an unquoted identifier.
+func isIdent(s string) bool {
+	if s == "" || scanner.Lookup(s) != scanner.IDENT {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,108 @@
+package record
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+)
+
+// flate writers and readers allocate large buffers, they are reused between calls.
+var (
+	flateWriters sync.Pool
+	flateReaders sync.Pool
+)
+
+// Compress compresses an encoded record using DEFLATE and sets the compressed flag of the format.
+// The version and the flags are kept uncompressed, followed by the size of the uncompressed data
+// and the compressed header and body.
+// If compression doesn't reduce the size of the record, data is returned as is.
+// Compressed records can be decoded by Format, EncodedRecord and LazyRecord like any other record.
+func Compress(data []byte) ([]byte, error) {
+	_, flags, rest, err := decodeVersion(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if flags&compressedFlag != 0 {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(versionMarker)
+	buf.WriteByte(FormatVersion)
+	buf.WriteByte(flags | compressedFlag)
+
+	var intBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(intBuf[:], uint64(len(rest)))
+	buf.Write(intBuf[:n])
+
+	w, _ := flateWriters.Get().(*flate.Writer)
+	if w == nil {
+		w, err = flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		w.Reset(&buf)
+	}
+	defer flateWriters.Put(w)
+
+	_, err = w.Write(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if buf.Len() >= len(data) {
+		return data, nil
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decompress the data following the flags of a compressed record into buf.
+// If buf is too small, a new buffer is allocated.
+func decompress(data, buf []byte) ([]byte, error) {
+	size, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, errors.New("can't decode data")
+	}
+	data = data[n:]
+
+	// the compressed data can't be smaller than the uncompressed data
+	// by more than 1032 times, the maximum ratio of DEFLATE
+	if size > uint64(len(data))*1032 {
+		return nil, errors.New("can't decode data")
+	}
+
+	if uint64(cap(buf)) < size {
+		buf = make([]byte, size)
+	}
+	buf = buf[:size]
+
+	br := bytes.NewReader(data)
+	r, _ := flateReaders.Get().(io.ReadCloser)
+	if r == nil {
+		r = flate.NewReader(br)
+	} else {
+		err := r.(flate.Resetter).Reset(br, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	defer flateReaders.Put(r)
+
+	_, err := io.ReadFull(r, buf)
+	if err != nil {
+		return nil, errors.New("can't decompress data")
+	}
+
+	return buf, nil
+}
@@ -0,0 +1,80 @@
+package record_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/asdine/genji/record"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompress(t *testing.T) {
+	rec := record.FieldBuffer([]record.Field{
+		record.NewInt64Field("age", 10),
+		record.NewStringField("message", strings.Repeat("connection refused ", 50)),
+	})
+
+	data, err := record.Encode(rec)
+	require.NoError(t, err)
+
+	compressed, err := record.Compress(data)
+	require.NoError(t, err)
+	require.Less(t, len(compressed), len(data)/4)
+
+	v, err := record.DecodeVersion(compressed)
+	require.NoError(t, err)
+	require.Equal(t, record.FormatVersion, v)
+
+	// compressing twice has no effect
+	again, err := record.Compress(compressed)
+	require.NoError(t, err)
+	require.Equal(t, compressed, again)
+
+	var f record.Format
+	err = f.Decode(compressed)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, f.Header.FieldsCount)
+
+	fd, err := record.DecodeField(compressed, "message")
+	require.NoError(t, err)
+	require.Equal(t, rec[1], fd)
+
+	var fb record.FieldBuffer
+	err = fb.ScanRecord(record.EncodedRecord(compressed))
+	require.NoError(t, err)
+	require.Equal(t, rec, fb)
+
+	lr := record.NewLazyRecord(compressed)
+	fb = nil
+	err = fb.ScanRecord(lr)
+	require.NoError(t, err)
+	require.Equal(t, rec, fb)
+
+	// the decompression buffer is reused by the next record
+	other := record.FieldBuffer([]record.Field{
+		record.NewStringField("message", strings.Repeat("timeout ", 50)),
+	})
+	data, err = record.Encode(other)
+	require.NoError(t, err)
+	compressed, err = record.Compress(data)
+	require.NoError(t, err)
+
+	lr.Reset(compressed)
+	fd, err = lr.GetField("message")
+	require.NoError(t, err)
+	require.Equal(t, other[0], fd)
+
+	t.Run("Incompressible", func(t *testing.T) {
+		data, err := record.Encode(record.NewFieldBuffer(record.NewInt64Field("a", 1)))
+		require.NoError(t, err)
+
+		compressed, err := record.Compress(data)
+		require.NoError(t, err)
+		require.Equal(t, data, compressed)
+	})
+
+	t.Run("Corrupted", func(t *testing.T) {
+		_, err := record.DecodeField(compressed[:len(compressed)-5], "message")
+		require.Error(t, err)
+	})
+}
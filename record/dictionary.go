@@ -0,0 +1,117 @@
+package record
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var errNoDictionary = errors.New("record encoded with a dictionary, it can only be decoded using that dictionary")
+
+// A Dictionary associates field names with small integer identifiers, so that records
+// encoded with EncodeWithDictionary don't need to store the names of their fields.
+// Only the fields of the record itself are concerned, nested documents always
+// contain the names of their fields.
+type Dictionary interface {
+	// FieldID returns the identifier of a field name. It is only called while encoding
+	// a record and may associate an identifier to names that are not part of the dictionary yet.
+	FieldID(name string) (uint64, error)
+	// FieldName returns the name associated with an identifier.
+	FieldName(id uint64) (string, error)
+}
+
+// EncodeWithDictionary encodes r like Encode, but the field headers store the identifiers
+// of the names of the fields, as returned by d, instead of the names themselves.
+// The encoded record can only be decoded by a LazyRecord using the same dictionary.
+func EncodeWithDictionary(r Record, d Dictionary) ([]byte, error) {
+	var hbuf bytes.Buffer
+	var intBuf [binary.MaxVarintLen64]byte
+	var offset, count uint64
+	var dataList [][]byte
+
+	err := r.Iterate(func(f Field) error {
+		id, err := d.FieldID(f.Name)
+		if err != nil {
+			return err
+		}
+
+		for _, x := range []uint64{id, uint64(f.Type), uint64(len(f.Data)), offset} {
+			n := binary.PutUvarint(intBuf[:], x)
+			hbuf.Write(intBuf[:n])
+		}
+
+		offset += uint64(len(f.Data))
+		count++
+		dataList = append(dataList, f.Data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(versionMarker)
+	buf.WriteByte(FormatVersion)
+	buf.WriteByte(dictionaryFlag)
+
+	// header size, including the number of fields
+	n := binary.PutUvarint(intBuf[:], count)
+	hsize := binary.PutUvarint(intBuf[n:], uint64(n+hbuf.Len()))
+	buf.Write(intBuf[n : n+hsize])
+	buf.Write(intBuf[:n])
+	buf.Write(hbuf.Bytes())
+
+	buf.Grow(int(offset))
+	for _, data := range dataList {
+		buf.Write(data)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodeWithDictionary decodes a field header encoded by EncodeWithDictionary
+// and resolves its name using d.
+func (f *FieldHeader) decodeWithDictionary(data []byte, d Dictionary) (int, error) {
+	var read int
+	var values [4]uint64
+
+	for i := range values {
+		var n int
+		values[i], n = binary.Uvarint(data[read:])
+		if n <= 0 {
+			return 0, errors.New("can't decode data")
+		}
+		read += n
+	}
+
+	name, err := d.FieldName(values[0])
+	if err != nil {
+		return 0, err
+	}
+
+	f.nameString = name
+	f.NameSize = uint64(len(name))
+	f.Type = values[1]
+	f.Size = values[2]
+	f.Offset = values[3]
+
+	return read, nil
+}
+
+// name returns the name of the field.
+func (f *FieldHeader) name() string {
+	if f.Name == nil {
+		return f.nameString
+	}
+
+	return string(f.Name)
+}
+
+// hasName reports whether the name of the field is equal to name, without allocating.
+func (f *FieldHeader) hasName(name string) bool {
+	if f.Name == nil {
+		return f.nameString == name
+	}
+
+	return string(f.Name) == name
+}
@@ -0,0 +1,113 @@
+package record_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/asdine/genji/record"
+	"github.com/stretchr/testify/require"
+)
+
+type mapDictionary []string
+
+func (d *mapDictionary) FieldID(name string) (uint64, error) {
+	for i, n := range *d {
+		if n == name {
+			return uint64(i), nil
+		}
+	}
+
+	*d = append(*d, name)
+	return uint64(len(*d) - 1), nil
+}
+
+func (d *mapDictionary) FieldName(id uint64) (string, error) {
+	if id >= uint64(len(*d)) {
+		return "", errors.New("unknown field")
+	}
+
+	return (*d)[id], nil
+}
+
+func TestEncodeWithDictionary(t *testing.T) {
+	nested, err := record.NewDocumentField("nested_document", record.NewFieldBuffer(record.NewBoolField("is_valid", true)))
+	require.NoError(t, err)
+
+	rec := record.FieldBuffer([]record.Field{
+		record.NewInt64Field("customer_identifier", 10),
+		record.NewStringField("shipping_address_line", "john"),
+		nested,
+	})
+
+	var d mapDictionary
+	data, err := record.EncodeWithDictionary(rec, &d)
+	require.NoError(t, err)
+	require.Equal(t, mapDictionary{"customer_identifier", "shipping_address_line", "nested_document"}, d)
+
+	plain, err := record.Encode(rec)
+	require.NoError(t, err)
+	require.Less(t, len(data), len(plain)-50)
+
+	v, err := record.DecodeVersion(data)
+	require.NoError(t, err)
+	require.Equal(t, record.FormatVersion, v)
+
+	lr := record.NewLazyRecord(data)
+	lr.Dictionary = &d
+
+	f, err := lr.GetField("shipping_address_line")
+	require.NoError(t, err)
+	require.Equal(t, rec[1], f)
+
+	_, err = lr.GetField("unknown")
+	require.Error(t, err)
+
+	var fb record.FieldBuffer
+	err = fb.ScanRecord(lr)
+	require.NoError(t, err)
+	require.Equal(t, rec, fb)
+
+	// the dictionary is kept when the record is reset
+	other, err := record.EncodeWithDictionary(record.NewFieldBuffer(record.NewStringField("shipping_address_line", "jane")), &d)
+	require.NoError(t, err)
+	lr.Reset(other)
+	f, err = lr.GetField("shipping_address_line")
+	require.NoError(t, err)
+	require.Equal(t, record.NewStringField("shipping_address_line", "jane"), f)
+
+	t.Run("Compressed", func(t *testing.T) {
+		rec := record.NewFieldBuffer(record.NewStringField("message", strings.Repeat("connection refused ", 50)))
+		data, err := record.EncodeWithDictionary(rec, &d)
+		require.NoError(t, err)
+
+		compressed, err := record.Compress(data)
+		require.NoError(t, err)
+		require.Less(t, len(compressed), len(data))
+
+		lr := record.NewLazyRecord(compressed)
+		lr.Dictionary = &d
+		var fb record.FieldBuffer
+		err = fb.ScanRecord(lr)
+		require.NoError(t, err)
+		require.Equal(t, rec, fb)
+	})
+
+	t.Run("Without dictionary", func(t *testing.T) {
+		_, err := record.NewLazyRecord(data).GetField("shipping_address_line")
+		require.Error(t, err)
+
+		_, err = record.EncodedRecord(data).GetField("shipping_address_line")
+		require.Error(t, err)
+
+		_, err = record.DecodeField(data, "shipping_address_line")
+		require.Error(t, err)
+	})
+
+	t.Run("Unknown identifier", func(t *testing.T) {
+		lr := record.NewLazyRecord(data)
+		lr.Dictionary = new(mapDictionary)
+		_, err := lr.GetField("shipping_address_line")
+		require.Error(t, err)
+	})
+}
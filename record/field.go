@@ -2,6 +2,8 @@ package record
 
 import (
 	"fmt"
+	"math/big"
+	"time"
 
 	"github.com/asdine/genji/value"
 )
@@ -14,8 +16,9 @@ type Field struct {
 }
 
 // NewField creates a field whose type is infered from x.
+// See NewValue for the list of supported types.
 func NewField(name string, x interface{}) (Field, error) {
-	v, err := value.New(x)
+	v, err := NewValue(x)
 	if err != nil {
 		return Field{}, err
 	}
@@ -23,6 +26,30 @@ func NewField(name string, x interface{}) (Field, error) {
 	return Field{Name: name, Value: v}, nil
 }
 
+// NewValue creates a value whose type is infered from x.
+// In addition to the types supported by value.New, records and maps of type map[string]interface{}
+// are stored as documents, and slices of type []interface{} are stored as arrays.
+func NewValue(x interface{}) (value.Value, error) {
+	switch t := x.(type) {
+	case Record:
+		return NewDocumentValue(t)
+	case map[string]interface{}:
+		return NewDocumentValue(NewFromMap(t))
+	case []interface{}:
+		values := make([]value.Value, len(t))
+		for i := range t {
+			v, err := NewValue(t[i])
+			if err != nil {
+				return value.Value{}, err
+			}
+			values[i] = v
+		}
+		return value.NewArray(values...), nil
+	}
+
+	return value.New(x)
+}
+
 // NewBytesField encodes x and returns a field.
 func NewBytesField(name string, x []byte) Field {
 	return Field{
@@ -143,6 +170,75 @@ func NewFloat64Field(name string, x float64) Field {
 	}
 }
 
+// NewTimestampField encodes x and returns a field.
+func NewTimestampField(name string, x time.Time) Field {
+	return Field{
+		Name:  name,
+		Value: value.NewTimestamp(x),
+	}
+}
+
+// NewDurationField encodes x and returns a field.
+func NewDurationField(name string, x time.Duration) Field {
+	return Field{
+		Name:  name,
+		Value: value.NewDuration(x),
+	}
+}
+
+// NewDecimalField encodes x and returns a field.
+func NewDecimalField(name string, x *big.Rat) Field {
+	return Field{
+		Name:  name,
+		Value: value.NewDecimal(x),
+	}
+}
+
+// NewUUIDField encodes x and returns a field.
+func NewUUIDField(name string, x [16]byte) Field {
+	return Field{
+		Name:  name,
+		Value: value.NewUUID(x),
+	}
+}
+
+// NewDocumentField encodes r and returns a field of type Document.
+func NewDocumentField(name string, r Record) (Field, error) {
+	v, err := NewDocumentValue(r)
+	if err != nil {
+		return Field{}, err
+	}
+
+	return Field{Name: name, Value: v}, nil
+}
+
+// NewDocumentValue encodes r and returns a value of type Document.
+func NewDocumentValue(r Record) (value.Value, error) {
+	data, err := Encode(r)
+	if err != nil {
+		return value.Value{}, err
+	}
+
+	return value.NewDocument(data), nil
+}
+
+// DecodeDocument returns the record stored in a value of type Document.
+func DecodeDocument(v value.Value) (Record, error) {
+	if v.Type != value.Document {
+		return nil, fmt.Errorf("can't convert %q to document", v.Type)
+	}
+
+	return EncodedRecord(v.Data), nil
+}
+
+// NewNullField returns a field whose value is null.
+func NewNullField(name string) Field {
+	return Field{
+		Name:  name,
+		Value: value.NewNull(),
+	}
+}
+
 func (f Field) String() string {
 	return fmt.Sprintf("%s:%s", f.Name, f.Value)
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/asdine/genji/record"
+	"github.com/asdine/genji/value"
 	"github.com/stretchr/testify/require"
 )
 
@@ -36,3 +37,18 @@ func TestFieldString(t *testing.T) {
 		})
 	}
 }
+
+func TestNewDocumentField(t *testing.T) {
+	f, err := record.NewField("address", map[string]interface{}{"city": "Lyon"})
+	require.NoError(t, err)
+	require.Equal(t, value.Document, f.Type)
+
+	d, err := record.DecodeDocument(f.Value)
+	require.NoError(t, err)
+	city, err := d.GetField("city")
+	require.NoError(t, err)
+	require.Equal(t, record.NewStringField("city", "Lyon"), city)
+
+	_, err = record.DecodeDocument(value.NewString("Lyon"))
+	require.Error(t, err)
+}
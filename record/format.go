@@ -10,17 +10,52 @@ import (
 	"github.com/asdine/genji/value"
 )
 
+// FormatVersion is the version of the format used by Encode.
+// It must be incremented every time the encoding changes, and the decoders
+// must keep supporting every previous version.
+//
+// Versions:
+//
+//	0: records start with the header, without version marker
+//	1: the header is preceded by the version marker and the version byte
+//	2: the version byte is followed by a byte of flags, see Compress
+//	3: adds the dictionary flag, see EncodeWithDictionary
+const FormatVersion uint8 = 3
+
+// versionMarker precedes the version of the format at the beginning of an encoded record.
+// Records encoded before the format was versioned start with the size of their header,
+// which is never zero, so they can't be confused with versioned records.
+const versionMarker byte = 0x00
+
+// Flags stored after the version byte.
+const (
+	// compressedFlag indicates that the header and the body are compressed.
+	compressedFlag byte = 1 << iota
+	// dictionaryFlag indicates that field headers store the identifier of the name
+	// of the fields instead of the names themselves.
+	dictionaryFlag
+)
+
 // Format is an encoding format used to encode and decode records.
-// It is composed of a header and a body.
+// It is composed of a version, a header and a body.
 // The header defines a list of fields, offsets and relevant metadata.
 // The body contains each fields data one concatenated one after another.
 type Format struct {
-	Header Header
-	Body   []byte
+	Version uint8
+	Header  Header
+	Body    []byte
 }
 
 // Decode the given data into the format.
+// Compressed records are decompressed first.
 func (f *Format) Decode(data []byte) error {
+	var err error
+
+	f.Version, data, err = decodePrefix(data, nil)
+	if err != nil {
+		return err
+	}
+
 	n, err := f.Header.Decode(data)
 	if err != nil {
 		return err
@@ -30,6 +65,76 @@ func (f *Format) Decode(data []byte) error {
 	return nil
 }
 
+// DecodeVersion returns the version of the format used to encode data.
+func DecodeVersion(data []byte) (uint8, error) {
+	v, _, _, err := decodeVersion(data)
+	return v, err
+}
+
+// decodeVersion reads the version of the format and the flags and returns them alongside
+// the rest of the data, starting at the header or, if the record is compressed, at the size
+// of the uncompressed data.
+func decodeVersion(data []byte) (uint8, byte, []byte, error) {
+	if len(data) == 0 {
+		return 0, 0, nil, errors.New("can't decode data")
+	}
+
+	if data[0] != versionMarker {
+		return 0, 0, data, nil
+	}
+
+	if len(data) < 2 {
+		return 0, 0, nil, errors.New("can't decode data")
+	}
+
+	v := data[1]
+	switch {
+	case v == 0 || v > FormatVersion:
+		return 0, 0, nil, fmt.Errorf("unsupported record format version %d", v)
+	case v == 1:
+		return v, 0, data[2:], nil
+	}
+
+	if len(data) < 3 {
+		return 0, 0, nil, errors.New("can't decode data")
+	}
+
+	flags := data[2]
+	supported := compressedFlag
+	if v >= 3 {
+		supported |= dictionaryFlag
+	}
+	if flags&^supported != 0 {
+		return 0, 0, nil, fmt.Errorf("unsupported record format flags %#x", flags)
+	}
+
+	return v, flags, data[3:], nil
+}
+
+// decodePrefix reads the version and the flags of the format and returns the data
+// starting at the header. Compressed records are decompressed into buf, which is
+// allocated if it is too small.
+// Records encoded with a dictionary can only be decoded by a LazyRecord.
+func decodePrefix(data, buf []byte) (uint8, []byte, error) {
+	v, flags, data, err := decodeVersion(data)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if flags&dictionaryFlag != 0 {
+		return 0, nil, errNoDictionary
+	}
+
+	if flags&compressedFlag != 0 {
+		data, err = decompress(data, buf)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	return v, data, nil
+}
+
 // A Header contains a representation of a record's metadata.
 type Header struct {
 	// Size of the header
@@ -129,7 +234,7 @@ type FieldHeader struct {
 	// from the end of the format header.
 	Offset uint64
 
-	nameString string // used for encoding
+	nameString string // used for encoding and set when decoded with a dictionary
 	buf        [binary.MaxVarintLen64]byte
 }
 
@@ -252,6 +357,11 @@ func Encode(r Record) ([]byte, error) {
 	}
 
 	var buf bytes.Buffer
+	buf.WriteByte(versionMarker)
+	buf.WriteByte(FormatVersion)
+	// flags
+	buf.WriteByte(0)
+
 	_, err = format.Header.WriteTo(&buf)
 	if err != nil {
 		return nil, err
@@ -271,6 +381,11 @@ func Encode(r Record) ([]byte, error) {
 
 // DecodeField reads a single field from data without decoding the entire data.
 func DecodeField(data []byte, fieldName string) (Field, error) {
+	_, data, err := decodePrefix(data, nil)
+	if err != nil {
+		return Field{}, err
+	}
+
 	hsize, n := binary.Uvarint(data)
 	if n <= 0 {
 		return Field{}, errors.New("can't decode data")
@@ -342,3 +457,228 @@ func (e EncodedRecord) Iterate(fn func(Field) error) error {
 
 	return nil
 }
+
+// A LazyRecord implements the record interface on top of an encoded representation of a record,
+// like EncodedRecord, but decodes its header progressively and only once: each field header
+// is decoded the first time it is needed and then reused by every subsequent call to GetField and Iterate.
+// This makes it suitable for records that are read several times, for example when evaluating
+// a condition on multiple fields.
+// The returned fields reference the encoded data, they are never copied. If the record is compressed,
+// they reference a decompression buffer that is reused by the next record passed to Reset.
+type LazyRecord struct {
+	EncodedRecord
+
+	// Dictionary used to decode the records encoded with EncodeWithDictionary.
+	// It is kept by Reset.
+	Dictionary Dictionary
+
+	headers []FieldHeader // field headers decoded so far
+	hdata   []byte        // part of the header that remains to be decoded
+	body    []byte
+	buf     []byte // decompressed header and body, if the record is compressed
+	ids     bool   // field headers contain identifiers instead of names
+	started bool
+}
+
+// NewLazyRecord creates a LazyRecord from the given encoded record.
+func NewLazyRecord(data []byte) *LazyRecord {
+	return &LazyRecord{EncodedRecord: data}
+}
+
+// Reset the record to use the given encoded record.
+// The memory used by the decoded field headers is reused for the new record.
+func (r *LazyRecord) Reset(data []byte) {
+	r.EncodedRecord = data
+	r.headers = r.headers[:0]
+	r.hdata = nil
+	r.body = nil
+	r.started = false
+}
+
+// start decodes the version and the size of the header.
+func (r *LazyRecord) start() error {
+	if r.started {
+		return nil
+	}
+
+	_, flags, data, err := decodeVersion(r.EncodedRecord)
+	if err != nil {
+		return err
+	}
+
+	r.ids = flags&dictionaryFlag != 0
+	if r.ids && r.Dictionary == nil {
+		return errNoDictionary
+	}
+
+	// the buffer is kept to be reused by the next records
+	if flags&compressedFlag != 0 {
+		r.buf, err = decompress(data, r.buf)
+		if err != nil {
+			return err
+		}
+		data = r.buf
+	}
+
+	hsize, n := binary.Uvarint(data)
+	if n <= 0 || uint64(len(data)-n) < hsize {
+		return errors.New("can't decode data")
+	}
+
+	hdata := data[n : n+int(hsize)]
+	r.body = data[n+int(hsize):]
+
+	// skip number of fields
+	_, n = binary.Uvarint(hdata)
+	if n <= 0 {
+		return errors.New("can't decode data")
+	}
+	r.hdata = hdata[n:]
+
+	r.started = true
+	return nil
+}
+
+// next decodes the next field header, if any.
+func (r *LazyRecord) next() (bool, error) {
+	if len(r.hdata) == 0 {
+		return false, nil
+	}
+
+	var fh FieldHeader
+	var n int
+	var err error
+	if r.ids {
+		n, err = fh.decodeWithDictionary(r.hdata, r.Dictionary)
+	} else {
+		n, err = fh.Decode(r.hdata)
+	}
+	if err != nil {
+		return false, err
+	}
+	r.hdata = r.hdata[n:]
+
+	if fh.Offset+fh.Size > uint64(len(r.body)) {
+		return false, errors.New("can't decode data")
+	}
+
+	r.headers = append(r.headers, fh)
+	return true, nil
+}
+
+func (r *LazyRecord) field(fh *FieldHeader, name string) Field {
+	return Field{
+		Name: name,
+		Value: value.Value{
+			Type: value.Type(fh.Type),
+			Data: r.body[fh.Offset : fh.Offset+fh.Size],
+		},
+	}
+}
+
+// GetField returns a field by name, without decoding its data.
+func (r *LazyRecord) GetField(name string) (Field, error) {
+	err := r.start()
+	if err != nil {
+		return Field{}, err
+	}
+
+	for i := range r.headers {
+		if r.headers[i].hasName(name) {
+			return r.field(&r.headers[i], name), nil
+		}
+	}
+
+	for {
+		ok, err := r.next()
+		if err != nil {
+			return Field{}, err
+		}
+		if !ok {
+			return Field{}, fmt.Errorf("field %s not found", name)
+		}
+
+		fh := &r.headers[len(r.headers)-1]
+		if fh.hasName(name) {
+			return r.field(fh, name), nil
+		}
+	}
+}
+
+// Iterate goes through all the fields of the record and calls fn for each one of them,
+// until the end of the record or until fn returns an error.
+func (r *LazyRecord) Iterate(fn func(Field) error) error {
+	err := r.start()
+	if err != nil {
+		return err
+	}
+
+	for i := 0; ; i++ {
+		if i == len(r.headers) {
+			ok, err := r.next()
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return nil
+			}
+		}
+
+		fh := &r.headers[i]
+		err = fn(r.field(fh, fh.name()))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Upgrade returns a copy of r whose nested documents, including the ones stored in arrays,
+// are encoded with the latest version of the format, so that encoding the returned record
+// rewrites the whole record using that version.
+// r can be a record encoded with any supported version of the format.
+func Upgrade(r Record) (FieldBuffer, error) {
+	var fb FieldBuffer
+
+	err := r.Iterate(func(f Field) error {
+		v, err := upgradeValue(f.Value)
+		if err != nil {
+			return err
+		}
+
+		fb.Add(Field{Name: f.Name, Value: v})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fb, nil
+}
+
+func upgradeValue(v value.Value) (value.Value, error) {
+	switch v.Type {
+	case value.Document:
+		fb, err := Upgrade(EncodedRecord(v.Data))
+		if err != nil {
+			return v, err
+		}
+
+		return NewDocumentValue(fb)
+	case value.Array:
+		values, err := v.DecodeToArray()
+		if err != nil {
+			return v, err
+		}
+
+		for i := range values {
+			values[i], err = upgradeValue(values[i])
+			if err != nil {
+				return v, err
+			}
+		}
+
+		return value.NewArray(values...), nil
+	}
+
+	return v, nil
+}
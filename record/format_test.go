@@ -68,6 +68,13 @@ func TestEncodeDecode(t *testing.T) {
 				record.NewStringField("name", "john"),
 			}),
 		},
+		{
+			"Null",
+			record.FieldBuffer([]record.Field{
+				record.NewNullField("age"),
+				record.NewStringField("name", "john"),
+			}),
+		},
 		{
 			"Map",
 			record.NewFromMap(map[string]interface{}{
@@ -115,6 +122,142 @@ func TestEncodedRecord(t *testing.T) {
 	require.Equal(t, 2, i)
 }
 
+func TestLazyRecord(t *testing.T) {
+	rec := record.FieldBuffer([]record.Field{
+		record.NewInt64Field("age", 10),
+		record.NewStringField("name", "john"),
+	})
+
+	data, err := record.Encode(rec)
+	require.NoError(t, err)
+
+	lr := record.NewLazyRecord(data)
+	f, err := lr.GetField("name")
+	require.NoError(t, err)
+	require.Equal(t, rec[1], f)
+
+	_, err = lr.GetField("foo")
+	require.Error(t, err)
+
+	var fb record.FieldBuffer
+	err = fb.ScanRecord(lr)
+	require.NoError(t, err)
+	require.Equal(t, rec, fb)
+
+	// the header must be decoded again after a reset
+	other := record.FieldBuffer([]record.Field{
+		record.NewBoolField("name", true),
+	})
+	data, err = record.Encode(other)
+	require.NoError(t, err)
+
+	lr.Reset(data)
+	f, err = lr.GetField("name")
+	require.NoError(t, err)
+	require.Equal(t, other[0], f)
+
+	_, err = lr.GetField("age")
+	require.Error(t, err)
+
+	lr.Reset([]byte{0, record.FormatVersion + 1})
+	_, err = lr.GetField("name")
+	require.Error(t, err)
+}
+
+func TestFormatVersion(t *testing.T) {
+	rec := record.FieldBuffer([]record.Field{
+		record.NewInt64Field("age", 10),
+		record.NewStringField("name", "john"),
+	})
+
+	data, err := record.Encode(rec)
+	require.NoError(t, err)
+
+	v, err := record.DecodeVersion(data)
+	require.NoError(t, err)
+	require.Equal(t, record.FormatVersion, v)
+
+	t.Run("Version 0", func(t *testing.T) {
+		// records of version 0 have no version marker nor flags
+		old := data[3:]
+
+		var f record.Format
+		err = f.Decode(old)
+		require.NoError(t, err)
+		require.EqualValues(t, 0, f.Version)
+		require.EqualValues(t, 2, f.Header.FieldsCount)
+
+		fd, err := record.DecodeField(old, "name")
+		require.NoError(t, err)
+		require.Equal(t, rec[1], fd)
+
+		var fb record.FieldBuffer
+		err = fb.ScanRecord(record.EncodedRecord(old))
+		require.NoError(t, err)
+		require.Equal(t, rec, fb)
+	})
+
+	t.Run("Version 1", func(t *testing.T) {
+		// records of version 1 have no flags
+		old := append([]byte{0, 1}, data[3:]...)
+
+		v, err := record.DecodeVersion(old)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, v)
+
+		fd, err := record.DecodeField(old, "name")
+		require.NoError(t, err)
+		require.Equal(t, rec[1], fd)
+
+		var fb record.FieldBuffer
+		err = fb.ScanRecord(record.NewLazyRecord(old))
+		require.NoError(t, err)
+		require.Equal(t, rec, fb)
+	})
+
+	t.Run("Unsupported version", func(t *testing.T) {
+		newer := append([]byte{0, record.FormatVersion + 1}, data[3:]...)
+
+		_, err := record.DecodeVersion(newer)
+		require.Error(t, err)
+
+		var f record.Format
+		err = f.Decode(newer)
+		require.Error(t, err)
+
+		_, err = record.DecodeField(newer, "name")
+		require.Error(t, err)
+	})
+
+	t.Run("Upgrade", func(t *testing.T) {
+		doc := data[3:]
+		outer, err := record.Encode(record.NewFieldBuffer(
+			record.Field{Name: "doc", Value: value.Value{Type: value.Document, Data: doc}},
+			record.Field{Name: "docs", Value: value.NewArray(value.Value{Type: value.Document, Data: doc})},
+		))
+		require.NoError(t, err)
+
+		fb, err := record.Upgrade(record.EncodedRecord(outer[3:]))
+		require.NoError(t, err)
+		upgraded, err := record.Encode(fb)
+		require.NoError(t, err)
+
+		v, err := record.DecodeVersion(upgraded)
+		require.NoError(t, err)
+		require.Equal(t, record.FormatVersion, v)
+
+		f, err := record.DecodeField(upgraded, "doc")
+		require.NoError(t, err)
+		require.Equal(t, data, f.Data)
+
+		f, err = record.DecodeField(upgraded, "docs")
+		require.NoError(t, err)
+		values, err := f.DecodeToArray()
+		require.NoError(t, err)
+		require.Equal(t, data, values[0].Data)
+	})
+}
+
 func BenchmarkEncode(b *testing.B) {
 	var fields []record.Field
 
@@ -178,3 +321,55 @@ func BenchmarkEncodedRecord(b *testing.B) {
 		})
 	}
 }
+
+// wideRecord returns an encoded record with 100 fields.
+func wideRecord(b *testing.B) []byte {
+	var fields []record.Field
+
+	for i := int64(0); i < 100; i++ {
+		fields = append(fields, record.NewInt64Field(fmt.Sprintf("name-%d", i), i))
+	}
+	data, err := record.Encode(record.FieldBuffer(fields))
+	require.NoError(b, err)
+
+	return data
+}
+
+// BenchmarkEncodedRecordGetFields benchmarks reading three fields of a wide record,
+// like a condition on three fields would.
+func BenchmarkEncodedRecordGetFields(b *testing.B) {
+	ec := record.EncodedRecord(wideRecord(b))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ec.GetField("name-10")
+		ec.GetField("name-50")
+		ec.GetField("name-99")
+	}
+}
+
+func BenchmarkLazyRecordGetFields(b *testing.B) {
+	data := wideRecord(b)
+	var lr record.LazyRecord
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lr.Reset(data)
+		lr.GetField("name-10")
+		lr.GetField("name-50")
+		lr.GetField("name-99")
+	}
+}
+
+func BenchmarkLazyRecordIterate(b *testing.B) {
+	data := wideRecord(b)
+	var lr record.LazyRecord
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lr.Reset(data)
+		lr.Iterate(func(record.Field) error {
+			return nil
+		})
+	}
+}
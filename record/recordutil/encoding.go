@@ -2,30 +2,236 @@ package recordutil
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"reflect"
+	"time"
 
 	"github.com/asdine/genji/record"
+	"github.com/asdine/genji/value"
 )
 
 // DumpRecord is helper that dumps the name, type and value of each field of a record into the given writer.
+// Fields of nested documents are dumped on the same line, between braces, and elements of arrays
+// between brackets.
 func DumpRecord(w io.Writer, r record.Record) error {
 	return r.Iterate(func(f record.Field) error {
-		v, err := f.Decode()
-		fmt.Fprintf(w, "%s(%s): %#v\n", f.Name, f.Type, v)
+		err := dumpField(w, f)
+		fmt.Fprintln(w)
 		return err
 	})
 }
 
+func dumpField(w io.Writer, f record.Field) error {
+	fmt.Fprintf(w, "%s(%s): ", f.Name, f.Type)
+
+	return dumpValue(w, f.Value)
+}
+
+func dumpValue(w io.Writer, v value.Value) error {
+	switch v.Type {
+	case value.Document:
+		return dumpDocument(w, v)
+	case value.Array:
+		values, err := v.DecodeToArray()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(w, "[")
+		for i := range values {
+			if i > 0 {
+				fmt.Fprint(w, ", ")
+			}
+
+			if values[i].Type != value.Document && values[i].Type != value.Array {
+				fmt.Fprintf(w, "%s(", values[i].Type)
+				err = dumpValue(w, values[i])
+				fmt.Fprint(w, ")")
+			} else {
+				err = dumpValue(w, values[i])
+			}
+			if err != nil {
+				return err
+			}
+		}
+		fmt.Fprint(w, "]")
+		return nil
+	case value.Timestamp, value.UUID:
+		fmt.Fprintf(w, "%q", v.String())
+		return nil
+	case value.Duration, value.Decimal:
+		fmt.Fprint(w, v.String())
+		return nil
+	}
+
+	x, err := v.Decode()
+	fmt.Fprintf(w, "%#v", x)
+	return err
+}
+
+func dumpDocument(w io.Writer, v value.Value) error {
+	d, err := record.DecodeDocument(v)
+	if err != nil {
+		return err
+	}
+
+	var notFirst bool
+	fmt.Fprint(w, "{")
+	err = d.Iterate(func(f record.Field) error {
+		if notFirst {
+			fmt.Fprint(w, ", ")
+		}
+		notFirst = true
+
+		return dumpField(w, f)
+	})
+	fmt.Fprint(w, "}")
+	return err
+}
+
 // RecordToJSON encodes r to w in JSON.
 func RecordToJSON(w io.Writer, r record.Record) error {
 	return json.NewEncoder(w).Encode(jsonRecord{r})
 }
 
+// RecordFromJSON decodes a JSON object into a record, preserving the order of its fields.
+// Nested objects are decoded as documents, arrays as arrays, integers as int64 and other numbers as float64.
+func RecordFromJSON(data []byte) (record.Record, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if t != json.Delim('{') {
+		return nil, errors.New("expected JSON object")
+	}
+
+	return decodeJSONFields(dec)
+}
+
+// decodeJSONFields decodes the fields of an object whose opening brace
+// has already been consumed.
+func decodeJSONFields(dec *json.Decoder) (*record.FieldBuffer, error) {
+	var fb record.FieldBuffer
+
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := decodeJSONValue(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		fb.Add(record.Field{Name: t.(string), Value: v})
+	}
+
+	// consume the closing brace
+	_, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	return &fb, nil
+}
+
+func decodeJSONValue(dec *json.Decoder) (value.Value, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return value.Value{}, err
+	}
+
+	switch v := t.(type) {
+	case nil:
+		return value.NewNull(), nil
+	case bool:
+		return value.NewBool(v), nil
+	case string:
+		return value.NewString(v), nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return value.NewInt64(i), nil
+		}
+
+		f, err := v.Float64()
+		if err != nil {
+			return value.Value{}, err
+		}
+		return value.NewFloat64(f), nil
+	case json.Delim:
+		switch v {
+		case '{':
+			fb, err := decodeJSONFields(dec)
+			if err != nil {
+				return value.Value{}, err
+			}
+			return record.NewDocumentValue(fb)
+		case '[':
+			var values []value.Value
+			for dec.More() {
+				v, err := decodeJSONValue(dec)
+				if err != nil {
+					return value.Value{}, err
+				}
+				values = append(values, v)
+			}
+
+			// consume the closing bracket
+			_, err := dec.Token()
+			if err != nil {
+				return value.Value{}, err
+			}
+			return value.NewArray(values...), nil
+		}
+	}
+
+	return value.Value{}, fmt.Errorf("unsupported JSON token %v", t)
+}
+
+// jsonValue returns a representation of v that can be encoded in JSON.
+func jsonValue(v value.Value) (interface{}, error) {
+	switch v.Type {
+	case value.Document:
+		d, err := record.DecodeDocument(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return jsonRecord{d}, nil
+	case value.Array:
+		values, err := v.DecodeToArray()
+		if err != nil {
+			return nil, err
+		}
+
+		l := make([]interface{}, len(values))
+		for i := range values {
+			l[i], err = jsonValue(values[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+		return l, nil
+	case value.Decimal:
+		// encoded as a JSON number without going through a float
+		return json.Number(v.String()), nil
+	case value.UUID:
+		return v.String(), nil
+	}
+
+	return v.Decode()
+}
+
 type jsonRecord struct {
 	record.Record
 }
@@ -42,7 +248,7 @@ func (j jsonRecord) MarshalJSON() ([]byte, error) {
 		}
 		notFirst = true
 
-		v, err := f.Decode()
+		v, err := jsonValue(f.Value)
 		if err != nil {
 			return err
 		}
@@ -76,6 +282,27 @@ func IteratorToCSV(w io.Writer, s record.Iterator) error {
 		line = line[:0]
 
 		err := r.Iterate(func(f record.Field) error {
+			// documents and arrays are written in JSON
+			if f.Type == value.Document || f.Type == value.Array {
+				v, err := jsonValue(f.Value)
+				if err != nil {
+					return err
+				}
+
+				data, err := json.Marshal(v)
+				if err != nil {
+					return err
+				}
+
+				line = append(line, string(data))
+				return nil
+			}
+
+			if f.Type == value.Decimal || f.Type == value.UUID {
+				line = append(line, f.Value.String())
+				return nil
+			}
+
 			v, err := f.Decode()
 			if err != nil {
 				return err
@@ -109,7 +336,8 @@ func IteratorToJSON(w io.Writer, s record.Iterator) error {
 }
 
 // Scan a record into the given variables. Each variable must be a pointer to
-// types supported by Genji.
+// types supported by Genji or implement the sql.Scanner interface, like sql.NullString.
+// Null values are scanned as nil by sql.Scanner implementations and as the zero value otherwise.
 func Scan(r record.Record, targets ...interface{}) error {
 	var i int
 
@@ -131,6 +359,29 @@ func Scan(r record.Record, targets ...interface{}) error {
 			return errors.New("target must be pointer to a valid Go type")
 		}
 
+		if sc, ok := targets[i].(sql.Scanner); ok {
+			i++
+
+			// uuids are passed in their text form, like database/sql does
+			if f.Type == value.UUID {
+				return sc.Scan(f.Value.String())
+			}
+
+			v, err := f.Decode()
+			if err != nil {
+				return err
+			}
+
+			return sc.Scan(v)
+		}
+
+		// null values reset the target to its zero value
+		if f.Type == value.Null {
+			ref.Elem().Set(reflect.Zero(ref.Elem().Type()))
+			i++
+			return nil
+		}
+
 		switch t := targets[i].(type) {
 		case *uint:
 			x, err := f.DecodeToUint()
@@ -229,6 +480,27 @@ func Scan(r record.Record, targets ...interface{}) error {
 				return err
 			}
 
+			*t = x
+		case *big.Rat:
+			x, err := f.DecodeToDecimal()
+			if err != nil {
+				return err
+			}
+
+			t.Set(x)
+		case *time.Time:
+			x, err := f.DecodeToTimestamp()
+			if err != nil {
+				return err
+			}
+
+			*t = x
+		case *time.Duration:
+			x, err := f.DecodeToDuration()
+			if err != nil {
+				return err
+			}
+
 			*t = x
 		case *bool:
 			x, err := f.DecodeToBool()
@@ -236,9 +508,27 @@ func Scan(r record.Record, targets ...interface{}) error {
 				return err
 			}
 
+			*t = x
+		case *[16]byte:
+			x, err := f.DecodeToUUID()
+			if err != nil {
+				return err
+			}
+
 			*t = x
 		default:
-			return errors.New("unsupported type")
+			// UUID types of other packages are usually defined as [16]byte
+			elem := ref.Elem()
+			if elem.Kind() != reflect.Array || elem.Len() != 16 || elem.Type().Elem().Kind() != reflect.Uint8 {
+				return errors.New("unsupported type")
+			}
+
+			x, err := f.DecodeToUUID()
+			if err != nil {
+				return err
+			}
+
+			reflect.Copy(elem, reflect.ValueOf(x[:]))
 		}
 		i++
 		return nil
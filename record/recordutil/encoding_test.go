@@ -2,8 +2,11 @@ package recordutil_test
 
 import (
 	"bytes"
+	"database/sql"
 	"fmt"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/asdine/genji/record"
 	"github.com/asdine/genji/record/recordutil"
@@ -65,6 +68,43 @@ func TestRecordToJSON(t *testing.T) {
 	}
 }
 
+func TestRecordToJSONDocument(t *testing.T) {
+	data := `{"name":"John","address":{"city":"Lyon","zip":null,"location":{"lat":45.76,"lon":4.83}},"age":10}`
+
+	r, err := recordutil.RecordFromJSON([]byte(data))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = recordutil.DumpRecord(&buf, r)
+	require.NoError(t, err)
+	require.Equal(t, `name(String): "John"
+address(Document): {city(String): "Lyon", zip(Null): <nil>, location(Document): {lat(Float64): 45.76, lon(Float64): 4.83}}
+age(Int64): 10
+`, buf.String())
+
+	buf.Reset()
+	err = recordutil.RecordToJSON(&buf, r)
+	require.NoError(t, err)
+	require.Equal(t, data+"\n", buf.String())
+
+	data = `{"tags":["a",1,{"b":[]}]}`
+	r, err = recordutil.RecordFromJSON([]byte(data))
+	require.NoError(t, err)
+
+	buf.Reset()
+	err = recordutil.DumpRecord(&buf, r)
+	require.NoError(t, err)
+	require.Equal(t, `tags(Array): [String("a"), Int64(1), {b(Array): []}]`+"\n", buf.String())
+
+	buf.Reset()
+	err = recordutil.RecordToJSON(&buf, r)
+	require.NoError(t, err)
+	require.Equal(t, data+"\n", buf.String())
+
+	_, err = recordutil.RecordFromJSON([]byte(`[1, 2]`))
+	require.Error(t, err)
+}
+
 func TestScan(t *testing.T) {
 	r := record.FieldBuffer([]record.Field{
 		record.NewBytesField("a", []byte("foo")),
@@ -82,6 +122,8 @@ func TestScan(t *testing.T) {
 		record.NewInt64Field("m", 10),
 		record.NewFloat32Field("n", 10.4),
 		record.NewFloat64Field("o", 10.5),
+		record.NewTimestampField("p", time.Date(2019, 11, 3, 10, 0, 0, 0, time.UTC)),
+		record.NewDurationField("q", time.Hour),
 	})
 
 	var a []byte
@@ -99,8 +141,10 @@ func TestScan(t *testing.T) {
 	var m int64
 	var n float32
 	var o float64
+	var p time.Time
+	var q time.Duration
 
-	err := recordutil.Scan(r, &a, &b, &c, &d, &e, &f, &g, &h, &i, &j, &k, &l, &m, &n, &o)
+	err := recordutil.Scan(r, &a, &b, &c, &d, &e, &f, &g, &h, &i, &j, &k, &l, &m, &n, &o, &p, &q)
 	require.NoError(t, err)
 	require.Equal(t, a, []byte("foo"))
 	require.Equal(t, b, "bar")
@@ -117,6 +161,8 @@ func TestScan(t *testing.T) {
 	require.Equal(t, m, int64(10))
 	require.Equal(t, n, float32(10.4))
 	require.Equal(t, o, float64(10.5))
+	require.Equal(t, p, time.Date(2019, 11, 3, 10, 0, 0, 0, time.UTC))
+	require.Equal(t, q, time.Hour)
 
 	t.Run("RecordScanner", func(t *testing.T) {
 		var rs recordScanner
@@ -127,6 +173,77 @@ func TestScan(t *testing.T) {
 		err := recordutil.Scan(r, &rs)
 		require.NoError(t, err)
 	})
+
+	t.Run("Null", func(t *testing.T) {
+		r := record.NewFieldBuffer(
+			record.NewNullField("a"),
+			record.NewNullField("b"),
+			record.NewStringField("c", "foo"),
+		)
+
+		a := 10
+		var b, c sql.NullString
+		err := recordutil.Scan(r, &a, &b, &c)
+		require.NoError(t, err)
+		require.Equal(t, 0, a)
+		require.Equal(t, sql.NullString{}, b)
+		require.Equal(t, sql.NullString{String: "foo", Valid: true}, c)
+	})
+
+	t.Run("Decimal", func(t *testing.T) {
+		r := record.NewFieldBuffer(
+			record.NewDecimalField("a", big.NewRat(1, 3)),
+			record.NewDecimalField("b", big.NewRat(-5, 2)),
+		)
+
+		var a, b big.Rat
+		err := recordutil.Scan(r, &a, &b)
+		require.NoError(t, err)
+		require.Equal(t, "0.3333333333333333333333333333333333", a.FloatString(34))
+		require.Equal(t, "-2.5", b.FloatString(1))
+
+		var buf bytes.Buffer
+		err = recordutil.RecordToJSON(&buf, r)
+		require.NoError(t, err)
+		require.Equal(t, `{"a":0.3333333333333333333333333333333333,"b":-2.5}`+"\n", buf.String())
+	})
+
+	t.Run("UUID", func(t *testing.T) {
+		type myUUID [16]byte
+
+		r := record.NewFieldBuffer(
+			record.NewUUIDField("a", [16]byte{15: 1}),
+			record.NewUUIDField("b", [16]byte{0: 0xff}),
+			record.NewUUIDField("c", [16]byte{}),
+		)
+
+		var a [16]byte
+		var b myUUID
+		var c uuidScanner
+		err := recordutil.Scan(r, &a, &b, &c)
+		require.NoError(t, err)
+		require.Equal(t, [16]byte{15: 1}, a)
+		require.Equal(t, myUUID{0: 0xff}, b)
+		require.Equal(t, uuidScanner("00000000-0000-0000-0000-000000000000"), c)
+
+		var buf bytes.Buffer
+		err = recordutil.RecordToJSON(&buf, r)
+		require.NoError(t, err)
+		require.Equal(t, `{"a":"00000000-0000-0000-0000-000000000001","b":"ff000000-0000-0000-0000-000000000000","c":"00000000-0000-0000-0000-000000000000"}`+"\n", buf.String())
+	})
+}
+
+// uuidScanner mimics the sql.Scanner implementations of UUID packages.
+type uuidScanner string
+
+func (u *uuidScanner) Scan(src interface{}) error {
+	s, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("unable to scan %T", src)
+	}
+
+	*u = uuidScanner(s)
+	return nil
 }
 
 type recordScanner struct {
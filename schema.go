@@ -0,0 +1,477 @@
+package genji
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/asdine/genji/engine"
+	"github.com/asdine/genji/internal/scanner"
+	"github.com/asdine/genji/record"
+	"github.com/asdine/genji/value"
+)
+
+// TableConfig holds the optional schema of a table.
+// Declared fields are type checked and converted when records are inserted or replaced.
+// Fields that aren't declared are allowed unless the table is strict.
+type TableConfig struct {
+	// If set to true, fields that aren't declared in FieldConstraints are rejected.
+	Strict bool
+
+	// List of declared fields.
+	FieldConstraints []FieldConstraint
+
+	// Generator used to create the keys of records that don't provide their own primary key.
+	KeyGenerator KeyGenerator
+
+	// List of expressions every record must satisfy, e.g. "age > 0".
+	// Checks are evaluated against the record before it is written, once defaults
+	// have been applied and declared fields have been converted.
+	Checks []string
+
+	// If set to true, records are compressed before being stored, see record.Compress.
+	// Changing it doesn't affect the records already stored, which remain readable.
+	Compressed bool
+}
+
+// A KeyGenerator determines how keys are generated for records inserted without a primary key.
+type KeyGenerator uint8
+
+const (
+	// ULIDKeyGenerator generates random ULIDs. It is the default generator.
+	ULIDKeyGenerator KeyGenerator = iota
+
+	// SequenceKeyGenerator generates monotonic integer keys from a sequence owned by the table,
+	// starting at 1. Keys are encoded using value.EncodeInt64 and sort in insertion order.
+	SequenceKeyGenerator
+
+	// UUIDKeyGenerator generates random version 4 UUIDs.
+	// Keys are encoded using value.EncodeUUID.
+	UUIDKeyGenerator
+
+	// OrderedUUIDKeyGenerator generates version 7 UUIDs, which start with the current Unix time
+	// in milliseconds followed by random bits. Keys are encoded using value.EncodeUUID and
+	// sort in insertion order, except for records inserted during the same millisecond.
+	OrderedUUIDKeyGenerator
+)
+
+// FieldConstraint describes the type and the constraints of a declared field.
+type FieldConstraint struct {
+	// Name of the field.
+	Name string
+	// Type of the field. If zero, values of any type are accepted.
+	Type value.Type
+	// If set to true, the field must be present in every record.
+	NotNull bool
+	// If set to true, a unique index is created on the field.
+	Unique bool
+	// Expression evaluated when a record is inserted without this field.
+	// Its result is used as the value of the field. Ignored if empty.
+	DefaultValue string
+	// If not nil, every value of the field must reference an existing record.
+	ForeignKey *ForeignKey
+}
+
+// GetFieldConstraint returns the constraint of the selected field, or nil if the field is not declared.
+func (cfg *TableConfig) GetFieldConstraint(name string) *FieldConstraint {
+	for i := range cfg.FieldConstraints {
+		if cfg.FieldConstraints[i].Name == name {
+			return &cfg.FieldConstraints[i]
+		}
+	}
+
+	return nil
+}
+
+// isZero returns true if cfg is equal to the default configuration.
+func (cfg *TableConfig) isZero() bool {
+	return !cfg.Strict && cfg.KeyGenerator == ULIDKeyGenerator && len(cfg.FieldConstraints) == 0 && len(cfg.Checks) == 0 && !cfg.Compressed
+}
+
+// validate the configuration before it gets stored.
+func (cfg *TableConfig) validate() error {
+	seen := make(map[string]bool, len(cfg.FieldConstraints))
+
+	for _, fc := range cfg.FieldConstraints {
+		if fc.Name == "" {
+			return errors.New("missing field name")
+		}
+
+		if seen[fc.Name] {
+			return fmt.Errorf("field %q declared more than once", fc.Name)
+		}
+		seen[fc.Name] = true
+
+		if fk := fc.ForeignKey; fk != nil {
+			if fk.Table == "" {
+				return fmt.Errorf("missing table name in foreign key of field %q", fc.Name)
+			}
+
+			if fk.OnDelete == ForeignKeySetNull && fc.NotNull {
+				return fmt.Errorf("field %q can't be set to null on delete: the field is not nullable", fc.Name)
+			}
+		}
+	}
+
+	_, err := newTableSchema("", cfg)
+	return err
+}
+
+// tableSchema holds a table configuration alongside its compiled expressions.
+type tableSchema struct {
+	tableName string
+	cfg       *TableConfig
+	// default value of each declared field, nil if the field has no default value.
+	defaults []expr
+	checks   []expr
+}
+
+func newTableSchema(tableName string, cfg *TableConfig) (*tableSchema, error) {
+	s := tableSchema{
+		tableName: tableName,
+		cfg:       cfg,
+		defaults:  make([]expr, len(cfg.FieldConstraints)),
+		checks:    make([]expr, len(cfg.Checks)),
+	}
+
+	var err error
+	for i, fc := range cfg.FieldConstraints {
+		if fc.DefaultValue == "" {
+			continue
+		}
+
+		s.defaults[i], err = parseConstraintExpr(fc.DefaultValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default value of field %q: %v", fc.Name, err)
+		}
+	}
+
+	for i, c := range cfg.Checks {
+		s.checks[i], err = parseConstraintExpr(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid check %q: %v", c, err)
+		}
+	}
+
+	return &s, nil
+}
+
+// parseConstraintExpr parses an expression stored in a table configuration.
+func parseConstraintExpr(s string) (expr, error) {
+	p := newParser(strings.NewReader(s))
+	e, err := p.parseConstraintExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.EOF {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"EOF"}, pos)
+	}
+
+	return e, nil
+}
+
+// validateRecord ensures r satisfies the table configuration and converts declared fields to their
+// declared type. If applyDefaults is true, missing fields that have a default value are added to the record.
+// It returns a record that can safely be encoded.
+func (s *tableSchema) validateRecord(tx *Tx, r record.Record, applyDefaults bool) (record.Record, error) {
+	var fb record.FieldBuffer
+	err := fb.ScanRecord(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, fc := range s.cfg.FieldConstraints {
+		f, err := fb.GetField(fc.Name)
+		if err != nil && applyDefaults && s.defaults[i] != nil {
+			f, err = s.evalDefault(tx, fc.Name, s.defaults[i])
+			if err != nil {
+				return nil, err
+			}
+			fb.Add(f)
+		}
+		if err != nil || f.Type == value.Null {
+			if fc.NotNull {
+				return nil, &ConstraintViolationError{Table: s.tableName, Field: fc.Name, Constraint: "NOT NULL"}
+			}
+
+			continue
+		}
+
+		if fc.Type == 0 || f.Type == fc.Type {
+			continue
+		}
+
+		v, err := f.ConvertTo(fc.Type)
+		if err != nil {
+			return nil, &ConstraintViolationError{Table: s.tableName, Field: fc.Name, Constraint: strings.ToUpper(fc.Type.String()), Err: err}
+		}
+
+		f.Value = v
+		err = fb.Replace(fc.Name, f)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if s.cfg.Strict {
+		for _, f := range fb {
+			if s.cfg.GetFieldConstraint(f.Name) == nil {
+				return nil, &ConstraintViolationError{Table: s.tableName, Field: f.Name, Constraint: "STRICT"}
+			}
+		}
+	}
+
+	for i, c := range s.checks {
+		v, err := c.Eval(evalStack{Tx: tx, Record: &fb})
+		if err != nil {
+			return nil, err
+		}
+
+		// a check that evaluates to null is satisfied
+		if !v.IsNull() && !v.Truthy() {
+			return nil, &ConstraintViolationError{Table: s.tableName, Constraint: "CHECK (" + s.cfg.Checks[i] + ")"}
+		}
+	}
+
+	return &fb, nil
+}
+
+func (s *tableSchema) evalDefault(tx *Tx, fieldName string, e expr) (record.Field, error) {
+	v, err := e.Eval(evalStack{Tx: tx})
+	if err != nil {
+		return record.Field{}, err
+	}
+
+	if v.IsList {
+		return record.Field{}, fmt.Errorf("default value of field %q must be a single value", fieldName)
+	}
+
+	return record.Field{Name: fieldName, Value: v.Value.Value}, nil
+}
+
+// prefix of the names of the indexes created for unique fields.
+const autoIndexPrefix = "__genji.autoindex."
+
+func autoIndexName(tableName, fieldName string) string {
+	return fmt.Sprintf("%s%s.%s", autoIndexPrefix, tableName, fieldName)
+}
+
+// tableInfo is the record stored in the table config table.
+type tableInfo struct {
+	TableName string
+	Config    TableConfig
+}
+
+func (ti *tableInfo) PrimaryKey() ([]byte, error) {
+	return []byte(ti.TableName), nil
+}
+
+// GetField implements the field method of the record.Record interface.
+func (ti *tableInfo) GetField(name string) (record.Field, error) {
+	switch name {
+	case "TableName":
+		return record.NewStringField("TableName", ti.TableName), nil
+	case "Strict":
+		return record.NewBoolField("Strict", ti.Config.Strict), nil
+	case "FieldConstraints":
+		data, err := encodeFieldConstraints(ti.Config.FieldConstraints)
+		if err != nil {
+			return record.Field{}, err
+		}
+		return record.NewBytesField("FieldConstraints", data), nil
+	case "KeyGenerator":
+		return record.NewUint8Field("KeyGenerator", uint8(ti.Config.KeyGenerator)), nil
+	case "Checks":
+		return record.NewBytesField("Checks", encodeStrings(ti.Config.Checks)), nil
+	case "Compressed":
+		return record.NewBoolField("Compressed", ti.Config.Compressed), nil
+	}
+
+	return record.Field{}, errors.New("unknown field")
+}
+
+// Iterate through all the fields one by one and pass each of them to the given function.
+// It the given function returns an error, the iteration is interrupted.
+func (ti *tableInfo) Iterate(fn func(record.Field) error) error {
+	for _, name := range []string{"TableName", "Strict", "FieldConstraints", "KeyGenerator", "Checks", "Compressed"} {
+		f, err := ti.GetField(name)
+		if err != nil {
+			return err
+		}
+
+		err = fn(f)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ScanRecord extracts fields from record and assigns them to the struct fields.
+// It implements the record.Scanner interface.
+func (ti *tableInfo) ScanRecord(rec record.Record) error {
+	return rec.Iterate(func(f record.Field) error {
+		var err error
+
+		switch f.Name {
+		case "TableName":
+			ti.TableName, err = f.DecodeToString()
+		case "Strict":
+			ti.Config.Strict, err = f.DecodeToBool()
+		case "FieldConstraints":
+			ti.Config.FieldConstraints, err = decodeFieldConstraints(f.Data)
+		case "KeyGenerator":
+			var kg uint8
+			kg, err = f.DecodeToUint8()
+			ti.Config.KeyGenerator = KeyGenerator(kg)
+		case "Checks":
+			ti.Config.Checks, err = decodeStrings(f.Data)
+		case "Compressed":
+			ti.Config.Compressed, err = f.DecodeToBool()
+		}
+		return err
+	})
+}
+
+// encodeFieldConstraints encodes each field constraint as a record
+// and prefixes each of them with their size.
+func encodeFieldConstraints(list []FieldConstraint) ([]byte, error) {
+	var buf []byte
+	var intBuf [binary.MaxVarintLen64]byte
+
+	for _, fc := range list {
+		fb := record.NewFieldBuffer(
+			record.NewStringField("Name", fc.Name),
+			record.NewUint8Field("Type", uint8(fc.Type)),
+			record.NewBoolField("NotNull", fc.NotNull),
+			record.NewBoolField("Unique", fc.Unique),
+			record.NewStringField("DefaultValue", fc.DefaultValue),
+		)
+
+		if fk := fc.ForeignKey; fk != nil {
+			fb.Add(record.NewStringField("RefTable", fk.Table))
+			fb.Add(record.NewStringField("RefField", fk.Field))
+			fb.Add(record.NewUint8Field("OnDelete", uint8(fk.OnDelete)))
+		}
+
+		data, err := record.Encode(fb)
+		if err != nil {
+			return nil, err
+		}
+
+		n := binary.PutUvarint(intBuf[:], uint64(len(data)))
+		buf = append(buf, intBuf[:n]...)
+		buf = append(buf, data...)
+	}
+
+	return buf, nil
+}
+
+func decodeFieldConstraints(data []byte) ([]FieldConstraint, error) {
+	var list []FieldConstraint
+
+	for len(data) > 0 {
+		size, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < size {
+			return nil, errors.New("can't decode field constraints")
+		}
+		data = data[n:]
+
+		var fc FieldConstraint
+		err := record.EncodedRecord(data[:size]).Iterate(func(f record.Field) error {
+			var err error
+
+			switch f.Name {
+			case "Name":
+				fc.Name, err = f.DecodeToString()
+			case "Type":
+				var tp uint8
+				tp, err = f.DecodeToUint8()
+				fc.Type = value.Type(tp)
+			case "NotNull":
+				fc.NotNull, err = f.DecodeToBool()
+			case "Unique":
+				fc.Unique, err = f.DecodeToBool()
+			case "DefaultValue":
+				fc.DefaultValue, err = f.DecodeToString()
+			case "RefTable":
+				fc.ForeignKey = new(ForeignKey)
+				fc.ForeignKey.Table, err = f.DecodeToString()
+			case "RefField":
+				fc.ForeignKey.Field, err = f.DecodeToString()
+			case "OnDelete":
+				var action uint8
+				action, err = f.DecodeToUint8()
+				fc.ForeignKey.OnDelete = ForeignKeyAction(action)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, fc)
+		data = data[size:]
+	}
+
+	return list, nil
+}
+
+// encodeStrings encodes a list of strings, each of them prefixed with their size.
+func encodeStrings(list []string) []byte {
+	var buf []byte
+	var intBuf [binary.MaxVarintLen64]byte
+
+	for _, s := range list {
+		n := binary.PutUvarint(intBuf[:], uint64(len(s)))
+		buf = append(buf, intBuf[:n]...)
+		buf = append(buf, s...)
+	}
+
+	return buf
+}
+
+func decodeStrings(data []byte) ([]string, error) {
+	var list []string
+
+	for len(data) > 0 {
+		size, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < size {
+			return nil, errors.New("can't decode string list")
+		}
+		data = data[n:]
+
+		list = append(list, string(data[:size]))
+		data = data[size:]
+	}
+
+	return list, nil
+}
+
+// readTableConfig reads the configuration of a table directly from the table config store.
+// It returns nil if the table was created without configuration.
+func readTableConfig(tx *Tx, tableName string) (*TableConfig, error) {
+	s, err := tx.tx.Store(tableConfigTable)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := s.Get([]byte(tableName))
+	if err == engine.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ti tableInfo
+	err = ti.ScanRecord(record.EncodedRecord(v))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ti.Config, nil
+}
@@ -16,8 +16,8 @@ func (p *parser) parseSelectStatement() (selectStmt, error) {
 	var stmt selectStmt
 	var err error
 
-	// Parse field list or wildcard
-	stmt.FieldSelectors, err = p.parseFieldNames()
+	// Parse projection list or wildcard
+	stmt.Projection, err = p.parseProjection()
 	if err != nil {
 		return stmt, err
 	}
@@ -47,27 +47,33 @@ func (p *parser) parseSelectStatement() (selectStmt, error) {
 	return stmt, nil
 }
 
-// parseFieldNames parses the list of field names or a wildward.
-func (p *parser) parseFieldNames() ([]fieldSelector, error) {
+// parseProjection parses the list of selected expressions, like field paths or function calls,
+// or a wildward.
+func (p *parser) parseProjection() ([]expr, error) {
 	// Check if the * token exists.
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.MUL {
 		return nil, nil
 	}
 	p.Unscan()
 
-	// Scan the list of fields
-	idents, err := p.ParseIdentList()
-	if err != nil {
-		return nil, err
-	}
+	var exprs []expr
+	for {
+		e, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
 
-	// turn it into field selectors
-	fselectors := make([]fieldSelector, len(idents))
-	for i := range idents {
-		fselectors[i] = fieldSelector(idents[i])
-	}
+		// double quoted identifiers always select fields
+		if i, ok := e.(identOrStringLitteral); ok {
+			e = fieldSelector(i)
+		}
+		exprs = append(exprs, e)
 
-	return fselectors, nil
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			return exprs, nil
+		}
+	}
 }
 
 func (p *parser) parseFrom() (string, error) {
@@ -101,11 +107,11 @@ func (p *parser) parseOffset() (expr, error) {
 
 // selectStmt is a DSL that allows creating a full Select query.
 type selectStmt struct {
-	tableName      string
-	whereExpr      expr
-	offsetExpr     expr
-	limitExpr      expr
-	FieldSelectors []fieldSelector
+	tableName  string
+	whereExpr  expr
+	offsetExpr expr
+	limitExpr  expr
+	Projection []expr
 }
 
 // IsReadOnly always returns true. It implements the Statement interface.
@@ -195,20 +201,73 @@ func (stmt selectStmt) exec(tx *Tx, args []driver.NamedValue) (Result, error) {
 		st = st.Limit(limit)
 	}
 
-	if len(stmt.FieldSelectors) > 0 {
-		fieldNames := make([]string, len(stmt.FieldSelectors))
-		for i := range stmt.FieldSelectors {
-			fieldNames[i] = stmt.FieldSelectors[i].Name()
+	if len(stmt.Projection) > 0 {
+		st = st.Map(projector(stmt.Projection, stack))
+	}
+
+	return Result{Stream: st}, nil
+}
+
+// projector returns a function applying the projection to each record.
+// If the projection only selects fields, the records are masked, otherwise
+// a new record is created with a field for each expression, named after it.
+func projector(projection []expr, stack evalStack) func(r record.Record) (record.Record, error) {
+	fieldNames := make([]string, len(projection))
+	onlyFields := true
+	for i, e := range projection {
+		fieldNames[i] = projectionName(e)
+
+		if _, ok := e.(fieldSelector); !ok {
+			onlyFields = false
 		}
-		st = st.Map(func(r record.Record) (record.Record, error) {
+	}
+
+	if onlyFields {
+		return func(r record.Record) (record.Record, error) {
 			return recordMask{
 				r:      r,
 				fields: fieldNames,
 			}, nil
-		})
+		}
 	}
 
-	return Result{Stream: st}, nil
+	return func(r record.Record) (record.Record, error) {
+		var fb record.FieldBuffer
+
+		for i, e := range projection {
+			// missing fields are skipped, like with recordMask
+			if fs, ok := e.(fieldSelector); ok {
+				f, err := fs.SelectField(r)
+				if err == nil {
+					fb.Add(f)
+				}
+				continue
+			}
+
+			stack.Record = r
+			v, err := e.Eval(stack)
+			if err != nil {
+				return nil, err
+			}
+			if v.IsList {
+				return nil, fmt.Errorf("%s must evaluate to a single value", fieldNames[i])
+			}
+
+			fb.Add(record.Field{Name: fieldNames[i], Value: v.Value.Value})
+		}
+
+		return &fb, nil
+	}
+}
+
+// projectionName returns the name of the column of a projected expression:
+// the path of selected fields or the expression itself.
+func projectionName(e expr) string {
+	if fs, ok := e.(fieldSelector); ok {
+		return fs.Name()
+	}
+
+	return e.String()
 }
 
 type recordMask struct {
@@ -221,7 +280,7 @@ var _ record.Record = recordMask{}
 func (r recordMask) GetField(name string) (record.Field, error) {
 	for _, n := range r.fields {
 		if n == name {
-			return r.r.GetField(name)
+			return fieldSelector(name).SelectField(r.r)
 		}
 	}
 
@@ -230,7 +289,7 @@ func (r recordMask) GetField(name string) (record.Field, error) {
 
 func (r recordMask) Iterate(fn func(f record.Field) error) error {
 	for _, n := range r.fields {
-		f, err := r.r.GetField(n)
+		f, err := fieldSelector(n).SelectField(r.r)
 		if err != nil {
 			continue
 		}
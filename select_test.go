@@ -3,10 +3,13 @@ package genji
 import (
 	"bytes"
 	"database/sql"
+	"math/big"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/asdine/genji/engine/memory"
+	"github.com/asdine/genji/record"
 	"github.com/asdine/genji/record/recordutil"
 	"github.com/stretchr/testify/require"
 )
@@ -24,8 +27,8 @@ func TestParserSelect(t *testing.T) {
 			}, false},
 		{"WithFields", "SELECT a, b FROM test",
 			selectStmt{
-				FieldSelectors: []fieldSelector{fieldSelector("a"), fieldSelector("b")},
-				tableName:      "test",
+				Projection: []expr{fieldSelector("a"), fieldSelector("b")},
+				tableName:  "test",
 			}, false},
 		{"WithCond", "SELECT * FROM test WHERE age = 10",
 			selectStmt{
@@ -52,6 +55,17 @@ func TestParserSelect(t *testing.T) {
 				limitExpr:  int64Value(10),
 			}, false},
 		{"WithOffsetThenLimit", "SELECT * FROM test WHERE age = 10 OFFSET 20 LIMIT 10", nil, true},
+		{"WithFunction", `SELECT a, LENGTH(b), "c d" FROM test`,
+			selectStmt{
+				Projection: []expr{fieldSelector("a"), lengthFunc{e: fieldSelector("b")}, fieldSelector("c d")},
+				tableName:  "test",
+			}, false},
+		{"WithPaths", "SELECT a.b, c FROM test WHERE a.b.c = 10",
+			selectStmt{
+				Projection: []expr{fieldSelector("a.b"), fieldSelector("c")},
+				tableName:  "test",
+				whereExpr:  eq(fieldSelector("a.b.c"), int64Value(10)),
+			}, false},
 	}
 
 	for _, test := range tests {
@@ -84,6 +98,10 @@ func TestSelectStmt(t *testing.T) {
 		{"With limit then offset", "SELECT * FROM test WHERE b = 'bar1' LIMIT 1 OFFSET 1", false, "foo2,bar1\n", nil},
 		{"With offset then limit", "SELECT * FROM test WHERE b = 'bar1' OFFSET 1 LIMIT 1", true, "", nil},
 		{"With positional params", "SELECT * FROM test WHERE a = ? OR d = ?", false, "foo1,bar1,baz1\nfoo3,bar2\n", []interface{}{"foo1", "foo3"}},
+		{"With IS NULL", "SELECT * FROM test WHERE c IS NULL", false, "foo2,bar1\nfoo3,bar2\n", nil},
+		{"With IS NOT NULL", "SELECT * FROM test WHERE c IS NOT NULL", false, "foo1,bar1,baz1\n", nil},
+		{"With missing field", "SELECT * FROM test WHERE c = 'nil'", false, "", nil},
+		{"With null comparison", "SELECT * FROM test WHERE c = NULL OR a = 'foo2'", false, "foo2,bar1\n", nil},
 		{"With named params", "SELECT * FROM test WHERE a = $a OR d = $d", false, "foo1,bar1,baz1\nfoo3,bar2\n", []interface{}{sql.Named("a", "foo1"), sql.Named("d", "foo3")}},
 	}
 
@@ -119,3 +137,415 @@ func TestSelectStmt(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectStmtDocuments(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		params   []interface{}
+	}{
+		{"Document", "SELECT address FROM user WHERE name = 'foo'", `"{""city"":""Lyon"",""zip"":""69001""}"` + "\n", nil},
+		{"Path", "SELECT name, address.city FROM user WHERE address.zip = ?", "foo,Lyon\n", []interface{}{"69001"}},
+		{"Missing path", "SELECT name FROM user WHERE address.city.name = 'Lyon' OR name.city = 'Lyon'", "", nil},
+		{"Document param", "SELECT name FROM user WHERE address = ?", "bar\n",
+			[]interface{}{record.NewFieldBuffer(record.NewStringField("city", "Paris"), record.NewStringField("zip", "75001"))}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := New(memory.NewEngine())
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE user;
+				CREATE INDEX idx_zip ON user (address.zip);
+				INSERT INTO user (name, address) VALUES ('foo', {city: 'Lyon', zip: '69001'}), ('bar', {city: 'Paris', zip: '75001'});
+			`)
+			require.NoError(t, err)
+
+			st, err := db.Query(test.query, test.params...)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = recordutil.IteratorToCSV(&buf, st)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestSelectStmtArrays(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		params   []interface{}
+	}{
+		{"Element", "SELECT name, tags[0] FROM user WHERE tags[1] = 'go'", "foo,db\n", nil},
+		{"Missing element", "SELECT name FROM user WHERE tags[5] = 'go'", "", nil},
+		{"Negative element", `SELECT name FROM user WHERE "tags[-1]" = 'go'`, "", nil},
+		{"IN indexed array", "SELECT name FROM user WHERE ? IN tags", "foo\nbar\n", []interface{}{"go"}},
+		{"IN list", "SELECT name FROM user WHERE name IN ('bar', 'baz')", "bar\n", nil},
+		{"LENGTH", "SELECT name FROM user WHERE LENGTH(tags) = 1", "bar\n", nil},
+		{"LENGTH projection", "SELECT name, LENGTH(tags), missing FROM user", "foo,3\nbar,1\n", nil},
+		{"ANY", "SELECT name FROM user WHERE 'db' = ANY(tags)", "foo\n", nil},
+		{"ALL", "SELECT name FROM user WHERE 'go' = ALL (tags)", "bar\n", nil},
+		{"Array param", "SELECT name FROM user WHERE tags = ?", "bar\n", []interface{}{[]interface{}{"go"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := New(memory.NewEngine())
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE user;
+				CREATE INDEX idx_tags ON user (tags);
+				INSERT INTO user (name, tags) VALUES ('foo', ['db', 'go', 'go']);
+			`)
+			require.NoError(t, err)
+			time.Sleep(time.Millisecond)
+			err = db.Exec("INSERT INTO user (name, tags) VALUES ('bar', ['go'])")
+			require.NoError(t, err)
+
+			st, err := db.Query(test.query, test.params...)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = recordutil.IteratorToCSV(&buf, st)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, buf.String())
+		})
+	}
+
+	t.Run("Unique", func(t *testing.T) {
+		db, err := New(memory.NewEngine())
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE user;
+			CREATE UNIQUE INDEX idx_tags ON user (tags);
+			INSERT INTO user (tags) VALUES (['a', 'b', 'a']);
+		`)
+		require.NoError(t, err)
+
+		err = db.Exec("INSERT INTO user (tags) VALUES (['c', 'b'])")
+		require.Error(t, err)
+
+		err = db.Exec("DELETE FROM user")
+		require.NoError(t, err)
+
+		err = db.Exec("INSERT INTO user (tags) VALUES (['c', 'b'])")
+		require.NoError(t, err)
+	})
+}
+
+func TestSelectStmtTimes(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		params   []interface{}
+	}{
+		{"Recent", "SELECT name FROM event WHERE ts > NOW() - 90m", "b\nc\n", nil},
+		{"Param", "SELECT name FROM event WHERE ts <= ?", "a\n", []interface{}{now.Add(-2 * time.Hour)}},
+		{"Duration", "SELECT name FROM event WHERE timeout >= 1m30s", "c\n", nil},
+		{"Duration param", "SELECT name FROM event WHERE timeout = ?", "b\n", []interface{}{time.Minute}},
+		{"Arithmetic", "SELECT name FROM event WHERE ts + timeout > NOW() + 1m", "c\n", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := New(memory.NewEngine())
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE event (timeout DURATION);
+				CREATE INDEX idx_ts ON event (ts);
+			`)
+			require.NoError(t, err)
+
+			for i, name := range []string{"a", "b", "c"} {
+				err = db.Exec("INSERT INTO event (name, ts, timeout) VALUES (?, ?, ?)",
+					name, now.Add(time.Duration(i-2)*time.Hour), time.Duration(i)*time.Minute)
+				require.NoError(t, err)
+				time.Sleep(time.Millisecond)
+			}
+
+			st, err := db.Query(test.query, test.params...)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = recordutil.IteratorToCSV(&buf, st)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestSelectStmtDecimals(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		params   []interface{}
+	}{
+		{"All", "SELECT id, amount FROM invoice", "1,0.1\n2,0.2\n3,9007199254740993\n", nil},
+		{"Exact sum", "SELECT id FROM invoice WHERE amount + DECIMAL('0.2') = DECIMAL('0.3')", "1\n", nil},
+		{"Large integer", "SELECT id FROM invoice WHERE amount = 9007199254740993", "3\n", nil},
+		{"Large integer / Not equal", "SELECT id FROM invoice WHERE amount = 9007199254740992", "", nil},
+		{"Param", "SELECT id FROM invoice WHERE amount * 3 = ?", "2\n", []interface{}{big.NewRat(6, 10)}},
+		{"Float", "SELECT id FROM invoice WHERE amount < 0.15", "1\n", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := New(memory.NewEngine())
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec("CREATE TABLE invoice (id INT64, amount DECIMAL)")
+			require.NoError(t, err)
+
+			for i, amount := range []interface{}{0.1, "0.2", int64(9007199254740993)} {
+				err = db.Exec("INSERT INTO invoice (id, amount) VALUES (?, ?)", i+1, amount)
+				require.NoError(t, err)
+				time.Sleep(time.Millisecond)
+			}
+
+			st, err := db.Query(test.query, test.params...)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = recordutil.IteratorToCSV(&buf, st)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestSelectStmtCasts(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		fails    bool
+	}{
+		{"String to integer", "SELECT id FROM item WHERE CAST(code AS INT64) = 12", "2\n", false},
+		{"Integer to string", "SELECT id FROM item WHERE id::STRING = '1'", "1\n", false},
+		{"Chained", "SELECT id FROM item WHERE qty::STRING::INT16 > 2", "2\n", false},
+		{"Duration", "SELECT id FROM item WHERE delay::DURATION > 1m", "2\n", false},
+		{"Integer to bool", "SELECT id FROM item WHERE CAST(qty AS BOOL) = false", "1\n", false},
+		{"Invalid string", "SELECT id FROM item WHERE CAST(name AS INT64) = 1", "", true},
+		{"Overflow", "SELECT id FROM item WHERE CAST(qty AS INT8) = 1", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := New(memory.NewEngine())
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec("CREATE TABLE item")
+			require.NoError(t, err)
+
+			err = db.Exec("INSERT INTO item (id, name, code, qty, delay) VALUES (1, 'foo', '007', 0, '10s')")
+			require.NoError(t, err)
+			time.Sleep(time.Millisecond)
+			err = db.Exec("INSERT INTO item (id, name, code, qty, delay) VALUES (2, 'bar', '12', 300, '2m')")
+			require.NoError(t, err)
+
+			st, err := db.Query(test.query)
+			if test.fails {
+				if err == nil {
+					var buf bytes.Buffer
+					err = recordutil.IteratorToCSV(&buf, st)
+					st.Close()
+				}
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = recordutil.IteratorToCSV(&buf, st)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestSelectStmtIndexedNumbers(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		params   []interface{}
+	}{
+		{"GT / Float", "SELECT name FROM people WHERE age > 18.5", "c\nd\n", nil},
+		{"EQ / Int64", "SELECT name FROM people WHERE age = 18", "b\n", nil},
+		{"EQ / Param", "SELECT name FROM people WHERE age = ?", "c\n", []interface{}{uint64(19)}},
+		{"LTE / Reversed", "SELECT name FROM people WHERE 18 >= age", "b\na\n", nil},
+		{"GTE / Mixed types", "SELECT name FROM people WHERE score >= 2", "b\nc\nd\n", nil},
+		{"LT / Mixed types", "SELECT name FROM people WHERE score < 3", "b\na\n", nil},
+		{"EQ / Mixed types", "SELECT name FROM people WHERE score = 3", "c\n", nil},
+		{"EQ / Decimal", "SELECT name FROM people WHERE score = DECIMAL('2.5')", "b\n", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := New(memory.NewEngine())
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE people (name STRING, age INT8);
+				CREATE INDEX idx_age ON people (age);
+				CREATE INDEX idx_score ON people (score);
+			`)
+			require.NoError(t, err)
+
+			records := []struct {
+				name  string
+				age   int64
+				score interface{}
+			}{
+				{"a", 17, int64(1)},
+				{"b", 18, 2.5},
+				{"c", 19, uint64(3)},
+				{"d", 20, float32(1e10)},
+			}
+
+			for _, r := range records {
+				err = db.Exec("INSERT INTO people (name, age, score) VALUES (?, ?, ?)", r.name, r.age, r.score)
+				require.NoError(t, err)
+			}
+
+			st, err := db.Query(test.query, test.params...)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = recordutil.IteratorToCSV(&buf, st)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestSelectStmtMixedTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"GT / String", "SELECT name FROM test WHERE v > 'a'", []string{"c"}},
+		{"GTE / String", "SELECT name FROM test WHERE v >= 'b'", []string{"c"}},
+		{"LT / String", "SELECT name FROM test WHERE v < 'z'", []string{"c"}},
+		{"LTE / Bool", "SELECT name FROM test WHERE v <= true", []string{"a"}},
+		{"GT / Integer", "SELECT name FROM test WHERE v > 0", []string{"b", "d"}},
+		{"LT / Integer", "SELECT name FROM test WHERE v < 100", []string{"b", "d"}},
+		{"EQ / Bool", "SELECT name FROM test WHERE v = true", []string{"a"}},
+	}
+
+	// type mismatches never match, with or without an index
+	for _, indexed := range []bool{false, true} {
+		for _, test := range tests {
+			name := test.name
+			if indexed {
+				name += " / Index"
+			}
+
+			t.Run(name, func(t *testing.T) {
+				db, err := New(memory.NewEngine())
+				require.NoError(t, err)
+				defer db.Close()
+
+				err = db.Exec("CREATE TABLE test")
+				require.NoError(t, err)
+				if indexed {
+					err = db.Exec("CREATE INDEX idx_test_v ON test (v)")
+					require.NoError(t, err)
+				}
+
+				err = db.Exec(`
+					INSERT INTO test (name, v) VALUES ('a', true), ('b', 10), ('c', 'foo'), ('d', 1.5), ('e', NOW());
+				`)
+				require.NoError(t, err)
+
+				st, err := db.Query(test.query)
+				require.NoError(t, err)
+				defer st.Close()
+
+				var names []string
+				err = st.Iterate(func(r record.Record) error {
+					f, err := r.GetField("name")
+					if err != nil {
+						return err
+					}
+					names = append(names, string(f.Data))
+					return nil
+				})
+				require.NoError(t, err)
+				sort.Strings(names)
+				require.Equal(t, test.expected, names)
+			})
+		}
+	}
+}
+
+func TestSelectStmtUUIDs(t *testing.T) {
+	type myUUID [16]byte
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		params   []interface{}
+	}{
+		{"All", "SELECT name, id FROM user", "a,00000000-0000-0000-0000-000000000001\nb,f47ac10b-58cc-4372-a567-0e02b2c3d479\n", nil},
+		{"Litteral", "SELECT name FROM user WHERE id = UUID('F47AC10B-58CC-4372-A567-0E02B2C3D479')", "b\n", nil},
+		{"Param", "SELECT name FROM user WHERE id = ?", "a\n", []interface{}{myUUID{15: 1}}},
+		{"Parsed param", "SELECT name FROM user WHERE id = UUID(?)", "b\n", []interface{}{"f47ac10b-58cc-4372-a567-0e02b2c3d479"}},
+		{"Ordering", "SELECT name FROM user WHERE id > UUID('00000000-0000-0000-0000-000000000001')", "b\n", nil},
+		{"String", "SELECT name FROM user WHERE id = 'f47ac10b-58cc-4372-a567-0e02b2c3d479'", "", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := New(memory.NewEngine())
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE user (id UUID UNIQUE) UUID ORDERED;
+				INSERT INTO user (name, id) VALUES ('a', ?);
+			`, [16]byte{15: 1})
+			require.NoError(t, err)
+			time.Sleep(time.Millisecond)
+			err = db.Exec("INSERT INTO user (name, id) VALUES ('b', 'f47ac10b-58cc-4372-a567-0e02b2c3d479')")
+			require.NoError(t, err)
+
+			st, err := db.Query(test.query, test.params...)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = recordutil.IteratorToCSV(&buf, st)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, buf.String())
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package genji
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/asdine/genji/engine"
+	"github.com/asdine/genji/record"
+)
+
+// CreateSequence creates a sequence with the given name.
+// The first value returned by NextValue for that sequence is 1.
+// If it already exists, returns ErrSequenceAlreadyExists.
+func (tx Tx) CreateSequence(name string) error {
+	return tx.createSequence(name, 0)
+}
+
+// createSequence creates a sequence whose next value is value + 1.
+func (tx Tx) createSequence(name string, value int64) error {
+	s, err := tx.tx.Store(sequenceTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Get([]byte(name))
+	if err == nil {
+		return ErrSequenceAlreadyExists
+	}
+	if err != engine.ErrKeyNotFound {
+		return err
+	}
+
+	return putSequenceValue(s, name, value)
+}
+
+// DropSequence deletes a sequence from the database.
+// If it doesn't exist, returns ErrSequenceNotFound.
+func (tx Tx) DropSequence(name string) error {
+	s, err := tx.tx.Store(sequenceTable)
+	if err != nil {
+		return err
+	}
+
+	err = s.Delete([]byte(name))
+	if err == engine.ErrKeyNotFound {
+		return ErrSequenceNotFound
+	}
+	return err
+}
+
+// NextValue increments the sequence and returns its new value.
+// The sequence is updated within the transaction, if the transaction is rolled back
+// the value will be returned again by the next call.
+func (tx Tx) NextValue(name string) (int64, error) {
+	s, err := tx.tx.Store(sequenceTable)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := getSequenceValue(s, name)
+	if err != nil {
+		return 0, err
+	}
+
+	if n == math.MaxInt64 {
+		return 0, fmt.Errorf("sequence %q reached its maximum value", name)
+	}
+	n++
+
+	err = putSequenceValue(s, name, n)
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// raiseSequence sets the value of a sequence to n if it is lower,
+// so that NextValue only returns values greater than n.
+func (tx Tx) raiseSequence(name string, n int64) error {
+	s, err := tx.tx.Store(sequenceTable)
+	if err != nil {
+		return err
+	}
+
+	cur, err := getSequenceValue(s, name)
+	if err != nil || cur >= n {
+		return err
+	}
+
+	return putSequenceValue(s, name, n)
+}
+
+func getSequenceValue(s engine.Store, name string) (int64, error) {
+	v, err := s.Get([]byte(name))
+	if err == engine.ErrKeyNotFound {
+		return 0, ErrSequenceNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return decodeSequenceValue(v)
+}
+
+func decodeSequenceValue(data []byte) (int64, error) {
+	f, err := record.EncodedRecord(data).GetField("Value")
+	if err != nil {
+		return 0, err
+	}
+
+	return f.DecodeToInt64()
+}
+
+func putSequenceValue(s engine.Store, name string, n int64) error {
+	v, err := record.Encode(record.NewFieldBuffer(
+		record.NewStringField("Name", name),
+		record.NewInt64Field("Value", n),
+	))
+	if err != nil {
+		return err
+	}
+
+	return s.Put([]byte(name), v)
+}
+
+// autoSequenceName returns the name of the sequence used to generate the keys of a table.
+func autoSequenceName(tableName string) string {
+	return fmt.Sprintf("__genji.autoincrement.%s", tableName)
+}
+
+// nextValFunc is the NEXTVAL function. It increments a sequence and returns its new value.
+type nextValFunc struct {
+	sequenceName expr
+}
+
+func newNextValFunc(args ...expr) (expr, error) {
+	if len(args) != 1 {
+		return nil, errors.New("NEXTVAL takes exactly one argument")
+	}
+
+	return nextValFunc{sequenceName: args[0]}, nil
+}
+
+// Eval implements the Expr interface.
+func (f nextValFunc) Eval(stack evalStack) (evalValue, error) {
+	v, err := f.sequenceName.Eval(stack)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if v.IsList {
+		return nullLitteral, errors.New("NEXTVAL expects a sequence name")
+	}
+
+	name, err := v.Value.DecodeToString()
+	if err != nil {
+		return nullLitteral, errors.New("NEXTVAL expects a sequence name")
+	}
+
+	n, err := stack.Tx.NextValue(name)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return evalValue{Value: int64Value(n)}, nil
+}
+
+func (f nextValFunc) String() string {
+	return "NEXTVAL(" + f.sequenceName.String() + ")"
+}
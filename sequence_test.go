@@ -0,0 +1,185 @@
+package genji
+
+import (
+	"testing"
+
+	"github.com/asdine/genji/engine/memory"
+	"github.com/asdine/genji/record"
+	"github.com/asdine/genji/value"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxSequence(t *testing.T) {
+	db, err := New(memory.NewEngine())
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Update(func(tx *Tx) error {
+		_, err := tx.NextValue("seq")
+		require.Equal(t, ErrSequenceNotFound, err)
+
+		err = tx.CreateSequence("seq")
+		require.NoError(t, err)
+
+		err = tx.CreateSequence("seq")
+		require.Equal(t, ErrSequenceAlreadyExists, err)
+
+		for i := int64(1); i <= 3; i++ {
+			n, err := tx.NextValue("seq")
+			require.NoError(t, err)
+			require.Equal(t, i, n)
+		}
+
+		err = tx.DropSequence("seq")
+		require.NoError(t, err)
+
+		err = tx.DropSequence("seq")
+		require.Equal(t, ErrSequenceNotFound, err)
+		return nil
+	})
+	require.NoError(t, err)
+
+	t.Run("Rollback", func(t *testing.T) {
+		err = db.Exec("CREATE SEQUENCE seq")
+		require.NoError(t, err)
+
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+		_, err = tx.NextValue("seq")
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback())
+
+		err = db.Update(func(tx *Tx) error {
+			n, err := tx.NextValue("seq")
+			require.Equal(t, int64(1), n)
+			return err
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("Start", func(t *testing.T) {
+		err = db.Exec("CREATE SEQUENCE other START WITH -5")
+		require.NoError(t, err)
+
+		err = db.Update(func(tx *Tx) error {
+			n, err := tx.NextValue("other")
+			require.Equal(t, int64(-5), n)
+			return err
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestNextValFunc(t *testing.T) {
+	db, err := New(memory.NewEngine())
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`CREATE SEQUENCE seq; CREATE TABLE test (id DEFAULT NEXTVAL('seq'));
+		INSERT INTO test (id) VALUES (NEXTVAL('seq')), (NEXTVAL('seq'));
+		INSERT INTO test (a) VALUES (1)`)
+	require.NoError(t, err)
+
+	res, err := db.Query("SELECT id FROM test WHERE id = 3")
+	require.NoError(t, err)
+	defer res.Close()
+	n, err := res.Count()
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	_, err = parseQuery("INSERT INTO test (id) VALUES (NEXTVAL())")
+	require.Error(t, err)
+	_, err = parseQuery("INSERT INTO test (id) VALUES (FOO('seq'))")
+	require.Error(t, err)
+}
+
+func TestAutoIncrement(t *testing.T) {
+	db, err := New(memory.NewEngine())
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test AUTOINCREMENT")
+	require.NoError(t, err)
+
+	err = db.Update(func(tx *Tx) error {
+		tb, err := tx.GetTable("test")
+		if err != nil {
+			return err
+		}
+
+		for i := int64(1); i <= 300; i++ {
+			key, err := tb.Insert(record.NewFieldBuffer(record.NewInt64Field("a", i)))
+			require.NoError(t, err)
+			require.Equal(t, value.EncodeInt64(i), key)
+		}
+
+		// keys are sorted in insertion order
+		var i int64
+		return tb.Iterate(func(r record.Record) error {
+			i++
+			f, err := r.GetField("a")
+			require.NoError(t, err)
+			require.Equal(t, value.NewInt64(i), f.Value)
+			return nil
+		})
+	})
+	require.NoError(t, err)
+
+	t.Run("LastInsertId", func(t *testing.T) {
+		sqlDB, err := OpenDB(db)
+		require.NoError(t, err)
+
+		res, err := sqlDB.Exec("INSERT INTO test (a) VALUES (1), (2)")
+		require.NoError(t, err)
+		id, err := res.LastInsertId()
+		require.NoError(t, err)
+		require.Equal(t, int64(302), id)
+
+		_, err = sqlDB.Exec("CREATE TABLE other")
+		require.NoError(t, err)
+		res, err = sqlDB.Exec("INSERT INTO other (a) VALUES (1)")
+		require.NoError(t, err)
+		_, err = res.LastInsertId()
+		require.Error(t, err)
+	})
+
+	t.Run("Key", func(t *testing.T) {
+		err = db.Exec("CREATE TABLE keys AUTOINCREMENT; INSERT INTO keys RECORDS (a: 1) KEY 10, (a: 2) KEY 5")
+		require.NoError(t, err)
+
+		// keys are generated after the largest key inserted explicitly
+		err = db.UpdateTable("keys", func(_ *Tx, tb *Table) error {
+			key, err := tb.Insert(record.NewFieldBuffer(record.NewInt64Field("a", 3)))
+			require.Equal(t, value.EncodeInt64(11), key)
+			return err
+		})
+		require.NoError(t, err)
+
+		// keys that are not integers don't change the sequence, even if they are 8 bytes long
+		res, err := db.Query("INSERT INTO keys RECORDS (a: 4) KEY 'zzzzzzzz'")
+		require.NoError(t, err)
+		_, err = res.LastInsertId()
+		require.Error(t, err)
+		require.NoError(t, res.Close())
+
+		res, err = db.Query("INSERT INTO keys RECORDS (a: 5)")
+		require.NoError(t, err)
+		id, err := res.LastInsertId()
+		require.NoError(t, err)
+		require.EqualValues(t, 12, id)
+		require.NoError(t, res.Close())
+	})
+
+	t.Run("Drop", func(t *testing.T) {
+		err = db.Exec("DROP TABLE test; CREATE TABLE test AUTOINCREMENT")
+		require.NoError(t, err)
+
+		// the sequence of the table is dropped with it
+		err = db.UpdateTable("test", func(_ *Tx, tb *Table) error {
+			key, err := tb.Insert(record.NewFieldBuffer(record.NewInt64Field("a", 1)))
+			require.Equal(t, value.EncodeInt64(1), key)
+			return err
+		})
+		require.NoError(t, err)
+	})
+}
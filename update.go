@@ -118,18 +118,46 @@ func (stmt updateStmt) Run(tx *Tx, args []driver.NamedValue) (Result, error) {
 	st := record.NewStream(t)
 	st = st.Filter(whereClause(stmt.whereExpr, stack))
 
+	// collect the keys first: some engines don't support writing
+	// or opening other iterators while iterating over a store, which
+	// happens when checking foreign keys.
+	var keys [][]byte
 	err = st.Iterate(func(r record.Record) error {
 		rk, ok := r.(record.Keyer)
 		if !ok {
 			return errors.New("attempt to update record without key")
 		}
 
-		var fb record.FieldBuffer
-		err := fb.ScanRecord(r)
+		// the keys are kept in memory until all of them are collected
+		err := tx.buffered(len(rk.Key()))
 		if err != nil {
 			return err
 		}
 
+		keys = append(keys, append([]byte{}, rk.Key()...))
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+
+	for _, key := range keys {
+		err = tx.checkInterrupted()
+		if err != nil {
+			return res, err
+		}
+
+		r, err := t.GetRecord(key)
+		if err != nil {
+			return res, err
+		}
+
+		var fb record.FieldBuffer
+		err = fb.ScanRecord(r)
+		if err != nil {
+			return res, err
+		}
+
 		for fname, e := range stmt.pairs {
 			f, err := fb.GetField(fname)
 			if err != nil {
@@ -142,27 +170,26 @@ func (stmt updateStmt) Run(tx *Tx, args []driver.NamedValue) (Result, error) {
 				Params: args,
 			})
 			if err != nil {
-				return err
+				return res, err
 			}
 
 			if v.IsList {
-				return fmt.Errorf("expected value got list")
+				return res, fmt.Errorf("expected value got list")
 			}
 
 			f.Type = v.Value.Type
 			f.Data = v.Value.Data
 			err = fb.Replace(f.Name, f)
 			if err != nil {
-				return err
+				return res, err
 			}
 		}
 
-		err = t.Replace(rk.Key(), &fb)
+		err = t.Replace(key, &fb)
 		if err != nil {
-			return err
+			return res, err
 		}
+	}
 
-		return nil
-	})
-	return res, err
+	return res, nil
 }
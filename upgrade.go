@@ -0,0 +1,258 @@
+package genji
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asdine/genji/engine"
+	"github.com/asdine/genji/index"
+	"github.com/asdine/genji/record"
+)
+
+// keys of the records of the meta table storing the format version of the database
+// and the format version of its indexes.
+var (
+	formatVersionKey      = []byte("format")
+	indexFormatVersionKey = []byte("indexFormat")
+)
+
+// FormatVersion returns the format version of the database.
+// Records encoded with an older version of the format can still be read,
+// but they are only rewritten using the latest version when they are modified
+// or when Upgrade is called.
+// Databases created before the format was versioned are at version 0.
+func (db DB) FormatVersion() (uint8, error) {
+	var v uint8
+
+	err := db.View(func(tx *Tx) error {
+		var err error
+		v, err = tx.version(formatVersionKey)
+		return err
+	})
+
+	return v, err
+}
+
+// version returns the version stored under the given key of the meta table,
+// or 0 if there is none.
+func (tx Tx) version(key []byte) (uint8, error) {
+	s, err := tx.tx.Store(metaTable)
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := s.Get(key)
+	if err == engine.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := record.EncodedRecord(v).GetField("Version")
+	if err != nil {
+		return 0, err
+	}
+
+	return f.DecodeToUint8()
+}
+
+func (tx Tx) setVersion(key []byte, version uint8) error {
+	s, err := tx.tx.Store(metaTable)
+	if err != nil {
+		return err
+	}
+
+	v, err := record.Encode(record.NewFieldBuffer(
+		record.NewUint8Field("Version", version),
+	))
+	if err != nil {
+		return err
+	}
+
+	return s.Put(key, v)
+}
+
+// Upgrade rewrites every record of the database, including the ones of the system tables,
+// using the latest version of the format, then drops and rebuilds every index using the latest
+// version of the index format, and finally updates the format versions of the database.
+// Each table is upgraded in its own transaction: if an error occurs, the tables that were
+// already upgraded remain readable and calling Upgrade again resumes the upgrade.
+func (db DB) Upgrade() error {
+	var v, iv uint8
+	err := db.View(func(tx *Tx) error {
+		var err error
+		v, err = tx.version(formatVersionKey)
+		if err != nil {
+			return err
+		}
+
+		iv, err = tx.version(indexFormatVersionKey)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if v == record.FormatVersion && iv == index.FormatVersion {
+		return nil
+	}
+
+	var tableNames []string
+	err = db.View(func(tx *Tx) error {
+		names, err := tx.tx.ListStores("")
+		if err != nil {
+			return err
+		}
+
+		// index stores contain keys, not records
+		for _, name := range names {
+			if !strings.HasPrefix(name, buildIndexName("")) {
+				tableNames = append(tableNames, name)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if v < record.FormatVersion {
+		for _, name := range tableNames {
+			err = db.UpdateTable(name, upgradeTable)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// the index entries are derived from the upgraded records
+	err = db.rebuildIndexes()
+	if err != nil {
+		return err
+	}
+
+	return db.Update(func(tx *Tx) error {
+		return tx.setVersion(formatVersionKey, record.FormatVersion)
+	})
+}
+
+// upgradeTable rewrites all the records of the table. Records encoded with the latest version
+// can still contain nested documents encoded with an older one, so none of them are skipped.
+// Records are written directly to the store: their content doesn't change, so they are neither
+// validated nor checked against foreign keys, and the indexes are rebuilt by Upgrade afterwards.
+func upgradeTable(tx *Tx, t *Table) error {
+	// stores can't be modified while being iterated on
+	var keys [][]byte
+	err := t.store.AscendGreaterOrEqual(nil, func(k, v []byte) error {
+		keys = append(keys, append([]byte{}, k...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		r, err := t.GetRecord(k)
+		if err != nil {
+			return err
+		}
+
+		fb, err := record.Upgrade(r)
+		if err != nil {
+			return err
+		}
+
+		v, err := t.encodeRecord(fb)
+		if err != nil {
+			return err
+		}
+
+		err = t.store.Put(k, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rebuildIndexes empties every index of the database and fills it again using the records
+// of its table, then updates the format version of the indexes.
+// Each table is reindexed in its own transaction.
+func (db DB) rebuildIndexes() error {
+	var tableNames []string
+	err := db.View(func(tx *Tx) error {
+		names, err := tx.tx.ListStores("")
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			if !strings.HasPrefix(name, systemTablePrefix) && !strings.HasPrefix(name, buildIndexName("")) {
+				tableNames = append(tableNames, name)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range tableNames {
+		err = db.UpdateTable(name, reindexTable)
+		if err != nil {
+			return err
+		}
+	}
+
+	return db.Update(func(tx *Tx) error {
+		return tx.setVersion(indexFormatVersionKey, index.FormatVersion)
+	})
+}
+
+// reindexTable empties the indexes of the table and adds the values of every record to them.
+func reindexTable(tx *Tx, t *Table) error {
+	for _, idx := range t.indexes {
+		s, err := tx.tx.Store(buildIndexName(idx.IndexName))
+		if err != nil {
+			return err
+		}
+
+		err = s.Truncate()
+		if err != nil {
+			return err
+		}
+	}
+
+	// truncating may recreate the index stores, they must be fetched again
+	t, err := tx.GetTable(t.name)
+	if err != nil {
+		return err
+	}
+
+	return t.Iterate(func(r record.Record) error {
+		key := r.(record.Keyer).Key()
+
+		for _, idx := range t.indexes {
+			values, err := indexedValues(idx.FieldName, r)
+			if err != nil {
+				return err
+			}
+
+			for _, v := range values {
+				err = idx.Set(v, key)
+				if err == index.ErrDuplicate {
+					return fmt.Errorf("can't rebuild unique index %q: %v", idx.IndexName, ErrDuplicateRecord)
+				}
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
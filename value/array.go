@@ -0,0 +1,99 @@
+package value
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// NewArray encodes the given values and returns a value of type Array.
+func NewArray(values ...Value) Value {
+	return Value{
+		Type: Array,
+		Data: EncodeArray(values),
+	}
+}
+
+// EncodeArray encodes a list of values. Each value is encoded as its type,
+// the size of its data and the data itself.
+func EncodeArray(values []Value) []byte {
+	var buf []byte
+	var intBuf [binary.MaxVarintLen64]byte
+
+	for _, v := range values {
+		n := binary.PutUvarint(intBuf[:], uint64(v.Type))
+		buf = append(buf, intBuf[:n]...)
+		n = binary.PutUvarint(intBuf[:], uint64(len(v.Data)))
+		buf = append(buf, intBuf[:n]...)
+		buf = append(buf, v.Data...)
+	}
+
+	return buf
+}
+
+// DecodeArray decodes a list of values encoded with EncodeArray.
+// The data of the returned values points to buf.
+func DecodeArray(buf []byte) ([]Value, error) {
+	var values []Value
+
+	for len(buf) > 0 {
+		tp, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return nil, errors.New("can't decode array")
+		}
+		buf = buf[n:]
+
+		size, n := binary.Uvarint(buf)
+		if n <= 0 || uint64(len(buf)-n) < size {
+			return nil, errors.New("can't decode array")
+		}
+		buf = buf[n:]
+
+		values = append(values, Value{Type: Type(tp), Data: buf[:size:size]})
+		buf = buf[size:]
+	}
+
+	return values, nil
+}
+
+// DecodeToArray returns the elements of a value of type Array.
+// It fails if it's used with any other type.
+func (v Value) DecodeToArray() ([]Value, error) {
+	if v.Type != Array {
+		return nil, fmt.Errorf("can't convert %q to array", v.Type)
+	}
+
+	return DecodeArray(v.Data)
+}
+
+func decodeArrayToInterfaces(buf []byte) ([]interface{}, error) {
+	values, err := DecodeArray(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	l := make([]interface{}, len(values))
+	for i := range values {
+		l[i], err = values[i].Decode()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+func arrayString(buf []byte) string {
+	values, err := DecodeArray(buf)
+	if err != nil {
+		return ""
+	}
+
+	s := make([]string, len(values))
+	for i := range values {
+		s[i] = values[i].String()
+	}
+
+	return "[" + strings.Join(s, ", ") + "]"
+}
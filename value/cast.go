@@ -0,0 +1,114 @@
+package value
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CastAs converts v to the given type, following the rules of the SQL CAST expression.
+// It accepts every conversion supported by ConvertTo, plus the following ones:
+//
+//   - numbers, decimals, booleans, timestamps and durations can be cast to strings
+//   - strings can be cast to numbers, decimals, booleans, timestamps (RFC 3339) and durations (e.g. "1h30m")
+//   - booleans can be cast to integers, true being 1 and false 0
+//   - integers can be cast to booleans, 0 being false and any other value true
+//   - integers can be cast to durations, and durations to integers, as a number of nanoseconds
+//
+// Like ConvertTo, casting a number to another number type fails if the value overflows
+// or if it has a fractional part that would be lost.
+// Casting a string fails if it can't be parsed into the target type.
+// Null values are returned as is, whatever the type.
+// Any other conversion returns an error.
+func (v Value) CastAs(t Type) (Value, error) {
+	if v.Type == t || v.Type == Null {
+		return v, nil
+	}
+
+	switch {
+	case t == String && v.Type != Bytes:
+		return castToString(v)
+	case (v.Type == String || v.Type == Bytes) && (IsNumber(t) || t == Bool || t == Timestamp || t == Duration):
+		return castFromString(strings.TrimSpace(string(v.Data)), t)
+	case v.Type == Bool && IsInteger(t):
+		var n int64
+		if v.Data[0] == 1 {
+			n = 1
+		}
+		return convertNumber(NewInt64(n), t)
+	case IsInteger(v.Type) && t == Bool:
+		return NewBool(!IsZeroValue(v.Type, v.Data)), nil
+	case IsInteger(v.Type) && t == Duration:
+		// uint64 values might not fit into a duration
+		n, err := convertNumber(v, Int64)
+		if err != nil {
+			return Value{}, err
+		}
+		d, err := n.DecodeToDuration()
+		if err != nil {
+			return Value{}, err
+		}
+		return NewDuration(d), nil
+	case v.Type == Duration && IsInteger(t):
+		d, err := v.DecodeToDuration()
+		if err != nil {
+			return Value{}, err
+		}
+		return convertNumber(NewInt64(int64(d)), t)
+	}
+
+	return v.ConvertTo(t)
+}
+
+func castToString(v Value) (Value, error) {
+	switch {
+	case IsNumber(v.Type), v.Type == Decimal, v.Type == Bool, v.Type == Duration, v.Type == UUID:
+		return NewString(v.String()), nil
+	case v.Type == Timestamp:
+		ts, err := v.DecodeToTimestamp()
+		if err != nil {
+			return Value{}, err
+		}
+		return NewString(ts.Format(time.RFC3339Nano)), nil
+	}
+
+	return Value{}, fmt.Errorf("cannot cast %s to %s", v.Type, String)
+}
+
+func castFromString(s string, t Type) (Value, error) {
+	switch {
+	case IsFloat(t):
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return Value{}, fmt.Errorf("cannot cast %q to %s: invalid number", s, t)
+		}
+		return convertNumber(NewFloat64(f), t)
+	case IsInteger(t):
+		d, err := ParseDecimal(s)
+		if err != nil {
+			return Value{}, fmt.Errorf("cannot cast %q to %s: invalid number", s, t)
+		}
+		return convertDecimal(d, t)
+	case t == Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return Value{}, fmt.Errorf("cannot cast %q to %s: invalid boolean", s, t)
+		}
+		return NewBool(b), nil
+	case t == Timestamp:
+		ts, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return Value{}, fmt.Errorf("cannot cast %q to %s: invalid RFC 3339 timestamp", s, t)
+		}
+		return NewTimestamp(ts), nil
+	case t == Duration:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return Value{}, fmt.Errorf("cannot cast %q to %s: invalid duration", s, t)
+		}
+		return NewDuration(d), nil
+	}
+
+	return Value{}, fmt.Errorf("cannot cast %s to %s", String, t)
+}
@@ -0,0 +1,75 @@
+package value_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/asdine/genji/value"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCastAs(t *testing.T) {
+	ts := time.Date(2019, 11, 3, 10, 0, 0, 500, time.UTC)
+
+	tests := []struct {
+		name     string
+		v        value.Value
+		t        value.Type
+		expected value.Value
+		fails    bool
+	}{
+		{"same type", value.NewInt8(10), value.Int8, value.NewInt8(10), false},
+		{"null", value.NewNull(), value.Int8, value.NewNull(), false},
+		{"int64 to int8", value.NewInt64(-10), value.Int8, value.NewInt8(-10), false},
+		{"int64 to int8 / overflow", value.NewInt64(200), value.Int8, value.Value{}, true},
+		{"float64 to int32 / fractional part", value.NewFloat64(10.5), value.Int32, value.Value{}, true},
+		{"int64 to string", value.NewInt64(-10), value.String, value.NewString("-10"), false},
+		{"float64 to string", value.NewFloat64(1.5), value.String, value.NewString("1.5"), false},
+		{"bool to string", value.NewBool(true), value.String, value.NewString("true"), false},
+		{"decimal to string", mustParseDecimal("0.125"), value.String, value.NewString("0.125"), false},
+		{"timestamp to string", value.NewTimestamp(ts), value.String, value.NewString("2019-11-03T10:00:00.0000005Z"), false},
+		{"duration to string", value.NewDuration(90 * time.Minute), value.String, value.NewString("1h30m0s"), false},
+		{"array to string", value.NewArray(value.NewInt64(1)), value.String, value.Value{}, true},
+		{"string to int8", value.NewString(" -10 "), value.Int8, value.NewInt8(-10), false},
+		{"string to int8 / overflow", value.NewString("200"), value.Int8, value.Value{}, true},
+		{"string to int64 / fractional part", value.NewString("1.5"), value.Int64, value.Value{}, true},
+		{"string to uint64", value.NewString("18446744073709551615"), value.Uint64, value.NewUint64(math.MaxUint64), false},
+		{"string to int / invalid", value.NewString("foo"), value.Int, value.Value{}, true},
+		{"bytes to int64", value.NewBytes([]byte("10")), value.Int64, value.NewInt64(10), false},
+		{"string to float64", value.NewString("1e3"), value.Float64, value.NewFloat64(1000), false},
+		{"string to float32 / overflow", value.NewString("1e300"), value.Float32, value.Value{}, true},
+		{"string to bool", value.NewString("TRUE"), value.Bool, value.NewBool(true), false},
+		{"string to bool / invalid", value.NewString("yes"), value.Bool, value.Value{}, true},
+		{"string to timestamp", value.NewString("2019-11-03T10:00:00.0000005Z"), value.Timestamp, value.NewTimestamp(ts), false},
+		{"string to timestamp / invalid", value.NewString("2019-11-03"), value.Timestamp, value.Value{}, true},
+		{"string to duration", value.NewString("1h30m"), value.Duration, value.NewDuration(90 * time.Minute), false},
+		{"string to duration / invalid", value.NewString("1d"), value.Duration, value.Value{}, true},
+		{"bool to int8", value.NewBool(true), value.Int8, value.NewInt8(1), false},
+		{"bool to uint", value.NewBool(false), value.Uint, value.NewUint(0), false},
+		{"int64 to bool", value.NewInt64(-3), value.Bool, value.NewBool(true), false},
+		{"uint8 to bool", value.NewUint8(0), value.Bool, value.NewBool(false), false},
+		{"float64 to bool", value.NewFloat64(1), value.Bool, value.Value{}, true},
+		{"int64 to duration", value.NewInt64(1000), value.Duration, value.NewDuration(time.Microsecond), false},
+		{"uint64 to duration / overflow", value.NewUint64(math.MaxUint64), value.Duration, value.Value{}, true},
+		{"duration to int64", value.NewDuration(time.Second), value.Int64, value.NewInt64(1e9), false},
+		{"duration to int8 / overflow", value.NewDuration(time.Second), value.Int8, value.Value{}, true},
+		{"timestamp to int64", value.NewTimestamp(ts), value.Int64, value.Value{}, true},
+		{"uuid to string", value.NewUUID([16]byte{15: 1}), value.String, value.NewString("00000000-0000-0000-0000-000000000001"), false},
+		{"string to uuid", value.NewString("00000000-0000-0000-0000-000000000001"), value.UUID, value.NewUUID([16]byte{15: 1}), false},
+		{"string to decimal", value.NewString("12.50"), value.Decimal, mustParseDecimal("12.5"), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := test.v.CastAs(test.t)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expected, v)
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package value
+
+import (
+	"fmt"
+	"math"
+)
+
+// ConvertTo converts v to the given type.
+// Strings and bytes can be converted to one another and numbers can be converted
+// to any other number type as long as the conversion doesn't lose information:
+// converting an integer to a smaller integer type fails if the value overflows
+// and converting a float to an integer fails if the float has a fractional part.
+// Decimals can be converted to and from numbers and strings, converting a decimal to
+// an integer follows the same rules as floats.
+// UUIDs can be converted to and from strings and bytes, either as text or as their 16 raw bytes.
+// Null values are returned as is, whatever the type.
+// Any other conversion returns an error.
+func (v Value) ConvertTo(t Type) (Value, error) {
+	if v.Type == t || v.Type == Null {
+		return v, nil
+	}
+
+	switch {
+	case t == Bytes && v.Type == String:
+		return NewBytes(v.Data), nil
+	case t == String && v.Type == Bytes:
+		return NewString(string(v.Data)), nil
+	case IsNumber(t) && IsNumber(v.Type):
+		return convertNumber(v, t)
+	case t == Decimal && IsNumber(v.Type):
+		x, err := v.DecodeToDecimal()
+		if err != nil {
+			return Value{}, err
+		}
+		return NewDecimal(x), nil
+	case t == Decimal && (v.Type == String || v.Type == Bytes):
+		return ParseDecimal(string(v.Data))
+	case v.Type == Decimal && (t == String || t == Bytes):
+		return NewString(v.String()).ConvertTo(t)
+	case v.Type == Decimal && IsNumber(t):
+		return convertDecimal(v, t)
+	case t == UUID && (v.Type == String || v.Type == Bytes):
+		u, err := v.DecodeToUUID()
+		if err != nil {
+			return Value{}, err
+		}
+		return NewUUID(u), nil
+	case v.Type == UUID && t == String:
+		return NewString(v.String()), nil
+	case v.Type == UUID && t == Bytes:
+		return NewBytes(v.Data), nil
+	}
+
+	return Value{}, fmt.Errorf("cannot convert %s to %s", v.Type, t)
+}
+
+func convertDecimal(v Value, t Type) (Value, error) {
+	x, err := v.DecodeToDecimal()
+	if err != nil {
+		return Value{}, err
+	}
+
+	if IsFloat(t) {
+		f, _ := x.Float64()
+		return convertNumber(NewFloat64(f), t)
+	}
+
+	if !x.IsInt() {
+		return Value{}, fmt.Errorf("cannot convert %s to %s without losing precision", v, t)
+	}
+
+	n := x.Num()
+	switch {
+	case n.IsInt64():
+		return convertNumber(NewInt64(n.Int64()), t)
+	case n.IsUint64():
+		return convertNumber(NewUint64(n.Uint64()), t)
+	}
+
+	return Value{}, fmt.Errorf("cannot convert %s to %s: value out of range", v, t)
+}
+
+func convertNumber(v Value, t Type) (Value, error) {
+	if IsFloat(t) {
+		f, err := v.DecodeToFloat64()
+		if err != nil {
+			return Value{}, err
+		}
+
+		if t == Float32 {
+			if !math.IsInf(f, 0) && math.Abs(f) > math.MaxFloat32 {
+				return Value{}, fmt.Errorf("cannot convert %s to %s: value out of range", v, t)
+			}
+			return NewFloat32(float32(f)), nil
+		}
+
+		return NewFloat64(f), nil
+	}
+
+	// the target is an integer, make sure the source value is an integer
+	// that fits in the target type.
+	var neg bool
+	var u uint64
+
+	switch v.Type {
+	case Uint, Uint8, Uint16, Uint32, Uint64:
+		x, err := v.DecodeToUint64()
+		if err != nil {
+			return Value{}, err
+		}
+		u = x
+	case Int, Int8, Int16, Int32, Int64:
+		x, err := v.DecodeToInt64()
+		if err != nil {
+			return Value{}, err
+		}
+		if x < 0 {
+			neg = true
+			u = uint64(-(x + 1)) + 1
+		} else {
+			u = uint64(x)
+		}
+	case Float32, Float64:
+		f, err := v.DecodeToFloat64()
+		if err != nil {
+			return Value{}, err
+		}
+		if f != math.Trunc(f) || math.IsInf(f, 0) || math.IsNaN(f) {
+			return Value{}, fmt.Errorf("cannot convert %s to %s without losing precision", v, t)
+		}
+		if f < 0 {
+			if f < math.MinInt64 {
+				return Value{}, fmt.Errorf("cannot convert %s to %s: value out of range", v, t)
+			}
+			neg = true
+			x := int64(f)
+			u = uint64(-(x + 1)) + 1
+		} else {
+			if f >= math.MaxUint64 {
+				return Value{}, fmt.Errorf("cannot convert %s to %s: value out of range", v, t)
+			}
+			u = uint64(f)
+		}
+	}
+
+	var max uint64
+	switch t {
+	case Uint8:
+		max = math.MaxUint8
+	case Uint16:
+		max = math.MaxUint16
+	case Uint32:
+		max = math.MaxUint32
+	case Uint, Uint64:
+		max = math.MaxUint64
+	case Int8:
+		max = math.MaxInt8
+	case Int16:
+		max = math.MaxInt16
+	case Int32:
+		max = math.MaxInt32
+	case Int, Int64:
+		max = math.MaxInt64
+	}
+
+	if neg {
+		// negative numbers can only be converted to signed integers
+		// whose minimum is -(max + 1).
+		if t < Int || u > max+1 {
+			return Value{}, fmt.Errorf("cannot convert %s to %s: value out of range", v, t)
+		}
+		x := -int64(u-1) - 1
+		switch t {
+		case Int:
+			return NewInt(int(x)), nil
+		case Int8:
+			return NewInt8(int8(x)), nil
+		case Int16:
+			return NewInt16(int16(x)), nil
+		case Int32:
+			return NewInt32(int32(x)), nil
+		}
+		return NewInt64(x), nil
+	}
+
+	if u > max {
+		return Value{}, fmt.Errorf("cannot convert %s to %s: value out of range", v, t)
+	}
+
+	switch t {
+	case Uint:
+		return NewUint(uint(u)), nil
+	case Uint8:
+		return NewUint8(uint8(u)), nil
+	case Uint16:
+		return NewUint16(uint16(u)), nil
+	case Uint32:
+		return NewUint32(uint32(u)), nil
+	case Uint64:
+		return NewUint64(u), nil
+	case Int:
+		return NewInt(int(u)), nil
+	case Int8:
+		return NewInt8(int8(u)), nil
+	case Int16:
+		return NewInt16(int16(u)), nil
+	case Int32:
+		return NewInt32(int32(u)), nil
+	}
+
+	return NewInt64(int64(u)), nil
+}
@@ -0,0 +1,76 @@
+package value_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/asdine/genji/value"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		v        value.Value
+		t        value.Type
+		expected value.Value
+		fails    bool
+	}{
+		{"same type", value.NewInt8(10), value.Int8, value.NewInt8(10), false},
+		{"string to bytes", value.NewString("foo"), value.Bytes, value.NewBytes([]byte("foo")), false},
+		{"bytes to string", value.NewBytes([]byte("foo")), value.String, value.NewString("foo"), false},
+		{"int64 to int8", value.NewInt64(-10), value.Int8, value.NewInt8(-10), false},
+		{"int64 to int8 / min", value.NewInt64(math.MinInt8), value.Int8, value.NewInt8(math.MinInt8), false},
+		{"int64 to int8 / overflow", value.NewInt64(200), value.Int8, value.Value{}, true},
+		{"int64 to int8 / underflow", value.NewInt64(-200), value.Int8, value.Value{}, true},
+		{"int64 to uint8", value.NewInt64(200), value.Uint8, value.NewUint8(200), false},
+		{"negative int to uint", value.NewInt64(-1), value.Uint64, value.Value{}, true},
+		{"int64 min to int64", value.NewInt(math.MinInt64), value.Int64, value.NewInt64(math.MinInt64), false},
+		{"uint64 to int64 / overflow", value.NewUint64(math.MaxUint64), value.Int64, value.Value{}, true},
+		{"float64 to int32", value.NewFloat64(-10), value.Int32, value.NewInt32(-10), false},
+		{"float64 to int32 / fractional part", value.NewFloat64(10.5), value.Int32, value.Value{}, true},
+		{"int64 to float64", value.NewInt64(10), value.Float64, value.NewFloat64(10), false},
+		{"float64 to float32", value.NewFloat64(1.5), value.Float32, value.NewFloat32(1.5), false},
+		{"float64 to float32 / overflow", value.NewFloat64(math.MaxFloat64), value.Float32, value.Value{}, true},
+		{"string to int", value.NewString("10"), value.Int, value.Value{}, true},
+		{"bool to int", value.NewBool(true), value.Int, value.Value{}, true},
+		{"int64 to decimal", value.NewInt64(-10), value.Decimal, mustParseDecimal("-10"), false},
+		{"float64 to decimal", value.NewFloat64(0.1), value.Decimal, mustParseDecimal("0.1"), false},
+		{"string to decimal", value.NewString("12.50"), value.Decimal, mustParseDecimal("12.5"), false},
+		{"string to decimal / invalid", value.NewString("foo"), value.Decimal, value.Value{}, true},
+		{"decimal to string", mustParseDecimal("-0.5"), value.String, value.NewString("-0.5"), false},
+		{"decimal to int8", mustParseDecimal("100"), value.Int8, value.NewInt8(100), false},
+		{"decimal to int8 / fractional part", mustParseDecimal("1.5"), value.Int8, value.Value{}, true},
+		{"decimal to uint64", mustParseDecimal("18446744073709551615"), value.Uint64, value.NewUint64(math.MaxUint64), false},
+		{"decimal to int64 / overflow", mustParseDecimal("18446744073709551616"), value.Int64, value.Value{}, true},
+		{"decimal to float64", mustParseDecimal("0.25"), value.Float64, value.NewFloat64(0.25), false},
+		{"string to uuid", value.NewString("F47AC10B-58CC-4372-A567-0E02B2C3D479"), value.UUID,
+			value.NewUUID([16]byte{0xf4, 0x7a, 0xc1, 0x0b, 0x58, 0xcc, 0x43, 0x72, 0xa5, 0x67, 0x0e, 0x02, 0xb2, 0xc3, 0xd4, 0x79}), false},
+		{"string to uuid / invalid", value.NewString("foo"), value.UUID, value.Value{}, true},
+		{"bytes to uuid", value.NewBytes([]byte{15: 1}), value.UUID, value.NewUUID([16]byte{15: 1}), false},
+		{"uuid to string", value.NewUUID([16]byte{15: 1}), value.String, value.NewString("00000000-0000-0000-0000-000000000001"), false},
+		{"uuid to int64", value.NewUUID([16]byte{15: 1}), value.Int64, value.Value{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := test.v.ConvertTo(test.t)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expected, v)
+		})
+	}
+}
+
+func mustParseDecimal(s string) value.Value {
+	v, err := value.ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
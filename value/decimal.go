@@ -0,0 +1,268 @@
+package value
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DecimalPrecision is the number of significant digits kept when a rational number
+// can't be represented exactly by a decimal, like 1/3.
+// Decimals with a finite expansion are always stored exactly.
+const DecimalPrecision = 34
+
+// first byte of encoded decimals, chosen so that negative numbers
+// sort before zero, which sorts before positive numbers.
+const (
+	decimalNegative byte = iota + 1
+	decimalZero
+	decimalPositive
+)
+
+var (
+	bigOne  = big.NewInt(1)
+	bigFive = big.NewInt(5)
+	bigTen  = big.NewInt(10)
+)
+
+// NewDecimal encodes x and returns a value of type Decimal.
+func NewDecimal(x *big.Rat) Value {
+	return Value{
+		Type: Decimal,
+		Data: EncodeDecimal(x),
+	}
+}
+
+// ParseDecimal parses a decimal number, like "12.50" or "-1e-3", and returns a value of type Decimal.
+// Fractions, like "1/3", are also accepted.
+func ParseDecimal(s string) (Value, error) {
+	x, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return Value{}, fmt.Errorf("cannot parse %q as decimal", s)
+	}
+
+	return NewDecimal(x), nil
+}
+
+// EncodeDecimal takes a rational number and returns its binary representation.
+// The number is stored as a list of decimal digits and an exponent, in a way that
+// preserves the ordering of the numbers when comparing the encoded data.
+// Numbers that don't have a finite decimal expansion are rounded to DecimalPrecision
+// significant digits.
+func EncodeDecimal(x *big.Rat) []byte {
+	if x.Sign() == 0 {
+		return []byte{decimalZero}
+	}
+
+	digits, exp := decimalDigits(new(big.Rat).Abs(x))
+
+	buf := make([]byte, 0, 5+len(digits)+1)
+	if x.Sign() > 0 {
+		buf = append(buf, decimalPositive)
+		buf = append(buf, EncodeInt32(int32(exp))...)
+		return append(buf, digits...)
+	}
+
+	// the exponent and the digits of negative numbers are inverted
+	// and followed by a terminator so that -0.12 sorts after -0.123.
+	buf = append(buf, decimalNegative)
+	buf = append(buf, EncodeInt32(int32(-exp))...)
+	for i := 0; i < len(digits); i++ {
+		buf = append(buf, '9'-digits[i]+'0')
+	}
+	return append(buf, 0xFF)
+}
+
+// DecodeDecimal takes a byte slice and decodes it into a rational number.
+func DecodeDecimal(buf []byte) (*big.Rat, error) {
+	digits, exp, neg, err := decodeDecimalDigits(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if digits == "" {
+		return new(big.Rat), nil
+	}
+
+	n, _ := new(big.Int).SetString(digits, 10)
+	if neg {
+		n.Neg(n)
+	}
+
+	x := new(big.Rat).SetInt(n)
+	scale := new(big.Int).Exp(bigTen, big.NewInt(int64(abs(len(digits)-exp))), nil)
+	if len(digits) >= exp {
+		return x.Quo(x, new(big.Rat).SetInt(scale)), nil
+	}
+
+	return x.Mul(x, new(big.Rat).SetInt(scale)), nil
+}
+
+// DecodeToDecimal turns a value of type Decimal or any number into a rational number.
+// Floats are converted using the shortest decimal representation that
+// identifies them, so 0.1 is converted to 1/10.
+// It doesn't work with other types.
+func (v Value) DecodeToDecimal() (*big.Rat, error) {
+	switch {
+	case v.Type == Decimal:
+		return DecodeDecimal(v.Data)
+	case v.Type == Uint64 || v.Type == Uint:
+		x, err := v.DecodeToUint64()
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Rat).SetInt(new(big.Int).SetUint64(x)), nil
+	case IsInteger(v.Type):
+		x, err := decodeAsInt64(v)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Rat).SetInt64(x), nil
+	case IsFloat(v.Type):
+		f, err := v.DecodeToFloat64()
+		if err != nil {
+			return nil, err
+		}
+
+		bitSize := 64
+		if v.Type == Float32 {
+			bitSize = 32
+		}
+		x, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, bitSize))
+		if !ok {
+			return nil, fmt.Errorf("cannot convert %v to decimal", f)
+		}
+		return x, nil
+	}
+
+	return nil, fmt.Errorf("can't convert %q to decimal", v.Type)
+}
+
+// decimalDigits returns the significant digits of a positive number and its exponent,
+// so that x = 0.digits * 10^exp.
+func decimalDigits(x *big.Rat) (string, int) {
+	num, den := x.Num(), x.Denom()
+
+	// a rational number has a finite decimal expansion if its
+	// denominator only has 2 and 5 as prime factors.
+	d := new(big.Int).Set(den)
+	twos := int(d.TrailingZeroBits())
+	d.Rsh(d, uint(twos))
+
+	var fives int
+	q, r := new(big.Int), new(big.Int)
+	for {
+		q.QuoRem(d, bigFive, r)
+		if r.Sign() != 0 {
+			break
+		}
+		d.Set(q)
+		fives++
+	}
+
+	var n *big.Int
+	var scale int
+	if d.Cmp(bigOne) == 0 {
+		scale = twos
+		if fives > scale {
+			scale = fives
+		}
+		n = mulPow10(num, scale)
+		n.Quo(n, den)
+	} else {
+		// compute one more digit than necessary to round the last one
+		scale = DecimalPrecision + len(den.String()) - len(num.String()) + 1
+		if scale >= 0 {
+			n = mulPow10(num, scale)
+			n.Quo(n, den)
+		} else {
+			n = new(big.Int).Quo(num, mulPow10(den, -scale))
+		}
+		n.Add(n, bigFive)
+		n.Quo(n, bigTen)
+		scale--
+	}
+
+	s := n.String()
+	return strings.TrimRight(s, "0"), len(s) - scale
+}
+
+// mulPow10 returns x * 10^n. n must be positive.
+func mulPow10(x *big.Int, n int) *big.Int {
+	p := new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+	return p.Mul(x, p)
+}
+
+func decodeDecimalDigits(buf []byte) (digits string, exp int, neg bool, err error) {
+	if len(buf) == 0 {
+		return "", 0, false, errors.New("cannot decode buffer to decimal")
+	}
+
+	switch buf[0] {
+	case decimalZero:
+		return "", 0, false, nil
+	case decimalPositive:
+		if len(buf) < 6 {
+			return "", 0, false, errors.New("cannot decode buffer to decimal")
+		}
+		e, err := DecodeInt32(buf[1:5])
+		if err != nil {
+			return "", 0, false, err
+		}
+		return string(buf[5:]), int(e), false, nil
+	case decimalNegative:
+		if len(buf) < 7 || buf[len(buf)-1] != 0xFF {
+			return "", 0, false, errors.New("cannot decode buffer to decimal")
+		}
+		e, err := DecodeInt32(buf[1:5])
+		if err != nil {
+			return "", 0, false, err
+		}
+
+		d := make([]byte, len(buf)-6)
+		for i := range d {
+			d[i] = '9' - buf[5+i] + '0'
+		}
+		return string(d), -int(e), true, nil
+	}
+
+	return "", 0, false, errors.New("cannot decode buffer to decimal")
+}
+
+// decimalString returns the representation of an encoded decimal, without exponent.
+func decimalString(buf []byte) string {
+	digits, exp, neg, err := decodeDecimalDigits(buf)
+	if err != nil {
+		return ""
+	}
+
+	if digits == "" {
+		return "0"
+	}
+
+	var s string
+	switch {
+	case exp <= 0:
+		s = "0." + strings.Repeat("0", -exp) + digits
+	case exp >= len(digits):
+		s = digits + strings.Repeat("0", exp-len(digits))
+	default:
+		s = digits[:exp] + "." + digits[exp:]
+	}
+
+	if neg {
+		return "-" + s
+	}
+
+	return s
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
@@ -0,0 +1,85 @@
+package value_test
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/asdine/genji/value"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{"0", "0"},
+		{"12.50", "12.5"},
+		{"-12.50", "-12.5"},
+		{"0.001", "0.001"},
+		{"-1e-3", "-0.001"},
+		{"1200", "1200"},
+		{"123456789012345678901234567890.123456789", "123456789012345678901234567890.123456789"},
+		{"1/3", "0.3333333333333333333333333333333333"},
+		{"-2/3", "-0.6666666666666666666666666666666667"},
+		{"100000/3", "33333.33333333333333333333333333333"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			v, err := value.ParseDecimal(test.input)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, v.String())
+
+			x, err := v.DecodeToDecimal()
+			require.NoError(t, err)
+			expected, _ := new(big.Rat).SetString(test.expected)
+			require.Zero(t, expected.Cmp(x), "%s", x)
+
+			// the encoding of the decoded number must be identical
+			require.Equal(t, v.Data, value.EncodeDecimal(x))
+		})
+	}
+
+	_, err := value.ParseDecimal("foo")
+	require.Error(t, err)
+}
+
+func TestDecimalOrdering(t *testing.T) {
+	numbers := []string{"-1000", "-12.5", "-12.345", "-12.34", "-1", "-0.5", "-0.05", "0", "0.05", "0.5", "1", "12.34", "12.345", "12.5", "1000"}
+
+	var prev []byte
+	for _, n := range numbers {
+		v, err := value.ParseDecimal(n)
+		require.NoError(t, err)
+
+		if prev != nil {
+			require.Equal(t, -1, bytes.Compare(prev, v.Data), "%s", n)
+		}
+		prev = v.Data
+	}
+}
+
+func TestDecodeToDecimal(t *testing.T) {
+	tests := []struct {
+		name     string
+		v        value.Value
+		expected *big.Rat
+	}{
+		{"int64", value.NewInt64(-10), big.NewRat(-10, 1)},
+		{"uint64", value.NewUint64(1 << 63), new(big.Rat).SetUint64(1 << 63)},
+		{"float64", value.NewFloat64(0.1), big.NewRat(1, 10)},
+		{"float32", value.NewFloat32(0.1), big.NewRat(1, 10)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			x, err := test.v.DecodeToDecimal()
+			require.NoError(t, err)
+			require.Zero(t, test.expected.Cmp(x), "%s", x)
+		})
+	}
+
+	_, err := value.NewString("1").DecodeToDecimal()
+	require.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package value
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// NewTimestamp encodes x and returns a value.
+func NewTimestamp(x time.Time) Value {
+	return Value{
+		Type: Timestamp,
+		Data: EncodeTimestamp(x),
+	}
+}
+
+// NewDuration encodes x and returns a value.
+func NewDuration(x time.Duration) Value {
+	return Value{
+		Type: Duration,
+		Data: EncodeDuration(x),
+	}
+}
+
+// EncodeTimestamp takes a time and returns its binary representation.
+// The number of seconds since the Unix epoch is followed by the nanoseconds,
+// which preserves the ordering of timestamps when comparing the encoded data.
+// The location is not encoded, decoded timestamps are always in UTC.
+func EncodeTimestamp(x time.Time) []byte {
+	buf := make([]byte, 12)
+	copy(buf, EncodeInt64(x.Unix()))
+	copy(buf[8:], EncodeUint32(uint32(x.Nanosecond())))
+	return buf
+}
+
+// DecodeTimestamp takes a byte slice and decodes it into a time in UTC.
+func DecodeTimestamp(buf []byte) (time.Time, error) {
+	if len(buf) < 12 {
+		return time.Time{}, errors.New("cannot decode buffer to timestamp")
+	}
+
+	sec, err := DecodeInt64(buf[:8])
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	nsec, err := DecodeUint32(buf[8:])
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(sec, int64(nsec)).UTC(), nil
+}
+
+// EncodeDuration takes a duration and returns its binary representation.
+func EncodeDuration(x time.Duration) []byte {
+	return EncodeInt64(int64(x))
+}
+
+// DecodeDuration takes a byte slice and decodes it into a duration.
+func DecodeDuration(buf []byte) (time.Duration, error) {
+	x, err := DecodeInt64(buf)
+	return time.Duration(x), err
+}
+
+// DecodeToTimestamp returns the time stored in a value of type Timestamp.
+// It fails if it's used with any other type.
+func (v Value) DecodeToTimestamp() (time.Time, error) {
+	if v.Type != Timestamp {
+		return time.Time{}, fmt.Errorf("can't convert %q to timestamp", v.Type)
+	}
+
+	return DecodeTimestamp(v.Data)
+}
+
+// DecodeToDuration returns the duration stored in a value of type Duration.
+// Integers are interpreted as a number of nanoseconds.
+// It doesn't work with other types.
+func (v Value) DecodeToDuration() (time.Duration, error) {
+	if v.Type == Duration {
+		return DecodeDuration(v.Data)
+	}
+
+	if IsInteger(v.Type) {
+		x, err := decodeAsInt64(v)
+		return time.Duration(x), err
+	}
+
+	return 0, fmt.Errorf("can't convert %q to duration", v.Type)
+}
@@ -0,0 +1,123 @@
+package value
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// NewUUID encodes x and returns a value of type UUID.
+func NewUUID(x [16]byte) Value {
+	return Value{
+		Type: UUID,
+		Data: EncodeUUID(x),
+	}
+}
+
+// ParseUUID parses a UUID and returns a value of type UUID.
+// The UUID must be written in its canonical form, e.g. "f47ac10b-58cc-4372-a567-0e02b2c3d479",
+// optionally surrounded by braces or prefixed by "urn:uuid:", or as 32 hexadecimal digits.
+func ParseUUID(s string) (Value, error) {
+	u, err := parseUUID(s)
+	if err != nil {
+		return Value{}, err
+	}
+
+	return NewUUID(u), nil
+}
+
+// EncodeUUID takes a UUID and returns its binary representation.
+// UUIDs are stored as is, in big-endian order, so time-ordered UUIDs
+// are sorted chronologically.
+func EncodeUUID(x [16]byte) []byte {
+	return x[:]
+}
+
+// DecodeUUID takes a byte slice and decodes it into a UUID.
+func DecodeUUID(buf []byte) ([16]byte, error) {
+	var u [16]byte
+	if len(buf) != len(u) {
+		return u, errors.New("cannot decode buffer to uuid")
+	}
+
+	copy(u[:], buf)
+	return u, nil
+}
+
+// DecodeToUUID turns a value of type UUID, String or Bytes into a UUID.
+// Strings are parsed, bytes are either parsed or, if they are 16 bytes long,
+// used as is.
+// It doesn't work with other types.
+func (v Value) DecodeToUUID() ([16]byte, error) {
+	switch v.Type {
+	case UUID:
+		return DecodeUUID(v.Data)
+	case Bytes:
+		if len(v.Data) == 16 {
+			return DecodeUUID(v.Data)
+		}
+		return parseUUID(string(v.Data))
+	case String:
+		return parseUUID(string(v.Data))
+	}
+
+	return [16]byte{}, fmt.Errorf("can't convert %q to uuid", v.Type)
+}
+
+func parseUUID(s string) ([16]byte, error) {
+	var u [16]byte
+
+	x := strings.TrimPrefix(strings.ToLower(s), "urn:uuid:")
+	if len(x) == 38 && x[0] == '{' && x[37] == '}' {
+		x = x[1:37]
+	}
+
+	if len(x) == 36 {
+		if x[8] != '-' || x[13] != '-' || x[18] != '-' || x[23] != '-' {
+			return u, fmt.Errorf("cannot parse %q as uuid", s)
+		}
+		x = x[:8] + x[9:13] + x[14:18] + x[19:23] + x[24:]
+	}
+
+	if len(x) != 32 {
+		return u, fmt.Errorf("cannot parse %q as uuid", s)
+	}
+
+	_, err := hex.Decode(u[:], []byte(x))
+	if err != nil {
+		return u, fmt.Errorf("cannot parse %q as uuid", s)
+	}
+
+	return u, nil
+}
+
+// formatUUID returns the canonical representation of u.
+func formatUUID(u [16]byte) string {
+	var buf [36]byte
+
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+
+	return string(buf[:])
+}
+
+var uuidType = reflect.TypeOf([16]byte{})
+
+// uuidFromArray returns the content of x if its underlying type is [16]byte.
+func uuidFromArray(x interface{}) ([16]byte, bool) {
+	v := reflect.ValueOf(x)
+	if !v.IsValid() || !v.Type().ConvertibleTo(uuidType) {
+		return [16]byte{}, false
+	}
+
+	return v.Convert(uuidType).Interface().([16]byte), true
+}
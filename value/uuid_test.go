@@ -0,0 +1,62 @@
+package value_test
+
+import (
+	"testing"
+
+	"github.com/asdine/genji/value"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUUID(t *testing.T) {
+	expected := [16]byte{0xf4, 0x7a, 0xc1, 0x0b, 0x58, 0xcc, 0x43, 0x72, 0xa5, 0x67, 0x0e, 0x02, 0xb2, 0xc3, 0xd4, 0x79}
+
+	tests := []struct {
+		input string
+		fails bool
+	}{
+		{"f47ac10b-58cc-4372-a567-0e02b2c3d479", false},
+		{"F47AC10B-58CC-4372-A567-0E02B2C3D479", false},
+		{"{f47ac10b-58cc-4372-a567-0e02b2c3d479}", false},
+		{"urn:uuid:f47ac10b-58cc-4372-a567-0e02b2c3d479", false},
+		{"f47ac10b58cc4372a5670e02b2c3d479", false},
+		{"f47ac10b-58cc-4372-a567-0e02b2c3d47", true},
+		{"f47ac10b-58cc-4372-a5670-e02b2c3d479", true},
+		{"g47ac10b-58cc-4372-a567-0e02b2c3d479", true},
+		{"", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			v, err := value.ParseUUID(test.input)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, value.UUID, v.Type)
+			require.Equal(t, "f47ac10b-58cc-4372-a567-0e02b2c3d479", v.String())
+
+			u, err := v.DecodeToUUID()
+			require.NoError(t, err)
+			require.Equal(t, expected, u)
+		})
+	}
+}
+
+func TestNewUUID(t *testing.T) {
+	// most UUID packages define their own type
+	type myUUID [16]byte
+
+	u := myUUID{1, 2, 3}
+
+	v, err := value.New(u)
+	require.NoError(t, err)
+	require.Equal(t, value.NewUUID([16]byte(u)), v)
+
+	x, err := v.Decode()
+	require.NoError(t, err)
+	require.Equal(t, [16]byte(u), x)
+
+	require.True(t, value.IsZeroValue(value.UUID, value.EncodeUUID([16]byte{})))
+	require.False(t, value.IsZeroValue(value.UUID, v.Data))
+}
@@ -7,6 +7,8 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"math/big"
+	"time"
 )
 
 // Type represents a value type supported by the database.
@@ -29,6 +31,13 @@ const (
 	Int64
 	Float32
 	Float64
+	Null
+	Document
+	Array
+	Timestamp
+	Duration
+	Decimal
+	UUID
 )
 
 func (t Type) String() string {
@@ -63,6 +72,20 @@ func (t Type) String() string {
 		return "Float32"
 	case Float64:
 		return "Float64"
+	case Null:
+		return "Null"
+	case Document:
+		return "Document"
+	case Array:
+		return "Array"
+	case Timestamp:
+		return "Timestamp"
+	case Duration:
+		return "Duration"
+	case Decimal:
+		return "Decimal"
+	case UUID:
+		return "UUID"
 	}
 
 	return ""
@@ -101,6 +124,10 @@ func TypeFromGoType(tp string) Type {
 		return Float32
 	case "float64":
 		return Float64
+	case "time.Time":
+		return Timestamp
+	case "time.Duration":
+		return Duration
 	}
 
 	return 0
@@ -116,6 +143,8 @@ type Value struct {
 // New creates a value whose type is infered from x.
 func New(x interface{}) (Value, error) {
 	switch v := x.(type) {
+	case nil:
+		return NewNull(), nil
 	case []byte:
 		return NewBytes(v), nil
 	case string:
@@ -146,7 +175,21 @@ func New(x interface{}) (Value, error) {
 		return NewFloat32(v), nil
 	case float64:
 		return NewFloat64(v), nil
+	case time.Time:
+		return NewTimestamp(v), nil
+	case time.Duration:
+		return NewDuration(v), nil
+	case *big.Rat:
+		return NewDecimal(v), nil
+	case big.Rat:
+		return NewDecimal(&v), nil
+	case [16]byte:
+		return NewUUID(v), nil
 	default:
+		// most UUID packages define their UUID type as a [16]byte
+		if u, ok := uuidFromArray(x); ok {
+			return NewUUID(u), nil
+		}
 		return Value{}, fmt.Errorf("unsupported type %t", x)
 	}
 }
@@ -271,6 +314,22 @@ func NewFloat64(x float64) Value {
 	}
 }
 
+// NewNull returns a value of type Null. Null values have no data.
+func NewNull() Value {
+	return Value{
+		Type: Null,
+	}
+}
+
+// NewDocument returns a value of type Document. The data must be a record encoded
+// with the record package, which also provides helpers to create and decode documents.
+func NewDocument(data []byte) Value {
+	return Value{
+		Type: Document,
+		Data: data,
+	}
+}
+
 func (v *Value) decode() error {
 	var err error
 
@@ -305,6 +364,21 @@ func (v *Value) decode() error {
 		v.v, err = DecodeFloat32(v.Data)
 	case Float64:
 		v.v, err = DecodeFloat64(v.Data)
+	case Null:
+		v.v = nil
+	case Document:
+		// documents are decoded by the record package
+		v.v = v.Data
+	case Array:
+		v.v, err = decodeArrayToInterfaces(v.Data)
+	case Timestamp:
+		v.v, err = DecodeTimestamp(v.Data)
+	case Duration:
+		v.v, err = DecodeDuration(v.Data)
+	case Decimal:
+		v.v, err = DecodeDecimal(v.Data)
+	case UUID:
+		v.v, err = DecodeUUID(v.Data)
 	default:
 		return errors.New("unknown type")
 	}
@@ -360,6 +434,22 @@ func (v Value) String() string {
 		vv, _ = DecodeFloat32(v.Data)
 	case Float64:
 		vv, _ = DecodeFloat64(v.Data)
+	case Null:
+		return "NULL"
+	case Document:
+		vv = v.Data
+	case Array:
+		return arrayString(v.Data)
+	case Timestamp:
+		t, _ := DecodeTimestamp(v.Data)
+		return t.Format(time.RFC3339Nano)
+	case Duration:
+		vv, _ = DecodeDuration(v.Data)
+	case Decimal:
+		return decimalString(v.Data)
+	case UUID:
+		u, _ := DecodeUUID(v.Data)
+		return formatUUID(u)
 	}
 
 	return fmt.Sprintf("%v", vv)
@@ -581,6 +671,14 @@ func (v Value) DecodeToFloat32() (float32, error) {
 		return float32(f), err
 	}
 
+	if v.Type == Uint64 || v.Type == Uint {
+		x, err := v.DecodeToUint64()
+		if err != nil {
+			return 0, err
+		}
+		return float32(x), nil
+	}
+
 	if IsInteger(v.Type) {
 		x, err := decodeAsInt64(v)
 		if err != nil {
@@ -604,6 +702,14 @@ func (v Value) DecodeToFloat64() (float64, error) {
 		return float64(f), err
 	}
 
+	if v.Type == Uint64 || v.Type == Uint {
+		x, err := v.DecodeToUint64()
+		if err != nil {
+			return 0, err
+		}
+		return float64(x), nil
+	}
+
 	if IsInteger(v.Type) {
 		x, err := decodeAsInt64(v)
 		if err != nil {
@@ -949,27 +1055,42 @@ func ZeroValue(t Type) Value {
 		return NewFloat32(0)
 	case Float64:
 		return NewFloat64(0)
+	case Null:
+		return NewNull()
+	case Array:
+		return NewArray()
+	case Timestamp:
+		return NewTimestamp(time.Time{})
+	case Duration:
+		return NewDuration(0)
+	case Decimal:
+		return NewDecimal(new(big.Rat))
+	case UUID:
+		return NewUUID([16]byte{})
 	}
 
 	return Value{}
 }
 
 var (
-	bytesZeroValue   = ZeroValue(Bytes)
-	stringZeroValue  = ZeroValue(String)
-	boolZeroValue    = ZeroValue(Bool)
-	uintZeroValue    = ZeroValue(Uint)
-	uint8ZeroValue   = ZeroValue(Uint8)
-	uint16ZeroValue  = ZeroValue(Uint16)
-	uint32ZeroValue  = ZeroValue(Uint32)
-	uint64ZeroValue  = ZeroValue(Uint64)
-	intZeroValue     = ZeroValue(Int)
-	int8ZeroValue    = ZeroValue(Int8)
-	int16ZeroValue   = ZeroValue(Int16)
-	int32ZeroValue   = ZeroValue(Int32)
-	int64ZeroValue   = ZeroValue(Int64)
-	float32ZeroValue = ZeroValue(Float32)
-	float64ZeroValue = ZeroValue(Float64)
+	bytesZeroValue     = ZeroValue(Bytes)
+	stringZeroValue    = ZeroValue(String)
+	boolZeroValue      = ZeroValue(Bool)
+	uintZeroValue      = ZeroValue(Uint)
+	uint8ZeroValue     = ZeroValue(Uint8)
+	uint16ZeroValue    = ZeroValue(Uint16)
+	uint32ZeroValue    = ZeroValue(Uint32)
+	uint64ZeroValue    = ZeroValue(Uint64)
+	intZeroValue       = ZeroValue(Int)
+	int8ZeroValue      = ZeroValue(Int8)
+	int16ZeroValue     = ZeroValue(Int16)
+	int32ZeroValue     = ZeroValue(Int32)
+	int64ZeroValue     = ZeroValue(Int64)
+	float32ZeroValue   = ZeroValue(Float32)
+	float64ZeroValue   = ZeroValue(Float64)
+	timestampZeroValue = ZeroValue(Timestamp)
+	durationZeroValue  = ZeroValue(Duration)
+	uuidZeroValue      = ZeroValue(UUID)
 )
 
 // IsZeroValue indicates if the value data is the zero value for the value type.
@@ -1006,6 +1127,18 @@ func IsZeroValue(t Type, data []byte) bool {
 		return bytes.Equal(data, float32ZeroValue.Data)
 	case Float64:
 		return bytes.Equal(data, float64ZeroValue.Data)
+	case Null:
+		return true
+	case Array:
+		return len(data) == 0
+	case Timestamp:
+		return bytes.Equal(data, timestampZeroValue.Data)
+	case Duration:
+		return bytes.Equal(data, durationZeroValue.Data)
+	case Decimal:
+		return len(data) == 1 && data[0] == decimalZero
+	case UUID:
+		return bytes.Equal(data, uuidZeroValue.Data)
 	}
 
 	return false
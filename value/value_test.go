@@ -3,6 +3,7 @@ package value_test
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/asdine/genji/value"
 	"github.com/stretchr/testify/require"
@@ -109,6 +110,10 @@ func TestFieldString(t *testing.T) {
 		{"int64", value.NewInt64(10), "10"},
 		{"float32", value.NewFloat32(10.1), "10.1"},
 		{"float64", value.NewFloat64(10.1), "10.1"},
+		{"null", value.NewNull(), "NULL"},
+		{"timestamp", value.NewTimestamp(time.Date(2019, 11, 3, 10, 0, 0, 5, time.FixedZone("", 3600))), "2019-11-03T09:00:00.000000005Z"},
+		{"duration", value.NewDuration(90 * time.Minute), "1h30m0s"},
+		{"array", value.NewArray(value.NewString("a"), value.NewInt64(1)), "[a, 1]"},
 	}
 
 	for _, test := range tests {
@@ -119,6 +124,61 @@ func TestFieldString(t *testing.T) {
 
 }
 
+func TestArray(t *testing.T) {
+	values := []value.Value{value.NewString("a"), value.NewNull(), value.NewArray(value.NewInt8(1))}
+	v := value.NewArray(values...)
+
+	l, err := v.DecodeToArray()
+	require.NoError(t, err)
+	require.Len(t, l, len(values))
+	for i := range values {
+		require.Equal(t, values[i].Type, l[i].Type)
+		require.Equal(t, string(values[i].Data), string(l[i].Data))
+	}
+
+	x, err := v.Decode()
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"a", nil, []interface{}{int8(1)}}, x)
+
+	l, err = value.NewArray().DecodeToArray()
+	require.NoError(t, err)
+	require.Empty(t, l)
+
+	_, err = value.NewString("a").DecodeToArray()
+	require.Error(t, err)
+
+	_, err = value.DecodeArray([]byte{byte(value.String), 10, 'a'})
+	require.Error(t, err)
+}
+
+func TestTimestampOrdering(t *testing.T) {
+	times := []time.Time{
+		{},
+		time.Date(1969, 12, 31, 23, 59, 59, 999999999, time.UTC),
+		time.Unix(0, 0),
+		time.Unix(0, 1),
+		time.Unix(1, 0),
+		time.Date(2262, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	for i := range times {
+		v := value.NewTimestamp(times[i])
+		x, err := v.DecodeToTimestamp()
+		require.NoError(t, err)
+		require.True(t, times[i].Equal(x))
+
+		if i > 0 {
+			require.Equal(t, -1, bytes.Compare(value.EncodeTimestamp(times[i-1]), v.Data))
+		}
+	}
+
+	require.True(t, value.IsZeroValue(value.Timestamp, value.EncodeTimestamp(time.Time{})))
+
+	d, err := value.NewInt64(10).DecodeToDuration()
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Nanosecond, d)
+}
+
 func TestDecodeToBytes(t *testing.T) {
 	tests := []struct {
 		name     string